@@ -1,47 +1,340 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
 	"github.com/gin-gonic/gin"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"log"
 
 	"my_blog/internal/conf"
-	"my_blog/internal/model"
+	"my_blog/internal/database"
+	"my_blog/internal/handler"
+	"my_blog/internal/mail"
+	"my_blog/internal/metrics"
+	"my_blog/internal/middleware"
+	"my_blog/internal/migrate"
+	"my_blog/internal/publisher"
+	"my_blog/internal/ratelimit"
+	"my_blog/internal/realtime"
+	"my_blog/internal/repository"
 	"my_blog/internal/route" // 👈 确保导入了 route 包
+	"my_blog/internal/search"
+	"my_blog/internal/service"
+	"my_blog/internal/storage"
+	"my_blog/internal/util"
+	"my_blog/internal/version"
+)
+
+var (
+	db       *gorm.DB
+	searcher search.Searcher
+	migrator *migrate.Migrator
+	registry = metrics.NewRegistry()
+	// logLevel 当前日志级别，SIGHUP 时热更新
+	logLevel = new(slog.LevelVar)
 )
 
-var db *gorm.DB
+// setup 加载密钥、连接数据库、执行迁移并初始化检索
+func setup(cfg *conf.Config) {
+	if len(cfg.JWT.Keys) == 0 {
+		log.Println("⚠️ 未配置 [jwt] 密钥，使用随机生成的临时密钥：重启后已签发的令牌全部失效，多实例部署时必须配置")
+	} else if err := applyJWT(cfg.JWT); err != nil {
+		log.Fatal("❌ Failed to load JWT keys:", err)
+	}
 
-func init() {
-	// 优先从环境变量读取配置文件路径，否则用默认 config.toml
-	configPath := "conf/mysql.toml"
-	cfg := conf.LoadConfig(configPath)
+	driver := database.Driver(cfg)
+	db = connect(cfg)
+	if err := db.Use(metrics.NewDB(registry)); err != nil {
+		log.Fatal("❌ Failed to register query metrics:", err)
+	}
+	if cfg.File != "" {
+		log.Printf("✅ Connected to %s using %s", driver, cfg.File)
+	} else {
+		log.Printf("✅ Connected to %s using defaults and environment", driver)
+	}
 
-	dsn := cfg.MySQL.DSN()
+	// 多实例同时启动时由迁移锁保证只有一个实例执行迁移
 	var err error
-	db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	migrator, err = migrate.New(db, driver)
+	if err != nil {
+		log.Fatal("❌ Failed to load migrations:", err)
+	}
+	applied, err := migrator.Up(context.Background(), 0)
+	if err != nil {
+		log.Fatal("❌ Failed to migrate database:", err)
+	}
+	for _, mig := range applied {
+		log.Printf("✅ Applied migration %04d_%s", mig.Version, mig.Name)
+	}
+
+	// FULLTEXT 检索仅 MySQL 可用，其他驱动默认使用内存索引
+	engine := cfg.Search.Engine
+	if engine == "" {
+		engine = "memory"
+		if driver == conf.DriverMySQL {
+			engine = "mysql"
+		}
+	}
+	switch engine {
+	case "memory":
+		mem := search.NewMemory()
+		if err := search.Rebuild(context.Background(), mem, db); err != nil {
+			log.Fatal("❌ Failed to build search index:", err)
+		}
+		searcher = mem
+	case "mysql":
+		if searcher, err = search.NewMySQL(db); err != nil {
+			log.Fatal("❌ Failed to open MySQL search:", err)
+		}
+	}
+}
+
+// setupLogging 按配置设置默认 logger，标准库 log 的输出也经由它
+func setupLogging(cfg conf.LogConfig) {
+	applyLogLevel(cfg.Level)
+	opts := &slog.HandlerOptions{Level: logLevel}
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if cfg.Format == "json" {
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(h))
+}
+
+func applyLogLevel(level string) {
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		log.Printf("⚠️ Unknown log level %q: %v", level, err)
+	}
+}
+
+// applyJWT 加载并启用 JWT 密钥
+func applyJWT(cfg conf.JWTConfig) error {
+	ks, err := util.LoadKeySet(cfg)
+	if err != nil {
+		return err
+	}
+	util.SetKeySet(ks)
+	return nil
+}
+
+// watchReload 收到 SIGHUP 时按相同的参数重新加载配置，只更新可热更新的部分
+func watchReload(store *conf.Store, args []string) {
+	store.OnReload(func(cfg *conf.Config) {
+		applyLogLevel(cfg.Log.Level)
+		if len(cfg.JWT.Keys) > 0 {
+			if err := applyJWT(cfg.JWT); err != nil {
+				log.Println("❌ Failed to reload JWT keys, keeping current keys:", err)
+			}
+		}
+	})
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	for range ch {
+		next, _, err := conf.Load(args)
+		if err != nil {
+			log.Println("❌ Failed to reload config, keeping current settings:", err)
+			continue
+		}
+		if ignored := store.Reload(next); len(ignored) > 0 {
+			log.Printf("⚠️ 以下配置修改后需重启才能生效: %s", strings.Join(ignored, ", "))
+		}
+		log.Println("🔄 Configuration reloaded")
+	}
+}
+
+// connect 按配置连接数据库，失败时退出
+func connect(cfg *conf.Config) *gorm.DB {
+	db, err := database.Open(cfg, &gorm.Config{})
 	if err != nil {
-		log.Fatal("❌ Failed to connect to MySQL:", err)
+		log.Fatalf("❌ Failed to connect to %s: %v", database.Driver(cfg), err)
 	}
-	db.AutoMigrate(&model.User{}, &model.Post{}, &model.Comment{})
-	log.Println("✅ Connected to MySQL using config.toml")
+	return db
 }
 
 func main() {
-	r := gin.Default()
-	if err := r.SetTrustedProxies([]string{
-		"127.0.0.1",
-		"::1",
-		"10.0.0.0/8",
-		"172.16.0.0/12",
-		"192.168.0.0/16",
-	}); err != nil {
+	args := os.Args[1:]
+	cfg, rest, err := conf.Load(args)
+	if err != nil {
+		var verr *conf.ValidationError
+		if errors.As(err, &verr) {
+			for _, f := range verr.Fields {
+				log.Printf("❌ config %s", f)
+			}
+			os.Exit(1)
+		}
+		log.Fatal("❌ Failed to load config: ", err)
+	}
+	setupLogging(cfg.Log)
+
+	// my_blog [flags] migrate up|down|status
+	if len(rest) > 0 {
+		if rest[0] != "migrate" {
+			log.Fatalf("❌ Unknown command %q", rest[0])
+		}
+		runMigrate(cfg, rest[1:])
+		return
+	}
+
+	setup(cfg)
+	store := conf.NewStore(cfg)
+	go watchReload(store, args)
+
+	r := gin.New()
+	r.Use(
+		gin.Recovery(),
+		middleware.RequestID(),
+		middleware.Logger(slog.Default()),
+		middleware.Metrics(metrics.NewHTTP(registry)),
+	)
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
 		log.Fatalf("Failed to set trusted proxies: %v", err)
 	}
+	r.Use(middleware.CORS(func() conf.CORSConfig { return store.Get().CORS }))
+
+	health := &handler.HealthHandler{DB: db, Migrations: migrator}
+	route.RegisterHealth(r, health, registry)
+	limiter := &middleware.Limiter{
+		Store:  ratelimit.NewMemory(),
+		Config: func() conf.RateLimitConfig { return store.Get().RateLimit },
+	}
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		log.Fatal("❌ Failed to create mailer:", err)
+	}
+	mailQueue := mail.NewQueue(mailer, cfg.Mail.QueueSize, cfg.Mail.SendTimeout)
+	hub := realtime.NewHub(realtime.Options{
+		ReplayBuffer: cfg.Realtime.ReplayBuffer,
+		ReplayTTL:    cfg.Realtime.ReplayTTL,
+		ClientBuffer: cfg.Realtime.ClientBuffer,
+	})
+	views := service.NewViewCounter(repository.New(db))
+	files, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal("❌ Failed to create storage:", err)
+	}
+	route.RegisterRoutes(r, route.Deps{
+		DB:         db,
+		Searcher:   searcher,
+		Limiter:    limiter,
+		Mailer:     mailer,
+		MailQueue:  mailQueue,
+		BaseURL:    cfg.Mail.BaseURL,
+		Security:   func() conf.SecurityConfig { return store.Get().Security },
+		SIWE:       func() conf.SIWEConfig { return store.Get().SIWE },
+		Hub:        hub,
+		Realtime:   cfg.Realtime,
+		CORS:       func() conf.CORSConfig { return store.Get().CORS },
+		Views:      views,
+		Engagement: cfg.Engagement,
+		Storage:    files,
+		Upload:     cfg.Upload,
+	})
 
-	route.RegisterRoutes(r, db)
+	// SIGTERM / Ctrl+C 时停止接收新请求，等待处理中的请求完成后退出
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
-	log.Println("🚀 Server running on :8080")
-	r.Run(":8080")
+	// 后台定时发布 scheduled 文章
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		publisher.Run(ctx, &service.Posts{Store: repository.New(db), Searcher: searcher, Events: hub}, 30*time.Second)
+	}()
+	// 阅读数定期批量写入数据库
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		views.Run(ctx, cfg.Engagement.ViewFlushInterval)
+	}()
+	// 通知与重置密码邮件在后台逐封发送
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mailQueue.Run(ctx)
+	}()
+	// 定期删除上传后未关联文章的附件
+	uploads := &service.Uploads{Store: repository.New(db), Storage: files, Config: cfg.Upload}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		uploads.RunGC(ctx, cfg.Upload.GCInterval)
+	}()
+
+	// 定期删除过期的令牌与钱包登录 nonce
+	auth := &service.Auth{Store: repository.New(db)}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		auth.RunPurge(ctx, time.Hour)
+	}()
+
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("❌ Server failed: ", err)
+		}
+	}()
+	banner(cfg)
+
+	<-ctx.Done()
+	stop()
+	log.Printf("🛑 Shutting down, draining connections (up to %s)", cfg.Server.ShutdownTimeout)
+	health.Drain()
+	// SSE / WebSocket 长连接不会自行结束，先关闭 Hub 让它们退出，Shutdown 才能等到处理中的请求完成
+	hub.Close()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("⚠️ Forced shutdown with requests still in flight:", err)
+	}
+	wg.Wait()
+
+	// 处理中的请求都已结束，写入最后一批阅读数并发送队列中剩余的邮件
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := views.Flush(flushCtx); err != nil {
+		log.Println("⚠️ Failed to flush view counts:", err)
+	}
+	if err := mailQueue.Flush(flushCtx); err != nil {
+		log.Println("⚠️ Failed to send queued mail:", err)
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Println("⚠️ Failed to close database:", err)
+		}
+	}
+	log.Println("👋 Server stopped")
+}
+
+// banner 启动时输出版本与监听信息
+func banner(cfg *conf.Config) {
+	v := version.Get()
+	orUnknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	log.Printf("🚀 my_blog %s (commit %s, built %s, %s) running on %s with %s",
+		v.Version, orUnknown(v.Commit), orUnknown(v.BuildTime), v.GoVersion, cfg.Server.Addr, database.Driver(cfg))
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"my_blog/internal/conf"
+	"my_blog/internal/database"
+	"my_blog/internal/migrate"
+)
+
+const migrateUsage = `usage: my_blog migrate <command> [-n steps]
+
+commands:
+  up      执行未执行的迁移，-n 限制执行个数（默认全部）
+  down    回滚最近的迁移，-n 指定回滚个数（默认 1）
+  status  列出所有迁移及其执行状态`
+
+// runMigrate 执行 migrate 子命令
+func runMigrate(cfg *conf.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	steps := fs.Int("n", 0, "number of migrations")
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, migrateUsage) }
+	fs.Parse(args[1:])
+
+	m, err := migrate.New(connect(cfg), database.Driver(cfg))
+	if err != nil {
+		log.Fatal("❌ Failed to load migrations:", err)
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx, *steps)
+		for _, mig := range applied {
+			fmt.Printf("applied  %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		reverted, err := m.Down(ctx, *steps)
+		for _, mig := range reverted {
+			fmt.Printf("reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range status {
+			state, at := "pending", ""
+			if s.Applied {
+				state, at = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				state = "modified"
+			}
+			if s.Missing {
+				state = "missing"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, at)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,114 @@
+// Package apperr 领域错误：Kind 决定 HTTP 状态码，Code 供客户端判断并作为多语言消息的 key
+package apperr
+
+import (
+	"errors"
+	"time"
+)
+
+// Kind 错误类别
+type Kind uint8
+
+const (
+	Internal        Kind = iota // 未预期的错误，对外只返回通用消息
+	Invalid                     // 请求参数或业务状态不合法
+	Unauthorized                // 未认证或凭据无效
+	Forbidden                   // 无权操作
+	NotFound                    // 资源不存在
+	Conflict                    // 与现有数据冲突
+	Locked                      // 账号被锁定
+	TooManyRequests             // 请求过于频繁
+	TooLarge                    // 请求体超过大小限制
+	Unsupported                 // 不支持的内容类型
+)
+
+// FieldError 单个请求字段的校验错误
+type FieldError struct {
+	Field string // 请求中的字段名（json / form 标签）
+	Rule  string // 未通过的校验规则，如 required、email、min
+	Param string // 规则参数，如 min=6 中的 6
+}
+
+// Error 领域错误。同一 Code 的错误在 errors.Is 下相等，因此可以用包级变量作为哨兵错误
+type Error struct {
+	Kind       Kind
+	Code       string            // 机器可读的错误码，如 post.not_found
+	Args       map[string]string // 消息模板参数
+	Fields     []FieldError      // 参数校验错误
+	Meta       map[string]any    // 随错误一起返回给客户端的附加数据
+	RetryAfter time.Duration     // 大于 0 时响应带 Retry-After
+	Err        error             // 底层错误，只写日志，不对外展示
+}
+
+// New 创建错误，通常用作包级哨兵
+func New(kind Kind, code string) *Error {
+	return &Error{Kind: kind, Code: code}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Err.Error()
+	}
+	return e.Code
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is 按错误码比较
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func (e *Error) clone() *Error {
+	cp := *e
+	return &cp
+}
+
+// Wrap 返回附带底层错误的副本
+func (e *Error) Wrap(err error) *Error {
+	cp := e.clone()
+	cp.Err = err
+	return cp
+}
+
+// With 返回附带消息参数的副本
+func (e *Error) With(key, value string) *Error {
+	cp := e.clone()
+	cp.Args = make(map[string]string, len(e.Args)+1)
+	for k, v := range e.Args {
+		cp.Args[k] = v
+	}
+	cp.Args[key] = value
+	return cp
+}
+
+// WithMeta 返回附带附加数据的副本
+func (e *Error) WithMeta(key string, value any) *Error {
+	cp := e.clone()
+	cp.Meta = make(map[string]any, len(e.Meta)+1)
+	for k, v := range e.Meta {
+		cp.Meta[k] = v
+	}
+	cp.Meta[key] = value
+	return cp
+}
+
+// WithRetryAfter 返回附带重试等待时间的副本
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	cp := e.clone()
+	cp.RetryAfter = d
+	return cp
+}
+
+// ErrInternal 未预期的内部错误
+var ErrInternal = New(Internal, "internal")
+
+// From 将任意错误转换为 *Error，非领域错误视为内部错误
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return ErrInternal.Wrap(err)
+}
@@ -2,12 +2,22 @@ package conf
 
 import (
 	"fmt"
-	"log"
-	"os"
+	"strings"
+	"time"
+)
 
-	"github.com/BurntSushi/toml"
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
 )
 
+// DatabaseConfig 选择数据库驱动，各驱动的连接参数在各自的配置段中
+type DatabaseConfig struct {
+	Driver string `toml:"driver"` // mysql（默认）、postgres、sqlite
+}
+
 type MySQLConfig struct {
 	Host     string `toml:"host"`
 	Port     int    `toml:"port"`
@@ -17,27 +27,286 @@ type MySQLConfig struct {
 	Charset  string `toml:"charset"`
 }
 
-type Config struct {
-	MySQL MySQLConfig `toml:"mysql"`
+type PostgresConfig struct {
+	Host     string `toml:"host"`
+	Port     int    `toml:"port"`
+	User     string `toml:"user"`
+	Password string `toml:"password"`
+	Database string `toml:"database"`
+	SSLMode  string `toml:"sslmode"`
+	TimeZone string `toml:"timezone"`
+}
+
+type SQLiteConfig struct {
+	Path string `toml:"path"` // 数据库文件路径，":memory:" 为内存库
+}
+
+// JWTKeyConfig 单个 JWT 密钥，ID 即 token 头部的 kid
+type JWTKeyConfig struct {
+	ID             string `toml:"id"`
+	Algorithm      string `toml:"algorithm"`        // HS256 / RS256 / EdDSA
+	Secret         string `toml:"secret"`           // HS256 共享密钥
+	PrivateKeyFile string `toml:"private_key_file"` // RS256 / EdDSA 私钥（PEM），仅签名密钥需要
+	PublicKeyFile  string `toml:"public_key_file"`  // RS256 / EdDSA 公钥（PEM），轮换下来的旧密钥只需公钥
+}
+
+// JWTConfig JWT 签名配置，SigningKey 指定当前用于签名的 kid，其余密钥仅用于验证
+type JWTConfig struct {
+	SigningKey string         `toml:"signing_key"`
+	Keys       []JWTKeyConfig `toml:"keys"`
 }
 
-// LoadConfig 从文件加载配置，默认 config.toml
-func LoadConfig(path string) *Config {
-	if path == "" {
-		path = "config.toml"
+// SearchConfig 全文检索配置
+type SearchConfig struct {
+	Engine string `toml:"engine"` // mysql（FULLTEXT，仅 MySQL 驱动可用）或 memory（进程内倒排索引），默认随驱动选择
+}
+
+// ServerConfig HTTP 服务配置，修改后需重启
+type ServerConfig struct {
+	Addr              string        `toml:"addr"`                // 监听地址
+	TrustedProxies    []string      `toml:"trusted_proxies"`     // 信任的反向代理，用于识别客户端 IP
+	ReadTimeout       time.Duration `toml:"read_timeout"`        // 读取整个请求（含请求体）的超时
+	ReadHeaderTimeout time.Duration `toml:"read_header_timeout"` // 读取请求头的超时
+	WriteTimeout      time.Duration `toml:"write_timeout"`       // 写响应的超时
+	IdleTimeout       time.Duration `toml:"idle_timeout"`        // keep-alive 空闲连接的超时
+	ShutdownTimeout   time.Duration `toml:"shutdown_timeout"`    // 停机时等待处理中请求完成的最长时间
+}
+
+// LogConfig 日志配置，Level 可热更新
+type LogConfig struct {
+	Level  string `toml:"level"`  // debug / info / warn / error
+	Format string `toml:"format"` // json / text，修改后需重启
+}
+
+// CORSConfig 跨域配置，AllowOrigins 为空时不处理跨域请求，"*" 允许任意来源
+type CORSConfig struct {
+	AllowOrigins     []string      `toml:"allow_origins"`
+	AllowMethods     []string      `toml:"allow_methods"`
+	AllowHeaders     []string      `toml:"allow_headers"`
+	AllowCredentials bool          `toml:"allow_credentials"`
+	MaxAge           time.Duration `toml:"max_age"` // 预检结果缓存时间
+}
+
+// AllowOrigin 来源是否在跨域白名单中（"*" 匹配任意来源）
+func (c CORSConfig) AllowOrigin(origin string) bool {
+	for _, o := range c.AllowOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
 	}
+	return false
+}
+
+// RateLimitRule 令牌桶参数，Rate 为 0 时该组不限流
+type RateLimitRule struct {
+	Rate  float64 `toml:"rate"`  // 每秒补充的令牌数
+	Burst int     `toml:"burst"` // 桶容量
+}
+
+// 限流路由组
+const (
+	RateLimitPublic    = "public"    // 公开接口，按 IP
+	RateLimitAuth      = "auth"      // 登录、注册、刷新令牌，按 IP
+	RateLimitProtected = "protected" // 登录后接口，按用户
+	RateLimitComment   = "comment"   // 发表评论，按用户
+)
+
+// RateLimitConfig 限流配置，各路由组独立计数
+type RateLimitConfig struct {
+	Enabled   bool          `toml:"enabled"`
+	Public    RateLimitRule `toml:"public"`
+	Auth      RateLimitRule `toml:"auth"`
+	Protected RateLimitRule `toml:"protected"`
+	Comment   RateLimitRule `toml:"comment"`
+}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		log.Fatalf("❌ Config file not found: %s", path)
+// Rule 返回路由组的规则，未知的组不限流
+func (c RateLimitConfig) Rule(group string) RateLimitRule {
+	switch group {
+	case RateLimitPublic:
+		return c.Public
+	case RateLimitAuth:
+		return c.Auth
+	case RateLimitProtected:
+		return c.Protected
+	case RateLimitComment:
+		return c.Comment
 	}
+	return RateLimitRule{}
+}
+
+// MailConfig 邮件配置
+type MailConfig struct {
+	Driver  string `toml:"driver"`   // log（写日志）/ file（写 .eml 文件）
+	Dir     string `toml:"dir"`      // file 驱动的输出目录
+	From    string `toml:"from"`     // 发件人
+	BaseURL string `toml:"base_url"` // 邮件中链接指向的站点地址
+
+	QueueSize   int           `toml:"queue_size"`   // 后台邮件发送队列的容量，队列满时丢弃新邮件
+	SendTimeout time.Duration `toml:"send_timeout"` // 后台发送每封邮件的超时
+}
 
-	var cfg Config
-	_, err := toml.DecodeFile(path, &cfg)
-	if err != nil {
-		log.Fatalf("❌ Failed to parse config file: %v", err)
+// SecurityConfig 账号安全配置，可热更新
+type SecurityConfig struct {
+	MaxFailedLogins    int           `toml:"max_failed_logins"`    // 连续登录失败多少次后锁定账号，0 为不锁定
+	LockoutDuration    time.Duration `toml:"lockout_duration"`     // 首次锁定时长，之后每次再失败翻倍
+	MaxLockoutDuration time.Duration `toml:"max_lockout_duration"` // 锁定时长上限
+	PasswordResetTTL   time.Duration `toml:"password_reset_ttl"`   // 重置密码链接有效期
+	MFAIssuer          string        `toml:"mfa_issuer"`           // 验证器 App 中显示的站点名称
+}
+
+// Lockout 返回连续失败 failures 次后应锁定的时长，未达到阈值时为 0
+func (s SecurityConfig) Lockout(failures int) time.Duration {
+	if s.MaxFailedLogins <= 0 || failures < s.MaxFailedLogins {
+		return 0
+	}
+	d := s.LockoutDuration
+	for i := s.MaxFailedLogins; i < failures && d < s.MaxLockoutDuration; i++ {
+		d *= 2
+	}
+	if d > s.MaxLockoutDuration {
+		d = s.MaxLockoutDuration
 	}
+	return d
+}
+
+// SIWEConfig 以太坊钱包登录（EIP-4361）配置，可热更新
+type SIWEConfig struct {
+	Domain   string        `toml:"domain"`    // 签名消息中必须出现的域名（host[:port]），应与前端站点一致
+	NonceTTL time.Duration `toml:"nonce_ttl"` // nonce 有效期
+}
+
+// RealtimeConfig 实时推送（SSE / WebSocket）配置，修改后需重启
+type RealtimeConfig struct {
+	ReplayBuffer int           `toml:"replay_buffer"` // 每个主题保留的最近事件数，供断线重连补发
+	ReplayTTL    time.Duration `toml:"replay_ttl"`    // 无人订阅的主题在最后一条事件后保留多久
+	ClientBuffer int           `toml:"client_buffer"` // 单个连接允许积压的事件数，超出后断开慢客户端
+	MaxTopics    int           `toml:"max_topics"`    // 单个 WebSocket 连接最多订阅的主题数
+	Heartbeat    time.Duration `toml:"heartbeat"`     // 心跳间隔
+	WriteTimeout time.Duration `toml:"write_timeout"` // 单次写出的超时
+}
+
+// EngagementConfig 点赞、阅读数与热门榜配置，修改后需重启
+type EngagementConfig struct {
+	ViewFlushInterval time.Duration `toml:"view_flush_interval"` // 阅读数在内存中累积多久后批量写入数据库
+	TrendingWindow    time.Duration `toml:"trending_window"`     // 热门榜只统计这段时间内发布的文章
+}
 
-	return &cfg
+// StorageConfig 上传文件的存储后端，修改后需重启
+type StorageConfig struct {
+	Driver    string   `toml:"driver"`     // local（默认）/ s3
+	Dir       string   `toml:"dir"`        // local 驱动的存放目录
+	URLPrefix string   `toml:"url_prefix"` // local 驱动的访问路径，由本服务以静态文件提供
+	S3        S3Config `toml:"s3"`
+}
+
+// S3Config S3 兼容对象存储的连接参数
+type S3Config struct {
+	Endpoint  string `toml:"endpoint"` // 如 https://s3.us-east-1.amazonaws.com、http://localhost:9000
+	Region    string `toml:"region"`
+	Bucket    string `toml:"bucket"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	PathStyle bool   `toml:"path_style"` // 使用 endpoint/bucket/key 形式的地址，MinIO 通常需要开启
+	PublicURL string `toml:"public_url"` // 对外访问地址前缀（如 CDN），为空时使用对象的直接地址
+}
+
+// UploadConfig 上传限制与附件清理，修改后需重启
+type UploadConfig struct {
+	MaxSize       int           `toml:"max_size"`       // 单个文件的最大字节数
+	AllowedTypes  []string      `toml:"allowed_types"`  // 允许的类型，按文件头识别，不信任客户端声明
+	MaxPixels     int           `toml:"max_pixels"`     // 图片的最大像素数，超过时拒绝解码，防止解压炸弹
+	ThumbnailSize int           `toml:"thumbnail_size"` // 缩略图最长边的像素数
+	OrphanTTL     time.Duration `toml:"orphan_ttl"`     // 未关联文章的附件保留多久后删除
+	GCInterval    time.Duration `toml:"gc_interval"`    // 清理未关联附件的间隔
+}
+
+type Config struct {
+	Server     ServerConfig     `toml:"server"`
+	Database   DatabaseConfig   `toml:"database"`
+	MySQL      MySQLConfig      `toml:"mysql"`
+	Postgres   PostgresConfig   `toml:"postgres"`
+	SQLite     SQLiteConfig     `toml:"sqlite"`
+	JWT        JWTConfig        `toml:"jwt"`
+	Search     SearchConfig     `toml:"search"`
+	Log        LogConfig        `toml:"log"`
+	CORS       CORSConfig       `toml:"cors"`
+	RateLimit  RateLimitConfig  `toml:"rate_limit"`
+	Mail       MailConfig       `toml:"mail"`
+	Security   SecurityConfig   `toml:"security"`
+	SIWE       SIWEConfig       `toml:"siwe"`
+	Realtime   RealtimeConfig   `toml:"realtime"`
+	Engagement EngagementConfig `toml:"engagement"`
+	Storage    StorageConfig    `toml:"storage"`
+	Upload     UploadConfig     `toml:"upload"`
+
+	File string `toml:"-"` // 实际加载的配置文件，未使用配置文件时为空
+}
+
+// Defaults 返回默认配置，是配置合并的第一层
+func Defaults() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			TrustedProxies:    []string{"127.0.0.1", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"},
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: DatabaseConfig{Driver: DriverMySQL},
+		MySQL:    MySQLConfig{Host: "localhost", Port: 3306, User: "root", Database: "blog", Charset: "utf8mb4"},
+		Postgres: PostgresConfig{Host: "localhost", Port: 5432, User: "postgres", Database: "blog", SSLMode: "disable"},
+		SQLite:   SQLiteConfig{Path: "blog.db"},
+		Log:      LogConfig{Level: "info", Format: "json"},
+		Mail: MailConfig{
+			Driver:      "log",
+			Dir:         "mail",
+			From:        "no-reply@my-blog.local",
+			BaseURL:     "http://localhost:8080",
+			QueueSize:   1000,
+			SendTimeout: 30 * time.Second,
+		},
+		Security: SecurityConfig{
+			MaxFailedLogins:    5,
+			LockoutDuration:    time.Minute,
+			MaxLockoutDuration: time.Hour,
+			PasswordResetTTL:   30 * time.Minute,
+			MFAIssuer:          "my_blog",
+		},
+		SIWE: SIWEConfig{Domain: "localhost:8080", NonceTTL: 10 * time.Minute},
+		Realtime: RealtimeConfig{
+			ReplayBuffer: 256,
+			ReplayTTL:    10 * time.Minute,
+			ClientBuffer: 64,
+			MaxTopics:    50,
+			Heartbeat:    25 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		},
+		Engagement: EngagementConfig{ViewFlushInterval: 10 * time.Second, TrendingWindow: 7 * 24 * time.Hour},
+		Storage:    StorageConfig{Driver: "local", Dir: "uploads", URLPrefix: "/uploads", S3: S3Config{Region: "us-east-1"}},
+		Upload: UploadConfig{
+			MaxSize:       10 << 20,
+			AllowedTypes:  []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+			MaxPixels:     40_000_000,
+			ThumbnailSize: 320,
+			OrphanTTL:     24 * time.Hour,
+			GCInterval:    time.Hour,
+		},
+		CORS: CORSConfig{
+			AllowMethods: []string{"GET", "POST", "OPTIONS"},
+			AllowHeaders: []string{"Authorization", "Content-Type"},
+			MaxAge:       12 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   true,
+			Public:    RateLimitRule{Rate: 5, Burst: 20},
+			Auth:      RateLimitRule{Rate: 0.2, Burst: 5},
+			Protected: RateLimitRule{Rate: 10, Burst: 40},
+			Comment:   RateLimitRule{Rate: 0.1, Burst: 3},
+		},
+	}
 }
 
 // DSN 生成 MySQL 连接字符串
@@ -46,3 +315,16 @@ func (m *MySQLConfig) DSN() string {
 		fmt.Sprint(m.Port) + ")/" + m.Database +
 		"?charset=" + m.Charset + "&parseTime=True&loc=Local"
 }
+
+// DSN 生成 PostgreSQL 连接字符串
+func (p *PostgresConfig) DSN() string {
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s",
+		p.Host, p.Port, p.User, p.Password, p.Database)
+	if p.SSLMode != "" {
+		dsn += " sslmode=" + p.SSLMode
+	}
+	if p.TimeZone != "" {
+		dsn += " TimeZone=" + p.TimeZone
+	}
+	return dsn
+}
@@ -0,0 +1,211 @@
+package conf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	path := writeConfig(t, `
+[server]
+addr = ":9000"
+
+[database]
+driver = "sqlite"
+
+[sqlite]
+path = "file.db"
+
+[log]
+level = "warn"
+
+[cors]
+allow_origins = ["https://a.example.com"]
+max_age = "1h"
+`)
+	cfg, rest, err := load(
+		[]string{"-config", path, "-log-level", "debug", "-set", "rate_limit.public.burst=7", "migrate", "up"},
+		env(map[string]string{
+			"MY_BLOG_SQLITE_PATH":        "env.db",
+			"MY_BLOG_LOG_LEVEL":          "error",
+			"MY_BLOG_CORS_ALLOW_ORIGINS": "https://b.example.com, https://c.example.com",
+			"MY_BLOG_RATE_LIMIT_ENABLED": "false",
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.File != path || len(rest) != 2 || rest[0] != "migrate" {
+		t.Errorf("unexpected file %q or rest %v", cfg.File, rest)
+	}
+	// 默认值
+	if cfg.MySQL.Port != 3306 || cfg.RateLimit.Protected.Burst != 40 {
+		t.Errorf("defaults not applied: %+v %+v", cfg.MySQL, cfg.RateLimit)
+	}
+	// 配置文件
+	if cfg.Server.Addr != ":9000" || cfg.Database.Driver != DriverSQLite || cfg.CORS.MaxAge != time.Hour {
+		t.Errorf("file values not applied: %+v", cfg)
+	}
+	// 环境变量覆盖配置文件
+	if cfg.SQLite.Path != "env.db" || cfg.RateLimit.Enabled ||
+		len(cfg.CORS.AllowOrigins) != 2 || cfg.CORS.AllowOrigins[1] != "https://c.example.com" {
+		t.Errorf("env values not applied: %+v %+v", cfg.SQLite, cfg.CORS)
+	}
+	// 命令行覆盖环境变量
+	if cfg.Log.Level != "debug" || cfg.RateLimit.Public.Burst != 7 {
+		t.Errorf("flag values not applied: %+v %+v", cfg.Log, cfg.RateLimit.Public)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	// 显式指定的文件必须存在
+	_, _, err := load([]string{"-config", filepath.Join(t.TempDir(), "missing.toml")}, env(nil))
+	var ferr *FileError
+	if !errors.As(err, &ferr) {
+		t.Errorf("expected *FileError, got %v", err)
+	}
+
+	_, _, err = load(nil, env(map[string]string{"MY_BLOG_MYSQL_PORT": "abc"}))
+	var oerr *OverrideError
+	if !errors.As(err, &oerr) || oerr.Key != "mysql.port" {
+		t.Errorf("expected *OverrideError for mysql.port, got %v", err)
+	}
+
+	_, _, err = load([]string{"-set", "nope.key=1"}, env(nil))
+	if !errors.As(err, &oerr) || !errors.Is(err, errUnknownKey) {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+
+	path := writeConfig(t, `
+[database]
+driver = "postgres"
+
+[postgres]
+host = ""
+
+[search]
+engine = "mysql"
+
+[jwt]
+signing_key = "missing"
+
+[[jwt.keys]]
+id = "k1"
+algorithm = "HS256"
+`)
+	_, _, err = load([]string{"-config", path, "-set", "rate_limit.public.rate=-1", "-set", "rate_limit.comment.burst=0", "-set", "security.max_lockout_duration=1s", "-set", "realtime.client_buffer=0", "-set", "realtime.heartbeat=0s", "-set", "engagement.trending_window=0s", "-set", "storage.driver=s3", "-set", "upload.allowed_types=image/png,text/html"}, env(nil))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	for _, key := range []string{"postgres.host", "search.engine", "jwt.keys[0].secret", "jwt.signing_key", "rate_limit.public", "rate_limit.comment.burst", "security.max_lockout_duration", "realtime.client_buffer", "realtime.heartbeat", "engagement.trending_window", "storage.s3.bucket", "upload.allowed_types[1]"} {
+		if !verr.Has(key) {
+			t.Errorf("expected error for %s in %v", key, verr)
+		}
+	}
+	if verr.Has("rate_limit.protected") {
+		t.Errorf("unexpected error for rate_limit.protected in %v", verr)
+	}
+}
+
+func TestJWTSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		valid  bool
+	}{
+		{name: "占位密钥", secret: "your-secret-key"},
+		{name: "过短", secret: "0123456789abcdef0123456789abcde"},
+		{name: "32 字节", secret: "0123456789abcdef0123456789abcdef", valid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, `
+[database]
+driver = "sqlite"
+
+[[jwt.keys]]
+id = "k1"
+algorithm = "HS256"
+secret = "`+tt.secret+`"
+`)
+			_, _, err := load([]string{"-config", path}, env(nil))
+			var verr *ValidationError
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+			} else if !errors.As(err, &verr) || !verr.Has("jwt.keys[0].secret") {
+				t.Fatalf("expected error for jwt.keys[0].secret, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreReload(t *testing.T) {
+	cfg := Defaults()
+	store := NewStore(cfg)
+
+	var notified *Config
+	store.OnReload(func(c *Config) { notified = c })
+
+	next := Defaults()
+	next.Server.Addr = ":9999"
+	next.Log.Level = "debug"
+	next.Log.Format = "text"
+	next.CORS.AllowOrigins = []string{"*"}
+
+	ignored := store.Reload(next)
+	if len(ignored) != 2 || ignored[0] != "server" || ignored[1] != "log.format" {
+		t.Errorf("unexpected ignored sections %v", ignored)
+	}
+
+	got := store.Get()
+	if got != notified {
+		t.Error("expected subscribers to receive the new config")
+	}
+	if got.Server.Addr != ":8080" || got.Log.Format != "json" {
+		t.Errorf("structural settings should be kept: %+v %+v", got.Server, got.Log)
+	}
+	if got.Log.Level != "debug" || len(got.CORS.AllowOrigins) != 1 {
+		t.Errorf("reloadable settings should be applied: %+v %+v", got.Log, got.CORS)
+	}
+	if cfg.Log.Level != "info" {
+		t.Error("previous config must not be mutated")
+	}
+}
+
+func TestSecurityLockout(t *testing.T) {
+	s := SecurityConfig{MaxFailedLogins: 3, LockoutDuration: time.Minute, MaxLockoutDuration: 5 * time.Minute}
+	for failures, want := range map[int]time.Duration{
+		0: 0, 2: 0, 3: time.Minute, 4: 2 * time.Minute, 5: 4 * time.Minute, 6: 5 * time.Minute, 100: 5 * time.Minute,
+	} {
+		if got := s.Lockout(failures); got != want {
+			t.Errorf("Lockout(%d) = %v, want %v", failures, got, want)
+		}
+	}
+	s.MaxFailedLogins = 0
+	if got := s.Lockout(100); got != 0 {
+		t.Errorf("lockout disabled, got %v", got)
+	}
+}
@@ -0,0 +1,206 @@
+package conf
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	// DefaultFile 默认配置文件，不存在时只使用默认值与环境变量
+	DefaultFile = "conf/mysql.toml"
+	// EnvPrefix 环境变量前缀，如 MY_BLOG_MYSQL_PASSWORD 对应 mysql.password
+	EnvPrefix = "MY_BLOG_"
+)
+
+// FileError 配置文件读取或解析失败
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string { return fmt.Sprintf("config file %s: %v", e.Path, e.Err) }
+func (e *FileError) Unwrap() error { return e.Err }
+
+// OverrideError 环境变量或命令行覆盖的值无法解析
+type OverrideError struct {
+	Source string // 环境变量名或命令行参数
+	Key    string
+	Err    error
+}
+
+func (e *OverrideError) Error() string {
+	return fmt.Sprintf("%s: invalid value for %s: %v", e.Source, e.Key, e.Err)
+}
+func (e *OverrideError) Unwrap() error { return e.Err }
+
+var errUnknownKey = errors.New("unknown config key")
+
+// Load 依次合并 默认值 → 配置文件 → 环境变量 → 命令行参数，并校验结果。
+// args 为去掉程序名的命令行参数，返回值中的 rest 为参数解析后剩余的子命令
+func Load(args []string) (cfg *Config, rest []string, err error) {
+	return load(args, os.LookupEnv)
+}
+
+// sets 可重复的 -set key=value 参数
+type sets []string
+
+func (s *sets) String() string     { return strings.Join(*s, ",") }
+func (s *sets) Set(v string) error { *s = append(*s, v); return nil }
+
+func load(args []string, lookupEnv func(string) (string, bool)) (*Config, []string, error) {
+	fs := flag.NewFlagSet("my_blog", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var (
+		file      = fs.String("config", "", "config file (env "+EnvPrefix+"CONFIG, default "+DefaultFile+")")
+		addr      = fs.String("addr", "", "server listen address")
+		driver    = fs.String("driver", "", "database driver: mysql / postgres / sqlite")
+		logLevel  = fs.String("log-level", "", "log level: debug / info / warn / error")
+		overrides sets
+	)
+	fs.Var(&overrides, "set", "override any setting, e.g. -set mysql.host=db (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := Defaults()
+
+	// 配置文件：显式指定时必须存在，默认文件可缺省
+	path, explicit := *file, *file != ""
+	if !explicit {
+		path, explicit = lookupEnv(EnvPrefix + "CONFIG")
+	}
+	if !explicit {
+		path = DefaultFile
+	}
+	if _, err := os.Stat(path); err == nil || explicit {
+		if _, err := toml.DecodeFile(path, cfg); err != nil {
+			return nil, nil, &FileError{Path: path, Err: err}
+		}
+		cfg.File = path
+	}
+
+	// 环境变量
+	var err error
+	walk(reflect.ValueOf(cfg).Elem(), "", func(key string, v reflect.Value) {
+		name := EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_").Replace(key))
+		if raw, ok := lookupEnv(name); ok && err == nil {
+			if e := setValue(v, raw); e != nil {
+				err = &OverrideError{Source: name, Key: key, Err: e}
+			}
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 命令行参数
+	for _, kv := range []struct{ key, value string }{
+		{"server.addr", *addr}, {"database.driver", *driver}, {"log.level", *logLevel},
+	} {
+		if kv.value != "" {
+			overrides = append(overrides, kv.key+"="+kv.value)
+		}
+	}
+	for _, o := range overrides {
+		key, value, ok := strings.Cut(o, "=")
+		if !ok {
+			return nil, nil, &OverrideError{Source: "-set " + o, Key: o, Err: errors.New("expected key=value")}
+		}
+		if err := Set(cfg, key, value); err != nil {
+			return nil, nil, &OverrideError{Source: "-set " + o, Key: key, Err: err}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// Set 按 "段.键" 设置单个配置项，如 Set(cfg, "rate_limit.public.burst", "20")
+func Set(cfg *Config, key, value string) error {
+	err := errUnknownKey
+	walk(reflect.ValueOf(cfg).Elem(), "", func(k string, v reflect.Value) {
+		if k == key {
+			err = setValue(v, value)
+		}
+	})
+	return err
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// walk 遍历可由字符串设置的配置项，key 为按 toml 标签拼接的路径
+func walk(v reflect.Value, prefix string, fn func(key string, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("toml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := prefix + tag
+		f := v.Field(i)
+		switch {
+		case f.Kind() == reflect.Struct:
+			walk(f, key+".", fn)
+		case f.Kind() == reflect.Slice && f.Type().Elem().Kind() != reflect.String:
+			// [[jwt.keys]] 等结构数组只能在配置文件中设置
+		default:
+			fn(key, f)
+		}
+	}
+}
+
+// setValue 把字符串解析为字段类型，字符串数组以逗号分隔
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
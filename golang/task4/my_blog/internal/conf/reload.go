@@ -0,0 +1,66 @@
+package conf
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Store 保存当前生效的配置，支持运行时热更新
+type Store struct {
+	cur  atomic.Pointer[Config]
+	mu   sync.Mutex
+	subs []func(*Config)
+}
+
+// NewStore 以初始配置创建 Store
+func NewStore(cfg *Config) *Store {
+	s := &Store{}
+	s.cur.Store(cfg)
+	return s
+}
+
+// Get 返回当前配置，调用方不得修改
+func (s *Store) Get() *Config { return s.cur.Load() }
+
+// OnReload 注册配置更新后的回调
+func (s *Store) OnReload(fn func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = append(s.subs, fn)
+}
+
+// Reload 应用新配置中可热更新的部分（JWT 密钥、日志级别、CORS、限流、账号安全、钱包登录）。
+// 数据库、监听地址、检索引擎、邮件、实时推送、互动计数、文件存储与上传、日志格式等结构性配置保持原值，返回被忽略的配置段
+func (s *Store) Reload(next *Config) (ignored []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.cur.Load()
+	merged := *next
+	keep := func(name string, dst, src interface{}) {
+		d, o := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+		if !reflect.DeepEqual(d.Interface(), o.Interface()) {
+			ignored = append(ignored, name)
+			d.Set(o)
+		}
+	}
+	keep("server", &merged.Server, &old.Server)
+	keep("database", &merged.Database, &old.Database)
+	keep("mysql", &merged.MySQL, &old.MySQL)
+	keep("postgres", &merged.Postgres, &old.Postgres)
+	keep("sqlite", &merged.SQLite, &old.SQLite)
+	keep("search", &merged.Search, &old.Search)
+	keep("mail", &merged.Mail, &old.Mail)
+	keep("realtime", &merged.Realtime, &old.Realtime)
+	keep("engagement", &merged.Engagement, &old.Engagement)
+	keep("storage", &merged.Storage, &old.Storage)
+	keep("upload", &merged.Upload, &old.Upload)
+	keep("log.format", &merged.Log.Format, &old.Log.Format)
+
+	s.cur.Store(&merged)
+	for _, fn := range s.subs {
+		fn(&merged)
+	}
+	return ignored
+}
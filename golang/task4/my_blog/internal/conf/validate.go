@@ -0,0 +1,286 @@
+package conf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FieldError 单个配置项的校验错误
+type FieldError struct {
+	Key     string // 如 mysql.host
+	Message string
+}
+
+func (e FieldError) Error() string { return e.Key + ": " + e.Message }
+
+// ValidationError 配置校验失败，包含全部不合法的配置项
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// Has 是否包含某个配置项的错误
+func (e *ValidationError) Has(key string) bool {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate 校验配置，不合法时返回 *ValidationError
+func (c *Config) Validate() error {
+	var errs []FieldError
+	add := func(key, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+	required := func(key, value string) {
+		if value == "" {
+			add(key, "is required")
+		}
+	}
+
+	required("server.addr", c.Server.Addr)
+	for _, d := range []struct {
+		key string
+		val time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+	} {
+		if d.val < 0 {
+			add(d.key, "must not be negative")
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("server.shutdown_timeout", "must be positive")
+	}
+
+	switch c.Database.Driver {
+	case DriverMySQL:
+		required("mysql.host", c.MySQL.Host)
+		required("mysql.user", c.MySQL.User)
+		required("mysql.database", c.MySQL.Database)
+	case DriverPostgres:
+		required("postgres.host", c.Postgres.Host)
+		required("postgres.user", c.Postgres.User)
+		required("postgres.database", c.Postgres.Database)
+	case DriverSQLite:
+		required("sqlite.path", c.SQLite.Path)
+	default:
+		add("database.driver", "unsupported driver %q", c.Database.Driver)
+	}
+
+	switch c.Search.Engine {
+	case "", "memory":
+	case "mysql":
+		if c.Database.Driver != DriverMySQL {
+			add("search.engine", "mysql engine requires the mysql driver")
+		}
+	default:
+		add("search.engine", "unknown engine %q", c.Search.Engine)
+	}
+
+	c.JWT.validate(add)
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level", "unknown level %q", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		add("log.format", "unknown format %q", c.Log.Format)
+	}
+
+	if c.CORS.AllowCredentials {
+		for _, o := range c.CORS.AllowOrigins {
+			if o == "*" {
+				add("cors.allow_origins", `"*" cannot be used with allow_credentials`)
+			}
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		add("cors.max_age", "must not be negative")
+	}
+
+	for _, group := range []string{RateLimitPublic, RateLimitAuth, RateLimitProtected, RateLimitComment} {
+		r := c.RateLimit.Rule(group)
+		if r.Rate < 0 || r.Burst < 0 {
+			add("rate_limit."+group, "rate and burst must not be negative")
+		} else if r.Rate > 0 && r.Burst < 1 {
+			add("rate_limit."+group+".burst", "must be at least 1 when rate is set")
+		}
+	}
+
+	switch c.Mail.Driver {
+	case "log":
+	case "file":
+		required("mail.dir", c.Mail.Dir)
+	default:
+		add("mail.driver", "unknown driver %q", c.Mail.Driver)
+	}
+	required("mail.from", c.Mail.From)
+	if !strings.HasPrefix(c.Mail.BaseURL, "http://") && !strings.HasPrefix(c.Mail.BaseURL, "https://") {
+		add("mail.base_url", "must be an http(s) URL")
+	}
+	if c.Mail.QueueSize < 1 {
+		add("mail.queue_size", "must be at least 1")
+	}
+	if c.Mail.SendTimeout <= 0 {
+		add("mail.send_timeout", "must be positive")
+	}
+
+	if c.Security.MaxFailedLogins < 0 {
+		add("security.max_failed_logins", "must not be negative")
+	}
+	if c.Security.MaxFailedLogins > 0 {
+		if c.Security.LockoutDuration <= 0 {
+			add("security.lockout_duration", "must be positive")
+		}
+		if c.Security.MaxLockoutDuration < c.Security.LockoutDuration {
+			add("security.max_lockout_duration", "must not be less than lockout_duration")
+		}
+	}
+	if c.Security.PasswordResetTTL <= 0 {
+		add("security.password_reset_ttl", "must be positive")
+	}
+	required("security.mfa_issuer", c.Security.MFAIssuer)
+
+	required("siwe.domain", c.SIWE.Domain)
+	if strings.Contains(c.SIWE.Domain, "://") {
+		add("siwe.domain", "must be host[:port] without scheme")
+	}
+	if c.SIWE.NonceTTL <= 0 {
+		add("siwe.nonce_ttl", "must be positive")
+	}
+
+	if c.Realtime.ReplayBuffer < 0 {
+		add("realtime.replay_buffer", "must not be negative")
+	}
+	if c.Realtime.ReplayTTL < 0 {
+		add("realtime.replay_ttl", "must not be negative")
+	}
+	if c.Realtime.ClientBuffer < 1 {
+		add("realtime.client_buffer", "must be at least 1")
+	}
+	if c.Realtime.MaxTopics < 1 {
+		add("realtime.max_topics", "must be at least 1")
+	}
+	if c.Realtime.Heartbeat <= 0 {
+		add("realtime.heartbeat", "must be positive")
+	}
+	if c.Realtime.WriteTimeout <= 0 {
+		add("realtime.write_timeout", "must be positive")
+	}
+
+	if c.Engagement.ViewFlushInterval <= 0 {
+		add("engagement.view_flush_interval", "must be positive")
+	}
+	if c.Engagement.TrendingWindow <= 0 {
+		add("engagement.trending_window", "must be positive")
+	}
+
+	switch c.Storage.Driver {
+	case "local":
+		required("storage.dir", c.Storage.Dir)
+		if !strings.HasPrefix(c.Storage.URLPrefix, "/") || c.Storage.URLPrefix == "/" {
+			add("storage.url_prefix", "must be a path such as /uploads")
+		}
+	case "s3":
+		required("storage.s3.endpoint", c.Storage.S3.Endpoint)
+		required("storage.s3.region", c.Storage.S3.Region)
+		required("storage.s3.bucket", c.Storage.S3.Bucket)
+		required("storage.s3.access_key", c.Storage.S3.AccessKey)
+		required("storage.s3.secret_key", c.Storage.S3.SecretKey)
+	default:
+		add("storage.driver", "unknown driver %q", c.Storage.Driver)
+	}
+
+	if c.Upload.MaxSize < 1 {
+		add("upload.max_size", "must be at least 1")
+	}
+	if len(c.Upload.AllowedTypes) == 0 {
+		add("upload.allowed_types", "is required")
+	}
+	for i, t := range c.Upload.AllowedTypes {
+		switch t {
+		case "image/jpeg", "image/png", "image/gif", "image/webp":
+		default:
+			add(fmt.Sprintf("upload.allowed_types[%d]", i), "unsupported type %q", t)
+		}
+	}
+	if c.Upload.MaxPixels < 1 {
+		add("upload.max_pixels", "must be at least 1")
+	}
+	if c.Upload.ThumbnailSize < 1 {
+		add("upload.thumbnail_size", "must be at least 1")
+	}
+	if c.Upload.OrphanTTL <= 0 {
+		add("upload.orphan_ttl", "must be positive")
+	}
+	if c.Upload.GCInterval <= 0 {
+		add("upload.gc_interval", "must be positive")
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+// minHS256SecretLen HS256 共享密钥的最短字节数，与 SHA-256 的输出长度一致
+const minHS256SecretLen = 32
+
+// placeholderSecrets 示例配置和文档中出现过的占位密钥，不能用于签名
+var placeholderSecrets = []string{"your-secret-key"}
+
+func (j *JWTConfig) validate(add func(key, format string, args ...interface{})) {
+	if len(j.Keys) == 0 {
+		return
+	}
+	ids := make(map[string]bool, len(j.Keys))
+	for i, k := range j.Keys {
+		key := fmt.Sprintf("jwt.keys[%d]", i)
+		if k.ID == "" {
+			add(key+".id", "is required")
+		} else if ids[k.ID] {
+			add(key+".id", "duplicate key id %q", k.ID)
+		}
+		ids[k.ID] = true
+
+		switch k.Algorithm {
+		case "HS256":
+			switch {
+			case k.Secret == "":
+				add(key+".secret", "is required for HS256")
+			case slices.Contains(placeholderSecrets, k.Secret):
+				add(key+".secret", "is a placeholder, generate a random secret")
+			case len(k.Secret) < minHS256SecretLen:
+				add(key+".secret", "must be at least %d bytes", minHS256SecretLen)
+			}
+		case "RS256", "EdDSA":
+			if k.PrivateKeyFile == "" && k.PublicKeyFile == "" {
+				add(key, "private_key_file or public_key_file is required for %s", k.Algorithm)
+			}
+		default:
+			add(key+".algorithm", "unsupported algorithm %q", k.Algorithm)
+		}
+	}
+	if j.SigningKey != "" && !ids[j.SigningKey] {
+		add("jwt.signing_key", "unknown key id %q", j.SigningKey)
+	}
+}
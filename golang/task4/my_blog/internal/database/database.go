@@ -0,0 +1,62 @@
+// Package database 按配置选择数据库驱动并建立 gorm 连接
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"my_blog/internal/conf"
+)
+
+// Driver 返回配置的驱动名，未配置时为 mysql
+func Driver(cfg *conf.Config) string {
+	if cfg.Database.Driver == "" {
+		return conf.DriverMySQL
+	}
+	return cfg.Database.Driver
+}
+
+// Open 按 cfg.Database.Driver 打开数据库连接
+func Open(cfg *conf.Config, gormCfg *gorm.Config) (*gorm.DB, error) {
+	switch driver := Driver(cfg); driver {
+	case conf.DriverMySQL:
+		return gorm.Open(mysql.Open(cfg.MySQL.DSN()), gormCfg)
+	case conf.DriverPostgres:
+		return gorm.Open(postgres.Open(cfg.Postgres.DSN()), gormCfg)
+	case conf.DriverSQLite:
+		return OpenSQLite(cfg.SQLite.Path, gormCfg)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+// OpenSQLite 打开 SQLite 数据库并开启外键约束。SQLite 只允许单个写连接，
+// 内存库在每个连接上都是独立的，因此连接池限制为 1
+func OpenSQLite(path string, gormCfg *gorm.Config) (*gorm.DB, error) {
+	if path == "" {
+		path = "my_blog.db"
+	}
+	db, err := gorm.Open(sqlite.Open(path+sqliteParams(path)), gormCfg)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return db, nil
+}
+
+func sqliteParams(path string) string {
+	const params = "_foreign_keys=on&_busy_timeout=5000"
+	if strings.Contains(path, "?") {
+		return "&" + params
+	}
+	return "?" + params
+}
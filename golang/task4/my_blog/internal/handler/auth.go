@@ -2,95 +2,58 @@ package handler
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 
-	"my_blog/internal/model"
-	"my_blog/internal/util"
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
 )
 
 type AuthHandler struct {
-	DB *gorm.DB
+	Auth *service.Auth
 }
 
 // Register 用户注册
 func (h *AuthHandler) Register(c *gin.Context) {
 	var input struct {
 		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required,min=6"`
+		Email    string `json:"email" binding:"required,email,max=191"`
+		Password string `json:"password" binding:"required,min=6,max=72"`
 	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 检查用户名是否已存在
-	var existingUser model.User
-	if err := h.DB.Where("username = ?", input.Username).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "用户名已存在"})
-		return
-	}
-
-	// 加密密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
 		return
 	}
 
-	// 创建用户
-	user := model.User{
+	user, err := h.Auth.Register(c.Request.Context(), service.RegisterInput{
 		Username: input.Username,
-		Password: string(hashedPassword),
-	}
-	if err = h.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败"})
+		Email:    input.Email,
+		Password: input.Password,
+	})
+	if err != nil {
+		respond.Error(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "注册成功",
-		"user":    gin.H{"id": user.ID, "username": user.Username},
+		"message": respond.T(c, "auth.registered"),
+		"user":    gin.H{"id": user.ID, "username": user.Username, "email": user.Email, "email_verified": false},
 	})
 }
 
-// Login 用户登录
+// Login 用户登录，已启用两步验证时返回 mfa_token
 func (h *AuthHandler) Login(c *gin.Context) {
 	var input struct {
 		Username string `json:"username" binding:"required"`
 		Password string `json:"password" binding:"required"`
 	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	var user model.User
-	if err := h.DB.Where("username = ?", input.Username).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
-		return
-	}
-
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
 		return
 	}
 
-	// 生成 JWT
-	token, err := util.GenerateToken(user.ID, user.Username)
+	res, err := h.Auth.Login(c.Request.Context(), input.Username, input.Password, client(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成 token 失败"})
+		respond.Error(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"token":      token,
-		"expires_at": time.Now().Add(24 * time.Hour).Format(time.RFC3339),
-	})
+	c.JSON(http.StatusOK, res)
 }
@@ -1,80 +1,103 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
+	"github.com/gin-gonic/gin/binding"
 
 	"my_blog/internal/model"
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
 )
 
 type CommentHandler struct {
-	DB *gorm.DB
+	Comments *service.Comments
 }
 
-// CreateComment 创建评论（需认证）
+// CreateComment 创建评论或回复评论（需认证）
 func (h *CommentHandler) CreateComment(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+	var input struct {
+		PostID   uint   `json:"post_id" binding:"required"`
+		ParentID *uint  `json:"parent_id"`
+		Content  string `json:"content" binding:"required,min=1,max=1000"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
 		return
 	}
 
+	comment, err := h.Comments.Create(c.Request.Context(), c.GetUint("user_id"), input.PostID, input.ParentID, input.Content)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, comment)
+}
+
+// ListComments 获取某篇文章的评论树（公开），按顶层评论游标分页，每页带出整楼回复
+func (h *CommentHandler) ListComments(c *gin.Context) {
 	var input struct {
-		PostID  uint   `json:"post_id" binding:"required"`
-		Content string `json:"content" binding:"required,min=1,max=1000"`
+		PostID uint   `json:"post_id" binding:"required"`
+		Cursor string `json:"cursor"`
+		Limit  int    `json:"limit"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
 		return
 	}
 
-	// 可选：验证文章是否存在
-	var post model.Post
-	if err := h.DB.First(&post, input.PostID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询文章失败"})
-		}
+	page, err := h.Comments.List(c.Request.Context(), input.PostID, input.Cursor, input.Limit)
+	if err != nil {
+		respond.Error(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, page)
+}
+
+// UpdateComment 编辑评论（作者或版主，权限由路由上的 OwnerOrRole 策略检查）
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	comment := c.MustGet("comment").(*model.Comment)
 
-	comment := model.Comment{
-		Content: input.Content,
-		PostID:  input.PostID,
-		UserID:  userID.(uint),
+	var input struct {
+		ID      uint   `json:"id" binding:"required"`
+		Content string `json:"content" binding:"required,min=1,max=1000"`
+	}
+	if !respond.Bind(c, c.ShouldBindBodyWith(&input, binding.JSON)) {
+		return
 	}
 
-	if err := h.DB.Create(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "评论创建失败"})
+	comment, err := h.Comments.Update(c.Request.Context(), comment, input.Content)
+	if err != nil {
+		respond.Error(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, comment)
+}
 
-	c.JSON(http.StatusCreated, comment)
+// DeleteComment 删除评论及其全部回复（作者或版主）
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	comment := c.MustGet("comment").(*model.Comment)
+
+	if err := h.Comments.Delete(c.Request.Context(), comment); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	respond.Message(c, http.StatusOK, "comment.deleted")
 }
 
-// ListComments 获取某篇文章的所有评论（公开）
-func (h *CommentHandler) ListComments(c *gin.Context) {
+// HideComment 版主隐藏/取消隐藏评论，隐藏后内容不再对外展示但保留楼层结构
+func (h *CommentHandler) HideComment(c *gin.Context) {
 	var input struct {
-		PostID uint `json:"post_id" binding:"required"`
+		ID     uint `json:"id" binding:"required"`
+		Hidden bool `json:"hidden"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 post_id"})
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
 		return
 	}
 
-	var comments []model.Comment
-	if err := h.DB.
-		Preload("User"). // 加载评论作者
-		Where("post_id = ?", input.PostID).
-		Order("created_at ASC").
-		Find(&comments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取评论失败"})
+	comment, err := h.Comments.SetHidden(c.Request.Context(), input.ID, c.GetUint("user_id"), input.Hidden)
+	if err != nil {
+		respond.Error(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, comments)
+	c.JSON(http.StatusOK, comment)
 }
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/service"
+)
+
+// session 当前请求的登录会话，由 AuthMiddleware 写入上下文
+func session(c *gin.Context) service.Session {
+	return service.Session{UserID: c.GetUint("user_id"), Role: c.GetString("role"), JTI: c.GetString("jti")}
+}
+
+// client 发起请求的客户端信息，用于登录审计
+func client(c *gin.Context) service.Client {
+	return service.Client{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
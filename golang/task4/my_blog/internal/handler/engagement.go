@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
+)
+
+type EngagementHandler struct {
+	Engagement *service.Engagement
+}
+
+// postAction 绑定 {"id": 文章ID} 并以当前用户执行 fn，重复请求的结果相同
+func postAction[T any](c *gin.Context, fn func(ctx context.Context, userID, postID uint) (T, error)) {
+	var input struct {
+		ID uint `json:"id" binding:"required"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	res, err := fn(c.Request.Context(), c.GetUint("user_id"), input.ID)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Like 点赞文章（需认证），已赞过时不重复计数
+func (h *EngagementHandler) Like(c *gin.Context) { postAction(c, h.Engagement.Like) }
+
+// Unlike 取消点赞（需认证）
+func (h *EngagementHandler) Unlike(c *gin.Context) { postAction(c, h.Engagement.Unlike) }
+
+// Bookmark 收藏文章（需认证）
+func (h *EngagementHandler) Bookmark(c *gin.Context) { postAction(c, h.Engagement.Bookmark) }
+
+// Unbookmark 取消收藏（需认证）
+func (h *EngagementHandler) Unbookmark(c *gin.Context) { postAction(c, h.Engagement.Unbookmark) }
+
+// Reaction 当前用户对文章的点赞、收藏状态（需认证），参数为 query string 中的 id
+func (h *EngagementHandler) Reaction(c *gin.Context) {
+	var input struct {
+		ID uint `form:"id" binding:"required"`
+	}
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+
+	res, err := h.Engagement.Reaction(c.Request.Context(), c.GetUint("user_id"), input.ID)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ListBookmarks 当前用户的收藏（需认证），按收藏时间倒序，游标分页
+func (h *EngagementHandler) ListBookmarks(c *gin.Context) {
+	var input struct {
+		Cursor string `form:"cursor"`
+		Size   int    `form:"size"`
+	}
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+
+	page, err := h.Engagement.Bookmarks(c.Request.Context(), c.GetUint("user_id"), input.Cursor, input.Size)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
+)
+
+type FollowHandler struct {
+	Follows *service.Follows
+	Feed    *service.Feed
+}
+
+// followListInput 粉丝、关注列表的参数，均来自 query string
+type followListInput struct {
+	ID     uint   `form:"id" binding:"required"`
+	Cursor string `form:"cursor"`
+	Size   int    `form:"size"`
+}
+
+// Profile 用户的公开资料（公开），包含粉丝数与关注数
+func (h *FollowHandler) Profile(c *gin.Context) {
+	var input struct {
+		ID uint `form:"id" binding:"required"`
+	}
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+
+	profile, err := h.Follows.Profile(c.Request.Context(), input.ID)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// Follow 关注用户（需认证），已关注时不重复计数
+func (h *FollowHandler) Follow(c *gin.Context) {
+	var input userIDInput
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	res, err := h.Follows.Follow(c.Request.Context(), c.GetUint("user_id"), input.ID)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Unfollow 取消关注（需认证）
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	var input userIDInput
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	res, err := h.Follows.Unfollow(c.Request.Context(), c.GetUint("user_id"), input.ID)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Followers 用户的粉丝（公开），按关注时间倒序，游标分页
+func (h *FollowHandler) Followers(c *gin.Context) {
+	var input followListInput
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+
+	page, err := h.Follows.Followers(c.Request.Context(), input.ID, input.Cursor, input.Size)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// Following 用户关注的人（公开），按关注时间倒序，游标分页
+func (h *FollowHandler) Following(c *gin.Context) {
+	var input followListInput
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+
+	page, err := h.Follows.Following(c.Request.Context(), input.ID, input.Cursor, input.Size)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// HomeFeed 当前用户关注的作者的文章（需认证），按发布时间倒序，游标分页
+func (h *FollowHandler) HomeFeed(c *gin.Context) {
+	var input struct {
+		Cursor string `form:"cursor"`
+		Size   int    `form:"size"`
+	}
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+
+	page, err := h.Feed.Page(c.Request.Context(), c.GetUint("user_id"), input.Cursor, input.Size)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"my_blog/internal/metrics"
+	"my_blog/internal/version"
+)
+
+// MigrationChecker 报告尚未执行的迁移数，由 migrate.Migrator 实现
+type MigrationChecker interface {
+	Pending(ctx context.Context) (int, error)
+}
+
+// HealthHandler 存活与就绪探针
+type HealthHandler struct {
+	DB         *gorm.DB
+	Migrations MigrationChecker
+	draining   atomic.Bool
+}
+
+// Drain 标记服务正在停机，之后 /readyz 返回 503，让负载均衡摘除本实例
+func (h *HealthHandler) Drain() { h.draining.Store(true) }
+
+// Healthz 存活探针：进程能处理请求即可
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "version": version.Get().Version})
+}
+
+// Readyz 就绪探针：数据库可连接且迁移已全部执行
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	ready := true
+	checks := gin.H{}
+
+	if h.draining.Load() {
+		ready = false
+		checks["server"] = "shutting down"
+	}
+
+	sqlDB, err := h.DB.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		ready = false
+		checks["database"] = err.Error()
+	} else {
+		checks["database"] = "ok"
+	}
+
+	if h.Migrations != nil {
+		switch n, err := h.Migrations.Pending(ctx); {
+		case err != nil:
+			ready = false
+			checks["migrations"] = err.Error()
+		case n > 0:
+			ready = false
+			checks["migrations"] = strconv.Itoa(n) + " pending"
+		default:
+			checks["migrations"] = "ok"
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+}
+
+// Metrics 以 Prometheus 文本格式导出指标
+func Metrics(reg *metrics.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", metrics.ContentType)
+		c.Status(http.StatusOK)
+		if err := reg.WriteText(c.Writer); err != nil {
+			c.Error(err)
+		}
+	}
+}
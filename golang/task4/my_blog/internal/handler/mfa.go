@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
+)
+
+// mfaInput 两步验证凭据，验证码与恢复码二选一
+type mfaInput struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func (in mfaInput) code() service.MFACode {
+	return service.MFACode{Code: in.Code, RecoveryCode: in.RecoveryCode}
+}
+
+// LoginMFA 两步登录的第二步（公开）：用 mfa_token 与验证码（或恢复码）换取令牌对
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var input struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		mfaInput
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	pair, err := h.Auth.LoginMFA(c.Request.Context(), input.MFAToken, input.code(), client(c))
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+// MFAStatus 当前用户的两步验证状态（需认证）
+func (h *AuthHandler) MFAStatus(c *gin.Context) {
+	status, err := h.Auth.MFAStatus(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// SetupTOTP 开始启用两步验证（需认证）：生成新的密钥与 otpauth:// 链接，需调用 ConfirmTOTP 后才生效
+func (h *AuthHandler) SetupTOTP(c *gin.Context) {
+	setup, err := h.Auth.SetupTOTP(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+// ConfirmTOTP 用验证器 App 生成的验证码确认启用两步验证（需认证），返回一次性恢复码（只展示一次）
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	codes, err := h.Auth.ConfirmTOTP(c.Request.Context(), c.GetUint("user_id"), input.Code)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": respond.T(c, "mfa.enabled"), "recovery_codes": codes})
+}
+
+// DisableMFA 关闭两步验证（需认证），需同时提供密码与验证码（或恢复码）
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	var input struct {
+		Password string `json:"password" binding:"required"`
+		mfaInput
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	if err := h.Auth.DisableMFA(c.Request.Context(), c.GetUint("user_id"), input.Password, input.code()); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	respond.Message(c, http.StatusOK, "mfa.disabled")
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
+)
+
+type NotificationHandler struct {
+	Notifications *service.Notifications
+}
+
+// ListNotifications 当前用户的通知（需认证），按时间倒序，游标分页；unread=true 时只返回未读
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	var input struct {
+		Cursor string `form:"cursor"`
+		Size   int    `form:"size"`
+		Unread bool   `form:"unread"`
+	}
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+
+	page, err := h.Notifications.List(c.Request.Context(), c.GetUint("user_id"), input.Cursor, input.Size, input.Unread)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// UnreadCount 未读通知数（需认证），包含各类型的数量
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	unread, err := h.Notifications.Unread(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, unread)
+}
+
+// MarkRead 将指定通知标记为已读（需认证）
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	var input struct {
+		IDs []uint `json:"ids" binding:"required,min=1,max=100"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	res, err := h.Notifications.MarkRead(c.Request.Context(), c.GetUint("user_id"), input.IDs)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// MarkAllRead 将全部通知标记为已读（需认证）
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	res, err := h.Notifications.MarkAllRead(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GetPreferences 当前用户各类通知的接收设置（需认证）
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	prefs, err := h.Notifications.Preferences(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// UpdatePreferences 修改通知设置（需认证），只修改请求中给出的类型和字段
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	var input struct {
+		Preferences []struct {
+			Type  string `json:"type" binding:"required"`
+			InApp *bool  `json:"in_app"`
+			Email *bool  `json:"email"`
+		} `json:"preferences" binding:"required,min=1,dive"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	patches := make([]service.PreferencePatch, len(input.Preferences))
+	for i, p := range input.Preferences {
+		patches[i] = service.PreferencePatch{Type: p.Type, InApp: p.InApp, Email: p.Email}
+	}
+	prefs, err := h.Notifications.UpdatePreferences(c.Request.Context(), c.GetUint("user_id"), patches)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+)
+
+// ChangePassword 修改密码（需认证），需提供原密码；成功后其他会话全部下线
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var input struct {
+		OldPassword string `json:"old_password" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=6,max=72"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	if err := h.Auth.ChangePassword(c.Request.Context(), session(c), input.OldPassword, input.NewPassword); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	respond.Message(c, http.StatusOK, "password.changed")
+}
+
+// ForgotPassword 发送重置密码邮件（公开）。无论邮箱是否注册都返回相同结果，避免探测账号
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	if err := h.Auth.ForgotPassword(c.Request.Context(), input.Email); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	respond.Message(c, http.StatusOK, "password.reset_sent")
+}
+
+// ResetPassword 使用邮件中的令牌重置密码（公开）。令牌只能使用一次，成功后解除锁定并下线所有会话
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var input struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=6,max=72"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	if err := h.Auth.ResetPassword(c.Request.Context(), input.Token, input.NewPassword); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	respond.Message(c, http.StatusOK, "password.reset_done")
+}
@@ -2,181 +2,156 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
+	"github.com/gin-gonic/gin/binding"
 
 	"my_blog/internal/model"
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
 )
 
 type PostHandler struct {
-	DB *gorm.DB
+	Posts *service.Posts
 }
 
 // CreatePost 创建文章（需认证）
 func (h *PostHandler) CreatePost(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-
 	var input struct {
-		Title   string `json:"title" binding:"required"`
-		Content string `json:"content" binding:"required"`
+		Title      string     `json:"title" binding:"required,max=255"`
+		Content    string     `json:"content" binding:"required,max=200000"`
+		Tags       []string   `json:"tags"`
+		Categories []string   `json:"categories"`
+		Status     string     `json:"status"` // 默认直接发布
+		PublishAt  *time.Time `json:"publish_at"`
+		// 由 /api/upload 返回的附件 ID
+		AttachmentIDs []uint `json:"attachment_ids" binding:"max=20"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
 		return
 	}
 
-	post := model.Post{
-		Title:   input.Title,
-		Content: input.Content,
-		UserID:  userID.(uint),
-	}
+	post, err := h.Posts.Create(c.Request.Context(), c.GetUint("user_id"), service.PostInput{
+		Title:      input.Title,
+		Content:    input.Content,
+		Tags:       input.Tags,
+		Categories: input.Categories,
+		Status:     input.Status,
+		PublishAt:  input.PublishAt,
 
-	if err := h.DB.Create(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文章失败"})
+		AttachmentIDs: input.AttachmentIDs,
+	})
+	if err != nil {
+		respond.Error(c, err)
 		return
 	}
-
-	h.DB.Preload("User").First(&post, post.ID)
 	c.JSON(http.StatusCreated, post)
 }
 
-// ListPosts 获取所有文章列表（公开）
+// ListPosts 获取文章列表（公开），支持游标分页、排序与筛选，参数均来自 query string
 func (h *PostHandler) ListPosts(c *gin.Context) {
 	var input struct {
-		Page int `json:"page"`
-		Size int `json:"size"`
+		Cursor   string `form:"cursor"`
+		Size     int    `form:"size"`
+		Sort     string `form:"sort" binding:"omitempty,oneof=newest most_commented most_liked trending"`
+		AuthorID uint   `form:"author_id"`
+		Tag      string `form:"tag"`
+		Category string `form:"category"`
+		From     string `form:"from"` // RFC3339 或 2006-01-02
+		To       string `form:"to"`
 	}
-	_ = c.ShouldBindJSON(&input) // 不强制校验，可选
-
-	query := h.DB.Preload("User")
-	if input.Size > 0 {
-		query = query.Limit(input.Size)
-	}
-	if input.Page > 0 {
-		offset := (input.Page - 1) * input.Size
-		query = query.Offset(offset)
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
 	}
 
-	var posts []model.Post
-	if err := query.Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章列表失败"})
+	page, err := h.Posts.List(c.Request.Context(), service.PostListQuery(input))
+	if err != nil {
+		respond.Error(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, posts)
+	c.JSON(http.StatusOK, page)
 }
 
-// GetPost 获取单篇文章详情（公开）
+// GetPost 获取单篇文章详情（公开）并记一次阅读，format=html 时 Content 返回渲染后的 HTML，默认返回 Markdown 源文
 func (h *PostHandler) GetPost(c *gin.Context) {
 	var input struct {
-		ID uint `json:"id" binding:"required"`
+		ID     uint   `json:"id" binding:"required"`
+		Format string `json:"format"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少文章ID"})
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
 		return
 	}
+	if f := c.Query("format"); f != "" {
+		input.Format = f
+	}
 
-	var post model.Post
-	if err := h.DB.Preload("User").First(&post, input.ID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
-		}
+	post, err := h.Posts.Get(c.Request.Context(), input.ID, input.Format)
+	if err != nil {
+		respond.Error(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, post)
 }
 
-// UpdatePost 更新文章（仅作者）
-func (h *PostHandler) UpdatePost(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
-
+// MyPosts 当前用户自己的文章（需认证），包含草稿、定时和归档文章
+func (h *PostHandler) MyPosts(c *gin.Context) {
 	var input struct {
-		ID      uint    `json:"id" binding:"required"`
-		Title   *string `json:"title"`
-		Content *string `json:"content"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		Status string `form:"status"`
 	}
+	_ = c.ShouldBindQuery(&input)
 
-	var post model.Post
-	if err := h.DB.First(&post, input.ID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
-		}
+	posts, err := h.Posts.Mine(c.Request.Context(), c.GetUint("user_id"), input.Status)
+	if err != nil {
+		respond.Error(c, err)
 		return
 	}
-
-	// 权限检查：只有作者能修改
-	if post.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此文章"})
-		return
-	}
-
-	if input.Title != nil {
-		post.Title = *input.Title
-	}
-	if input.Content != nil {
-		post.Content = *input.Content
-	}
-
-	if err := h.DB.Save(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
-		return
-	}
-
-	h.DB.Preload("User").First(&post, post.ID)
-	c.JSON(http.StatusOK, post)
+	c.JSON(http.StatusOK, posts)
 }
 
-// DeletePost 删除文章（仅作者）
-func (h *PostHandler) DeletePost(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
-		return
-	}
+// UpdatePost 更新文章（作者或版主，权限由路由上的 OwnerOrRole 策略检查）
+func (h *PostHandler) UpdatePost(c *gin.Context) {
+	post := c.MustGet("post").(*model.Post)
 
 	var input struct {
-		ID uint `json:"id" binding:"required"`
+		ID         uint       `json:"id" binding:"required"`
+		Title      *string    `json:"title" binding:"omitempty,max=255"`
+		Content    *string    `json:"content" binding:"omitempty,max=200000"`
+		Tags       *[]string  `json:"tags"`
+		Categories *[]string  `json:"categories"`
+		Status     *string    `json:"status"`
+		PublishAt  *time.Time `json:"publish_at"`
+		// 给出时整体替换文章的附件，空数组移除全部附件
+		AttachmentIDs *[]uint `json:"attachment_ids" binding:"omitempty,max=20"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少文章ID"})
+	if !respond.Bind(c, c.ShouldBindBodyWith(&input, binding.JSON)) {
 		return
 	}
 
-	var post model.Post
-	if err := h.DB.First(&post, input.ID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
-		}
-		return
-	}
+	post, err := h.Posts.Update(c.Request.Context(), post, c.GetUint("user_id"), service.PostPatch{
+		Title:      input.Title,
+		Content:    input.Content,
+		Tags:       input.Tags,
+		Categories: input.Categories,
+		Status:     input.Status,
+		PublishAt:  input.PublishAt,
 
-	if post.UserID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权删除此文章"})
+		AttachmentIDs: input.AttachmentIDs,
+	})
+	if err != nil {
+		respond.Error(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, post)
+}
+
+// DeletePost 删除文章（作者或版主，权限由路由上的 OwnerOrRole 策略检查）
+func (h *PostHandler) DeletePost(c *gin.Context) {
+	post := c.MustGet("post").(*model.Post)
 
-	if err := h.DB.Delete(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+	if err := h.Posts.Delete(c.Request.Context(), post); err != nil {
+		respond.Error(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "文章已删除"})
+	respond.Message(c, http.StatusOK, "post.deleted")
 }
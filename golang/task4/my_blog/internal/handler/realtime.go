@@ -0,0 +1,400 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+
+	"my_blog/internal/conf"
+	"my_blog/internal/realtime"
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
+)
+
+const (
+	sseRetry        = 3 * time.Second // 建议浏览器断线后的重连间隔
+	socketReadLimit = 4096            // 客户端单条消息的上限
+)
+
+// 服务端下发的控制消息类型（事件本身的类型见 realtime 包）
+const (
+	msgSubscribed   = "subscribed"
+	msgUnsubscribed = "unsubscribed"
+	msgError        = "error"  // 客户端请求有误，连接保持
+	msgClosed       = "closed" // 服务端即将断开连接
+)
+
+type RealtimeHandler struct {
+	Hub      *realtime.Hub
+	Realtime *service.Realtime
+	Auth     *service.Auth
+	Config   conf.RealtimeConfig
+	CORS     func() conf.CORSConfig // WebSocket 握手时据此校验跨域来源，为 nil 时只允许同源
+}
+
+// control 控制消息：订阅确认、补发失败（reset）、错误与断开原因，错误字段与 HTTP 错误信封一致
+type control struct {
+	Type      string        `json:"type"`
+	Topics    []string      `json:"topics,omitempty"`
+	Error     *respond.Body `json:"error,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// failure 携带错误的控制消息
+func failure(c *gin.Context, typ string, err error) control {
+	env := respond.NewEnvelope(c, err)
+	return control{Type: typ, Error: &env.Error, RequestID: env.RequestID}
+}
+
+// PostEvents 单篇已发布文章的 SSE 事件流（公开）：评论的新增、修改、删除、隐藏以及文章本身的变更。
+// 重连时浏览器自动带上 Last-Event-ID（也可用 last_event_id 参数），服务端补发错过的事件，
+// 无法补发时先推送 reset，客户端应重新拉取评论；文章被删除或撤回后推送对应事件并结束
+func (h *RealtimeHandler) PostEvents(c *gin.Context) {
+	var input struct {
+		ID          uint   `form:"id" binding:"required"`
+		LastEventID uint64 `form:"last_event_id"`
+	}
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+	if v := c.GetHeader("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			respond.Error(c, respond.ErrMalformed.Wrap(err))
+			return
+		}
+		input.LastEventID = id
+	}
+
+	topic := realtime.PostTopic(input.ID)
+	if err := h.Realtime.Authorize(c.Request.Context(), 0, []string{topic}, 0); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	sub := h.Hub.Subscribe()
+	defer sub.Close()
+	missed := sub.Add([]string{topic}, input.LastEventID)
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭 nginx 的响应缓冲
+	c.Status(http.StatusOK)
+
+	stream := &sseStream{w: c.Writer, rc: http.NewResponseController(c.Writer), timeout: h.Config.WriteTimeout}
+	if err := stream.write(fmt.Sprintf("retry: %d\n\n", sseRetry.Milliseconds())); err != nil {
+		return
+	}
+	if missed {
+		if err := stream.control(realtime.Reset, control{Type: realtime.Reset, Topics: []string{topic}}); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.Config.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-sub.Done():
+			if errors.Is(sub.Err(), realtime.ErrSlowConsumer) {
+				_ = stream.control(msgClosed, failure(c, msgClosed, service.ErrSlowConsumer))
+			}
+			return
+		case <-sub.Ready():
+			for _, ev := range sub.Drain() {
+				if err := stream.event(ev); err != nil {
+					return
+				}
+				if endsTopic(ev) {
+					return
+				}
+			}
+		case <-heartbeat.C:
+			if err := stream.write(": ping\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sseStream 写出 text/event-stream。每次写出前单独设置写超时，
+// 既不会被 http.Server 的 WriteTimeout 切断长连接，也能及时发现卡住的客户端
+type sseStream struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (s *sseStream) write(frame string) error {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(s.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := io.WriteString(s.w, frame); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *sseStream) event(ev realtime.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return s.write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data))
+}
+
+func (s *sseStream) control(name string, msg control) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.write(fmt.Sprintf("event: %s\ndata: %s\n\n", name, data))
+}
+
+// endsTopic 文章被删除或撤回后，其主题不会再有事件，也不再允许订阅
+func endsTopic(ev realtime.Event) bool {
+	if ev.Type != realtime.PostDeleted && ev.Type != realtime.PostUnpublished {
+		return false
+	}
+	kind, _, _ := realtime.ParseTopic(ev.Topic)
+	return kind == realtime.KindPost
+}
+
+// socketRequest 客户端发来的订阅请求
+type socketRequest struct {
+	Action      string   `json:"action" binding:"required,oneof=subscribe unsubscribe"`
+	Topics      []string `json:"topics" binding:"required,min=1"`
+	LastEventID uint64   `json:"last_event_id"` // subscribe 时补发此 ID 之后的事件
+}
+
+// inbound 读协程转交给写协程的请求，解析失败时 err 非空
+type inbound struct {
+	req socketRequest
+	err error
+}
+
+// Socket WebSocket 实时推送。握手时校验 access token（Authorization 头，浏览器可用 access_token 参数），
+// 之后客户端发送 {"action":"subscribe","topics":["posts","post:1","user:2"],"last_event_id":N} 订阅主题，
+// 服务端推送事件与控制消息；access token 过期、积压过多或服务停止时断开，客户端应刷新令牌后重连
+func (h *RealtimeHandler) Socket(c *gin.Context) {
+	token, err := bearerToken(c)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	claims, err := h.Auth.Authenticate(c.Request.Context(), token)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: h.checkOrigin}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // Upgrade 已写出错误响应
+	}
+	defer conn.Close()
+
+	s := &socket{
+		h:       h,
+		c:       c,
+		conn:    conn,
+		sub:     h.Hub.Subscribe(),
+		userID:  claims.UserID,
+		inbound: make(chan inbound),
+		done:    make(chan struct{}),
+	}
+	defer s.sub.Close()
+	go s.read()
+	s.serve(time.Until(claims.ExpiresAt.Time))
+}
+
+// checkOrigin 允许非浏览器客户端（无 Origin）、同源请求以及跨域白名单中的来源
+func (h *RealtimeHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return h.CORS != nil && h.CORS().AllowOrigin(origin)
+}
+
+// bearerToken 取出 Authorization: Bearer 头中的令牌，没有该头时使用 access_token 参数
+func bearerToken(c *gin.Context) (string, error) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || scheme != "Bearer" || token == "" {
+			return "", service.ErrAuthHeaderFormat
+		}
+		return token, nil
+	}
+	if token := c.Query("access_token"); token != "" {
+		return token, nil
+	}
+	return "", service.ErrAuthHeaderMissing
+}
+
+// socket 一条 WebSocket 连接。读协程只负责读取与解析，订阅处理和全部写出都在 serve 所在的协程完成
+type socket struct {
+	h       *RealtimeHandler
+	c       *gin.Context
+	conn    *websocket.Conn
+	sub     *realtime.Subscription
+	userID  uint
+	inbound chan inbound
+	done    chan struct{} // serve 退出时关闭，通知读协程不再转交请求
+}
+
+func (s *socket) read() {
+	defer close(s.inbound)
+	wait := 2 * s.h.Config.Heartbeat
+	s.conn.SetReadLimit(socketReadLimit)
+	_ = s.conn.SetReadDeadline(time.Now().Add(wait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = s.conn.SetReadDeadline(time.Now().Add(wait))
+
+		var in inbound
+		if err := json.Unmarshal(data, &in.req); err != nil {
+			in.err = respond.ErrMalformed.Wrap(err)
+		} else if err := binding.Validator.ValidateStruct(&in.req); err != nil {
+			in.err = respond.BindError(err)
+		}
+		select {
+		case s.inbound <- in:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *socket) serve(ttl time.Duration) {
+	defer close(s.done)
+	heartbeat := time.NewTicker(s.h.Config.Heartbeat)
+	defer heartbeat.Stop()
+	expiry := time.NewTimer(ttl)
+	defer expiry.Stop()
+
+	for {
+		select {
+		case in, ok := <-s.inbound:
+			if !ok {
+				return // 客户端断开或读超时
+			}
+			if err := s.handle(in); err != nil {
+				return
+			}
+		case <-s.sub.Ready():
+			for _, ev := range s.sub.Drain() {
+				if err := s.send(ev); err != nil {
+					return
+				}
+				if endsTopic(ev) {
+					s.sub.Remove([]string{ev.Topic})
+					if err := s.send(control{Type: msgUnsubscribed, Topics: []string{ev.Topic}}); err != nil {
+						return
+					}
+				}
+			}
+		case <-s.sub.Done():
+			if errors.Is(s.sub.Err(), realtime.ErrSlowConsumer) {
+				s.close(websocket.CloseTryAgainLater, service.ErrSlowConsumer)
+			} else {
+				s.close(websocket.CloseGoingAway, nil)
+			}
+			return
+		case <-expiry.C:
+			s.close(websocket.ClosePolicyViolation, service.ErrTokenInvalid)
+			return
+		case <-heartbeat.C:
+			deadline := time.Now().Add(s.h.Config.WriteTimeout)
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handle 处理一条订阅请求，请求有误时回复 error 消息但保持连接；返回的错误表示连接已不可写
+func (s *socket) handle(in inbound) error {
+	if in.err != nil {
+		return s.sendError(in.err)
+	}
+	req := in.req
+	if req.Action == "unsubscribe" {
+		s.sub.Remove(req.Topics)
+		return s.send(control{Type: msgUnsubscribed, Topics: req.Topics})
+	}
+
+	current := s.sub.Topics()
+	var added []string
+	for _, t := range req.Topics {
+		if !slices.Contains(current, t) && !slices.Contains(added, t) {
+			added = append(added, t)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.h.Config.WriteTimeout)
+	defer cancel()
+	if err := s.h.Realtime.Authorize(ctx, s.userID, added, len(current)); err != nil {
+		return s.sendError(err)
+	}
+
+	// 先确认订阅，再由 serve 循环推送补发的事件
+	missed := s.sub.Add(req.Topics, req.LastEventID)
+	if err := s.send(control{Type: msgSubscribed, Topics: req.Topics}); err != nil {
+		return err
+	}
+	if missed {
+		return s.send(control{Type: realtime.Reset, Topics: req.Topics})
+	}
+	return nil
+}
+
+func (s *socket) send(msg any) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(s.h.Config.WriteTimeout)); err != nil {
+		return err
+	}
+	return s.conn.WriteJSON(msg)
+}
+
+func (s *socket) sendError(err error) error {
+	return s.send(failure(s.c, msgError, err))
+}
+
+// close 下发断开原因后发送关闭帧
+func (s *socket) close(code int, reason error) {
+	text := ""
+	if reason != nil {
+		msg := failure(s.c, msgClosed, reason)
+		if s.send(msg) != nil {
+			return
+		}
+		text = msg.Error.Code
+	}
+	deadline := time.Now().Add(s.h.Config.WriteTimeout)
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), deadline)
+}
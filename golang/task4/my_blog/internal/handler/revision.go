@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"my_blog/internal/model"
+	"my_blog/internal/respond"
+)
+
+// ListRevisions 文章的历史版本列表（作者或版主），不含正文
+func (h *PostHandler) ListRevisions(c *gin.Context) {
+	post := c.MustGet("post").(*model.Post)
+
+	revisions, err := h.Posts.Revisions(c.Request.Context(), post)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// DiffRevisions 比较文章的两个版本（作者或版主）
+func (h *PostHandler) DiffRevisions(c *gin.Context) {
+	post := c.MustGet("post").(*model.Post)
+
+	var input struct {
+		ID   uint `json:"id" binding:"required"`
+		From int  `json:"from" binding:"required"`
+		To   int  `json:"to" binding:"required"`
+	}
+	if !respond.Bind(c, c.ShouldBindBodyWith(&input, binding.JSON)) {
+		return
+	}
+
+	diff, err := h.Posts.Diff(c.Request.Context(), post, input.From, input.To)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// RestoreRevision 将文章恢复到某个历史版本（作者或版主），恢复本身也会生成新版本
+func (h *PostHandler) RestoreRevision(c *gin.Context) {
+	post := c.MustGet("post").(*model.Post)
+
+	var input struct {
+		ID      uint `json:"id" binding:"required"`
+		Version int  `json:"version" binding:"required"`
+	}
+	if !respond.Bind(c, c.ShouldBindBodyWith(&input, binding.JSON)) {
+		return
+	}
+
+	post, err := h.Posts.Restore(c.Request.Context(), post, input.Version, c.GetUint("user_id"))
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, post)
+}
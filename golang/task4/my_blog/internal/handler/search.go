@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/apperr"
+	"my_blog/internal/respond"
+	"my_blog/internal/search"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+type SearchHandler struct {
+	Searcher search.Searcher
+}
+
+// Search 全文检索文章和评论（公开）
+func (h *SearchHandler) Search(c *gin.Context) {
+	var input struct {
+		Q     string `form:"q" binding:"required"`
+		Limit int    `form:"limit"`
+	}
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+	if input.Limit <= 0 {
+		input.Limit = defaultSearchLimit
+	}
+	if input.Limit > maxSearchLimit {
+		input.Limit = maxSearchLimit
+	}
+
+	hits, err := h.Searcher.Search(c.Request.Context(), input.Q, input.Limit)
+	if err != nil {
+		respond.Error(c, apperr.ErrInternal.Wrap(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hits": hits})
+}
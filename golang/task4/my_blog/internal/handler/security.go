@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+)
+
+// LoginEvents 当前用户的登录记录（需认证），按时间倒序，游标分页
+func (h *AuthHandler) LoginEvents(c *gin.Context) {
+	var input struct {
+		Cursor string `form:"cursor"`
+		Size   int    `form:"size"`
+	}
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+
+	page, err := h.Auth.LoginEvents(c.Request.Context(), c.GetUint("user_id"), input.Cursor, input.Size)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+)
+
+// siweInput 签名后的 EIP-4361 消息
+type siweInput struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// SIWENonce 签发钱包登录用的一次性 nonce（公开），前端将其写入 EIP-4361 消息后请求钱包签名
+func (h *AuthHandler) SIWENonce(c *gin.Context) {
+	nonce, err := h.Auth.NewSIWENonce(c.Request.Context())
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nonce)
+}
+
+// SIWELogin 以太坊钱包登录（公开）：校验 EIP-4361 签名，首次登录时按地址创建账号。
+// 已启用两步验证的账号同样需要完成 /api/login/mfa
+func (h *AuthHandler) SIWELogin(c *gin.Context) {
+	var input siweInput
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	res, err := h.Auth.SIWELogin(c.Request.Context(), input.Message, input.Signature, client(c))
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// LinkWallet 为当前账号绑定以太坊地址（需认证），之后可用钱包登录该账号
+func (h *AuthHandler) LinkWallet(c *gin.Context) {
+	var input siweInput
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	address, already, err := h.Auth.LinkWallet(c.Request.Context(), c.GetUint("user_id"), input.Message, input.Signature)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	code := "siwe.linked"
+	if already {
+		code = "siwe.already_linked"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": respond.T(c, code), "eth_address": address})
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
+)
+
+type TagHandler struct {
+	Tags *service.Tags
+}
+
+// Autocomplete 标签自动补全（公开），按使用次数降序
+func (h *TagHandler) Autocomplete(c *gin.Context) {
+	var input struct {
+		Prefix string `form:"prefix" binding:"required"`
+		Limit  int    `form:"limit"`
+	}
+	if !respond.Bind(c, c.ShouldBindQuery(&input)) {
+		return
+	}
+
+	tags, err := h.Tags.Autocomplete(c.Request.Context(), input.Prefix, input.Limit)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// Cloud 标签云（公开）：使用最多的标签及文章数
+func (h *TagHandler) Cloud(c *gin.Context) {
+	var input struct {
+		Limit int `form:"limit"`
+	}
+	_ = c.ShouldBindQuery(&input)
+
+	tags, err := h.Tags.Cloud(c.Request.Context(), input.Limit)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// ListCategories 分类列表及已发布文章数（公开）
+func (h *TagHandler) ListCategories(c *gin.Context) {
+	categories, err := h.Tags.Categories(c.Request.Context())
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, categories)
+}
+
+// CreateCategory 新建分类（管理员或版主）
+func (h *TagHandler) CreateCategory(c *gin.Context) {
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	category, err := h.Tags.CreateCategory(c.Request.Context(), input.Name)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, category)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+	"my_blog/internal/util"
+)
+
+// Refresh 用 refresh token 换取新的令牌对（公开），旧 refresh token 立即失效
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	pair, err := h.Auth.Refresh(c.Request.Context(), input.RefreshToken)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+// Logout 退出登录（需认证）：吊销当前 access token 及其所属的令牌族
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.Auth.Logout(c.Request.Context(), session(c), c.GetTime("token_expires_at")); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	respond.Message(c, http.StatusOK, "auth.logged_out")
+}
+
+// JWKS 公开当前所有非对称验证公钥（JSON Web Key Set）
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, util.JWKS())
+}
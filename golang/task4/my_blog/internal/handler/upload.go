@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
+)
+
+// multipartOverhead 请求体中 multipart 边界和表单头的余量
+const multipartOverhead = 64 << 10
+
+type UploadHandler struct {
+	Uploads *service.Uploads
+}
+
+// Upload 上传图片（需认证），multipart/form-data 的 file 字段；返回的附件 ID 用于创建或编辑文章时的 attachment_ids
+func (h *UploadHandler) Upload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.Uploads.Config.MaxSize)+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respond.Error(c, h.Uploads.TooLarge(err))
+			return
+		}
+		respond.Error(c, service.ErrUploadFileMissing.Wrap(err))
+		return
+	}
+	if header.Size > int64(h.Uploads.Config.MaxSize) {
+		respond.Error(c, h.Uploads.TooLarge(nil))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.Uploads.Upload(c.Request.Context(), c.GetUint("user_id"), header.Filename, file)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, attachment)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/model"
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
+)
+
+type UserHandler struct {
+	Users *service.Users
+}
+
+// userView 对外展示的用户信息，不包含密码
+type userView struct {
+	ID         uint   `json:"id"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Role       string `json:"role"`
+	MFAEnabled bool   `json:"mfa_enabled"`
+}
+
+func newUserView(u *model.User) userView {
+	return userView{ID: u.ID, Username: u.Username, Email: u.Email, Role: u.Role, MFAEnabled: u.MFAEnabled()}
+}
+
+// userIDInput 按 ID 操作某个用户
+type userIDInput struct {
+	ID uint `json:"id" binding:"required"`
+}
+
+// ListUsers 用户列表（仅管理员）
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	users, err := h.Users.List(c.Request.Context())
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+
+	views := make([]userView, 0, len(users))
+	for i := range users {
+		views = append(views, newUserView(&users[i]))
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// UpdateUserRole 修改用户角色（仅管理员）
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	var input struct {
+		ID   uint   `json:"id" binding:"required"`
+		Role string `json:"role" binding:"required"`
+	}
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	user, err := h.Users.SetRole(c.Request.Context(), c.GetUint("user_id"), input.ID, input.Role)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newUserView(user))
+}
+
+// DeleteUser 删除用户（仅管理员）
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	var input userIDInput
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	if err := h.Users.Delete(c.Request.Context(), c.GetUint("user_id"), input.ID); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	respond.Message(c, http.StatusOK, "user.deleted")
+}
+
+// ResetUserMFA 重置用户的两步验证（仅管理员），用于用户丢失验证器且恢复码用尽的情况
+func (h *UserHandler) ResetUserMFA(c *gin.Context) {
+	var input userIDInput
+	if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+		return
+	}
+
+	user, err := h.Users.ResetMFA(c.Request.Context(), c.GetUint("user_id"), input.ID)
+	if err != nil {
+		respond.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newUserView(user))
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+)
+
+// VerifyEmail 通过邮件中的链接验证邮箱（公开），令牌可放在查询参数或 JSON 请求体中
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		var input struct {
+			Token string `json:"token" binding:"required"`
+		}
+		if !respond.Bind(c, c.ShouldBindJSON(&input)) {
+			return
+		}
+		token = input.Token
+	}
+
+	if err := h.Auth.VerifyEmail(c.Request.Context(), token); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	respond.Message(c, http.StatusOK, "email.verified")
+}
+
+// ResendVerification 重新发送验证邮件（需登录）
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	if err := h.Auth.ResendVerification(c.Request.Context(), c.GetUint("user_id")); err != nil {
+		respond.Error(c, err)
+		return
+	}
+	respond.Message(c, http.StatusOK, "email.sent")
+}
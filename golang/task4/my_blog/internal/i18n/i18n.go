@@ -0,0 +1,82 @@
+// Package i18n 错误与提示消息的多语言文本，按 Accept-Language 选择语言
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang 支持的语言
+type Lang string
+
+const (
+	ZH Lang = "zh"
+	EN Lang = "en"
+
+	// Default 未指定或不支持的语言时使用
+	Default = ZH
+)
+
+// Negotiate 解析 Accept-Language，按 q 值选出第一个支持的语言
+func Negotiate(header string) Lang {
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lang, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		tags = append(tags, tag{lang: strings.ToLower(strings.TrimSpace(lang)), q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if t.q <= 0 {
+			continue
+		}
+		base, _, _ := strings.Cut(t.lang, "-")
+		switch base {
+		case "zh":
+			return ZH
+		case "en":
+			return EN
+		case "*":
+			return Default
+		}
+	}
+	return Default
+}
+
+// T 返回 code 对应的消息，{name} 形式的占位符用 args 替换。
+// 缺少该语言的翻译时退回默认语言，完全未登记时返回 code 本身
+func T(lang Lang, code string, args map[string]string) string {
+	m, ok := messages[code]
+	if !ok {
+		return code
+	}
+	text := m.zh
+	if lang == EN && m.en != "" {
+		text = m.en
+	}
+	for k, v := range args {
+		text = strings.ReplaceAll(text, "{"+k+"}", v)
+	}
+	return text
+}
+
+// Has 是否登记了 code 的消息
+func Has(code string) bool {
+	_, ok := messages[code]
+	return ok
+}
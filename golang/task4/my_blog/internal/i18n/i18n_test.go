@@ -0,0 +1,39 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	for header, want := range map[string]Lang{
+		"":                        ZH,
+		"en":                      EN,
+		"en-US,en;q=0.9":          EN,
+		"zh-CN,zh;q=0.9,en;q=0.8": ZH,
+		"fr;q=0.9, en;q=0.5":      EN,
+		"zh;q=0.1, en-GB;q=0.7":   EN,
+		"en;q=0, zh-TW":           ZH,
+		"de, fr":                  Default,
+		"*":                       Default,
+	} {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	args := map[string]string{"field": "email"}
+	if got := T(EN, "validation.required", args); got != "email is required" {
+		t.Errorf("unexpected en message %q", got)
+	}
+	if got := T(ZH, "validation.required", args); got != "email 不能为空" {
+		t.Errorf("unexpected zh message %q", got)
+	}
+	if got := T(EN, "no.such.code", nil); got != "no.such.code" {
+		t.Errorf("unknown code should fall back to itself, got %q", got)
+	}
+	for code, m := range messages {
+		if m.zh == "" || m.en == "" {
+			t.Errorf("message %q is missing a translation", code)
+		}
+	}
+}
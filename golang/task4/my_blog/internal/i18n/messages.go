@@ -0,0 +1,124 @@
+package i18n
+
+// messages 按错误码或提示码登记的消息，zh 为默认语言
+var messages = map[string]struct{ zh, en string }{
+	// 通用
+	"internal":               {"服务器内部错误", "Internal server error"},
+	"rate_limited":           {"请求过于频繁，请稍后再试", "Too many requests, please try again later"},
+	"request.malformed":      {"请求格式错误", "Malformed request"},
+	"request.invalid":        {"请求参数错误", "Invalid request parameters"},
+	"request.cursor_invalid": {"无效的 cursor", "Invalid cursor"},
+	"request.date_invalid":   {"{field} 格式错误", "Invalid {field} date"},
+
+	// 参数校验
+	"validation.required": {"{field} 不能为空", "{field} is required"},
+	"validation.email":    {"{field} 不是有效的邮箱地址", "{field} must be a valid email address"},
+	"validation.min":      {"{field} 不能短于或小于 {param}", "{field} must be at least {param}"},
+	"validation.max":      {"{field} 不能长于或大于 {param}", "{field} must be at most {param}"},
+	"validation.oneof":    {"{field} 只能是以下之一：{param}", "{field} must be one of: {param}"},
+	"validation.invalid":  {"{field} 格式不正确", "{field} is invalid"},
+
+	// 认证与会话
+	"auth.unauthenticated":  {"未认证", "Authentication required"},
+	"auth.header_missing":   {"缺少 Authorization 头", "Missing Authorization header"},
+	"auth.header_malformed": {"Authorization 格式错误", "Malformed Authorization header"},
+	"auth.token_invalid":    {"无效或过期的 token", "Invalid or expired token"},
+	"auth.token_revoked":    {"token 已失效", "Token has been revoked"},
+	"auth.account_missing":  {"用户不存在", "Account no longer exists"},
+	"auth.forbidden":        {"权限不足", "Permission denied"},
+	"auth.email_unverified": {"请先验证邮箱", "Please verify your email first"},
+	"auth.bad_credentials":  {"用户名或密码错误", "Invalid username or password"},
+	"auth.locked":           {"登录失败次数过多，账号已被锁定，请稍后再试", "Too many failed logins, the account is locked; please try again later"},
+	"auth.refresh_invalid":  {"无效的 refresh token", "Invalid refresh token"},
+	"auth.refresh_reused":   {"refresh token 已被使用，请重新登录", "Refresh token already used, please log in again"},
+	"auth.refresh_expired":  {"refresh token 已过期", "Refresh token expired"},
+	"auth.registered":       {"注册成功，请查收验证邮件", "Registered, please check your inbox for the verification email"},
+	"auth.logged_out":       {"已退出登录", "Logged out"},
+
+	// 账号与邮箱
+	"user.not_found":         {"用户不存在", "User not found"},
+	"user.username_taken":    {"用户名已存在", "Username already taken"},
+	"user.email_taken":       {"邮箱已被注册", "Email already registered"},
+	"user.email_unavailable": {"邮箱不可用", "Email address is not allowed"},
+	"user.role_invalid":      {"无效的角色", "Invalid role"},
+	"user.own_role":          {"不能修改自己的角色", "You cannot change your own role"},
+	"user.delete_self":       {"不能删除自己", "You cannot delete yourself"},
+	"user.follow_self":       {"不能关注自己", "You cannot follow yourself"},
+	"user.deleted":           {"用户已删除", "User deleted"},
+	"email.link_invalid":     {"验证链接无效或已过期", "Verification link is invalid or expired"},
+	"email.link_used":        {"验证链接已使用", "Verification link already used"},
+	"email.already_verified": {"邮箱已验证", "Email already verified"},
+	"email.wallet_account":   {"钱包账号未设置邮箱", "Wallet accounts have no email address"},
+	"email.verified":         {"邮箱验证成功", "Email verified"},
+	"email.sent":             {"验证邮件已发送", "Verification email sent"},
+
+	// 密码
+	"password.wrong":              {"密码错误", "Incorrect password"},
+	"password.unchanged":          {"新密码不能与原密码相同", "New password must differ from the current one"},
+	"password.too_long":           {"密码不能超过 72 字节", "Password must be at most 72 bytes"},
+	"password.reset_link_invalid": {"重置链接无效或已过期", "Reset link is invalid or expired"},
+	"password.changed":            {"密码已修改", "Password changed"},
+	"password.reset_sent":         {"如果该邮箱已注册，重置密码邮件已发送", "If the email is registered, a password reset email has been sent"},
+	"password.reset_done":         {"密码已重置，请重新登录", "Password reset, please log in again"},
+
+	// 两步验证
+	"mfa.code_required":      {"缺少验证码或恢复码", "A verification code or recovery code is required"},
+	"mfa.code_invalid":       {"验证码错误", "Invalid verification code"},
+	"mfa.login_code_invalid": {"验证码错误", "Invalid verification code"},
+	"mfa.challenge_expired":  {"两步验证已过期，请重新登录", "Two-factor challenge expired, please log in again"},
+	"mfa.challenge_stale":    {"两步验证已关闭，请重新登录", "Two-factor authentication was disabled, please log in again"},
+	"mfa.already_enabled":    {"已启用两步验证", "Two-factor authentication is already enabled"},
+	"mfa.not_enabled":        {"未启用两步验证", "Two-factor authentication is not enabled"},
+	"mfa.setup_required":     {"请先获取两步验证密钥", "Please set up a TOTP secret first"},
+	"mfa.enabled":            {"已启用两步验证，请妥善保存恢复码", "Two-factor authentication enabled, keep your recovery codes safe"},
+	"mfa.disabled":           {"已关闭两步验证", "Two-factor authentication disabled"},
+
+	// 钱包登录
+	"siwe.malformed":         {"签名消息格式错误: {reason}", "Malformed sign-in message: {reason}"},
+	"siwe.domain_mismatch":   {"签名消息的域名不匹配", "Sign-in message domain mismatch"},
+	"siwe.expired":           {"签名消息不在有效期内", "Sign-in message is not within its validity period"},
+	"siwe.signature_invalid": {"签名无效", "Invalid signature"},
+	"siwe.nonce_invalid":     {"nonce 无效、已过期或已使用", "Nonce is invalid, expired or already used"},
+	"siwe.address_taken":     {"该地址已绑定其他账号", "This address is linked to another account"},
+	"siwe.already_linked":    {"已绑定该地址", "Address already linked"},
+	"siwe.linked":            {"绑定成功", "Wallet linked"},
+
+	// 文章与评论
+	"post.not_found":           {"文章不存在", "Post not found"},
+	"post.forbidden":           {"无权操作此文章", "You cannot modify this post"},
+	"post.status_invalid":      {"无效的文章状态", "Invalid post status"},
+	"post.publish_at_required": {"定时发布需要指定未来的 publish_at", "Scheduled posts require a future publish_at"},
+	"post.format_invalid":      {"format 只能是 html 或 markdown", "format must be html or markdown"},
+	"post.render_failed":       {"Markdown 渲染失败", "Failed to render Markdown"},
+	"post.revision_not_found":  {"版本不存在", "Revision not found"},
+	"post.revision_too_large":  {"版本超过 {max} 行，无法比较", "Revisions longer than {max} lines cannot be compared"},
+	"post.deleted":             {"文章已删除", "Post deleted"},
+	"comment.not_found":        {"评论不存在", "Comment not found"},
+	"comment.forbidden":        {"无权操作此评论", "You cannot modify this comment"},
+	"comment.parent_not_found": {"回复的评论不存在", "The comment being replied to does not exist"},
+	"comment.parent_mismatch":  {"回复的评论不属于该文章", "The comment being replied to belongs to another post"},
+	"comment.deleted":          {"评论已删除", "Comment deleted"},
+
+	// 标签与分类
+	"tag.too_many":       {"标签数量过多", "Too many tags"},
+	"tag.name_invalid":   {"标签或分类名称无效", "Invalid tag or category name"},
+	"category.not_found": {"分类不存在", "Category not found"},
+	"category.exists":    {"分类已存在", "Category already exists"},
+
+	// 实时推送
+	"realtime.topic_invalid":   {"无效的订阅主题 {topic}", "Invalid topic {topic}"},
+	"realtime.topic_forbidden": {"无权订阅主题 {topic}", "You cannot subscribe to {topic}"},
+	"realtime.too_many_topics": {"订阅的主题过多，最多 {max} 个", "Too many topics, at most {max} allowed"},
+	"realtime.slow_consumer":   {"消息积压过多，连接已断开，请重连", "Too many pending events, please reconnect"},
+
+	// 通知
+	"notification.type_invalid": {"通知类型只能是 comment、reply 或 mention", "Notification type must be comment, reply or mention"},
+
+	// 上传与附件
+	"upload.file_missing":     {"请通过 file 字段上传文件", "Please upload a file in the file field"},
+	"upload.too_large":        {"文件不能超过 {max}", "File must not exceed {max}"},
+	"upload.type_unsupported": {"不支持的文件类型 {type}", "Unsupported file type {type}"},
+	"upload.image_invalid":    {"无法识别的图片", "The image could not be decoded"},
+	"upload.image_too_large":  {"图片尺寸过大，最多 {max} 像素", "Image dimensions too large, at most {max} pixels"},
+	"attachment.invalid":      {"附件不存在或不属于你", "Attachment not found or not yours"},
+}
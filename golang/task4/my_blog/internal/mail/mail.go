@@ -0,0 +1,92 @@
+// Package mail 发送邮件。Mailer 是可替换的发送接口，
+// 本地开发使用 Log（写日志）或 File（写 .eml 文件），生产环境可接入 SMTP 或第三方服务
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"my_blog/internal/conf"
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New 按配置创建 Mailer
+func New(cfg conf.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return &Log{From: cfg.From}, nil
+	case "file":
+		return &File{Dir: cfg.Dir, From: cfg.From}, nil
+	default:
+		return nil, fmt.Errorf("mail: unknown driver %q", cfg.Driver)
+	}
+}
+
+// Log 把邮件内容写到日志
+type Log struct {
+	From string
+}
+
+func (m *Log) Send(_ context.Context, msg Message) error {
+	log.Printf("📧 mail from=%s to=%s subject=%q\n%s", m.From, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// File 把每封邮件写成 Dir 下的一个 .eml 文件
+type File struct {
+	Dir  string
+	From string
+}
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+func (m *File) Send(_ context.Context, msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), unsafeChars.ReplaceAllString(msg.To, "_"))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		m.From, msg.To, msg.Subject, now.Format(time.RFC1123Z), msg.Body)
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(b.String()), 0o600)
+}
+
+// Memory 把邮件保存在内存中，用于测试
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (m *Memory) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages 已发送的邮件
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
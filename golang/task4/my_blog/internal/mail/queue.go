@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrQueueFull 发送队列已满，邮件被丢弃
+var ErrQueueFull = errors.New("mail: queue is full")
+
+// Queue 异步发送邮件：Send 只把邮件放入缓冲队列就返回，由 Run 在后台逐封交给 Mailer 发送。
+// 每封邮件使用独立的上下文和超时，调用方（如 HTTP 请求）结束或取消不会中断发送
+type Queue struct {
+	mailer  Mailer
+	timeout time.Duration
+	ch      chan Message
+}
+
+// NewQueue 创建容量为 size 的发送队列，每封邮件的发送超时为 timeout
+func NewQueue(mailer Mailer, size int, timeout time.Duration) *Queue {
+	return &Queue{mailer: mailer, timeout: timeout, ch: make(chan Message, size)}
+}
+
+// Send 把邮件放入队列，不等待发送结果；队列已满时立即返回 ErrQueueFull
+func (q *Queue) Send(_ context.Context, msg Message) error {
+	select {
+	case q.ch <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run 逐封发送队列中的邮件，直到 ctx 取消；此时仍在队列中的邮件由 Flush 发送
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-q.ch:
+			q.send(context.Background(), msg)
+		}
+	}
+}
+
+// Flush 同步发送队列中剩余的邮件，用于停机前；ctx 取消时放弃剩余的邮件
+func (q *Queue) Flush(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-q.ch:
+			q.send(ctx, msg)
+		default:
+			return nil
+		}
+	}
+}
+
+func (q *Queue) send(ctx context.Context, msg Message) {
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+	if err := q.mailer.Send(ctx, msg); err != nil {
+		log.Printf("mail: send to %s: %v", msg.To, err)
+	}
+}
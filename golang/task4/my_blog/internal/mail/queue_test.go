@@ -0,0 +1,83 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockingMailer 阻塞到 ctx 结束，记录收到的上下文是否带有截止时间
+type blockingMailer struct {
+	deadline chan bool
+}
+
+func (m *blockingMailer) Send(ctx context.Context, _ Message) error {
+	_, ok := ctx.Deadline()
+	<-ctx.Done()
+	m.deadline <- ok
+	return ctx.Err()
+}
+
+func TestQueue(t *testing.T) {
+	mem := &Memory{}
+	q := NewQueue(mem, 2, time.Second)
+
+	// 调用方的上下文已取消也能入队，发送不受影响
+	reqCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		if err := q.Send(reqCtx, Message{To: to}); err != nil {
+			t.Fatalf("send %s: %v", to, err)
+		}
+	}
+	if err := q.Send(context.Background(), Message{To: "c@example.com"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	if n := len(mem.Messages()); n != 0 {
+		t.Fatalf("expected nothing sent before the worker runs, got %d", n)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Run(ctx)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for len(mem.Messages()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	<-done
+	if msgs := mem.Messages(); len(msgs) != 2 || msgs[0].To != "a@example.com" || msgs[1].To != "b@example.com" {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+
+	// 停止后入队的邮件由 Flush 发送
+	q.Send(context.Background(), Message{To: "d@example.com"})
+	if err := q.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := mem.Messages(); len(msgs) != 3 || msgs[2].To != "d@example.com" {
+		t.Fatalf("expected flushed message, got %+v", msgs)
+	}
+}
+
+func TestQueueTimeout(t *testing.T) {
+	m := &blockingMailer{deadline: make(chan bool, 1)}
+	q := NewQueue(m, 1, 10*time.Millisecond)
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go q.Run(ctx)
+
+	q.Send(context.Background(), Message{To: "a@example.com"})
+	select {
+	case ok := <-m.deadline:
+		if !ok {
+			t.Error("expected the send context to carry a deadline")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("send did not time out")
+	}
+}
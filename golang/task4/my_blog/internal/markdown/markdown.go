@@ -0,0 +1,77 @@
+// Package markdown 文章 Markdown 渲染、净化与摘要
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const (
+	// ExcerptLength 摘要最多保留的字符数
+	ExcerptLength = 140
+
+	cjkPerMinute  = 300 // 中文阅读速度（字/分钟）
+	wordPerMinute = 200 // 英文阅读速度（词/分钟）
+)
+
+var (
+	md = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// ugc 允许常见排版标签，去掉脚本、事件属性和危险链接
+	ugc = bluemonday.UGCPolicy()
+	// strict 去掉全部标签，用于生成纯文本
+	strict = bluemonday.StrictPolicy()
+)
+
+// Render 将 Markdown 渲染为净化后的 HTML
+func Render(src string) (string, error) {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return ugc.Sanitize(buf.String()), nil
+}
+
+// PlainText 将渲染后的 HTML 转为纯文本，连续空白合并为一个空格
+func PlainText(renderedHTML string) string {
+	text := html.UnescapeString(strict.Sanitize(renderedHTML))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Excerpt 从纯文本截取摘要，超长时以省略号结尾
+func Excerpt(text string) string {
+	runes := []rune(text)
+	if len(runes) <= ExcerptLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:ExcerptLength])) + "…"
+}
+
+// ReadingMinutes 估算阅读时长（分钟，至少 1 分钟）：汉字按字计，其他按词计
+func ReadingMinutes(text string) int {
+	cjk, words := 0, 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	minutes := float64(cjk)/cjkPerMinute + float64(words)/wordPerMinute
+	return max(1, int(math.Ceil(minutes)))
+}
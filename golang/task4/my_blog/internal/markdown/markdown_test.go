@@ -0,0 +1,86 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "基本语法",
+			src:      "# 标题\n\n**粗体** 和 `code`",
+			contains: []string{"<h1>标题</h1>", "<strong>粗体</strong>", "<code>code</code>"},
+		},
+		{
+			name:     "GFM 表格",
+			src:      "| a | b |\n|---|---|\n| 1 | 2 |",
+			contains: []string{"<table>", "<td>1</td>"},
+		},
+		{
+			name:     "过滤脚本",
+			src:      "hi <script>alert(1)</script>",
+			excludes: []string{"<script"},
+		},
+		{
+			name:     "过滤 javascript 链接和事件属性",
+			src:      "[x](javascript:alert(1)) <img src=x onerror=alert(1)>",
+			excludes: []string{"javascript:", "onerror"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Render(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(out, s) {
+					t.Errorf("Render(%q) = %q, expected to contain %q", tt.src, out, s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(out, s) {
+					t.Errorf("Render(%q) = %q, expected not to contain %q", tt.src, out, s)
+				}
+			}
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	out, _ := Render("# Hello\n\nworld &amp; *friends*")
+	if got := Excerpt(PlainText(out)); got != "Hello world & friends" {
+		t.Errorf("unexpected excerpt %q", got)
+	}
+
+	long := strings.Repeat("字", ExcerptLength+10)
+	if got := Excerpt(long); got != strings.Repeat("字", ExcerptLength)+"…" {
+		t.Errorf("expected excerpt truncated to %d runes, got %q", ExcerptLength, got)
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{name: "空文本至少一分钟", text: "", expected: 1},
+		{name: "中文 600 字", text: strings.Repeat("字", 600), expected: 2},
+		{name: "英文 250 词", text: strings.Repeat("word ", 250), expected: 2},
+		{name: "中英混合", text: strings.Repeat("字", 150) + strings.Repeat(" word", 100), expected: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadingMinutes(tt.text); got != tt.expected {
+				t.Errorf("ReadingMinutes() = %d, expected %d", got, tt.expected)
+			}
+		})
+	}
+}
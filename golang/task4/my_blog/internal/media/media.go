@@ -0,0 +1,121 @@
+// Package media 上传文件的类型识别与缩略图生成，只依赖标准库
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif" // 注册 GIF 解码器，image.Decode 按文件头选择
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// 支持上传的类型
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+	TypeGIF  = "image/gif"
+	TypeWebP = "image/webp" // 标准库不能解码，只保存原图，不生成缩略图
+)
+
+// extensions 各类型保存时使用的扩展名
+var extensions = map[string]string{
+	TypeJPEG: ".jpg",
+	TypePNG:  ".png",
+	TypeGIF:  ".gif",
+	TypeWebP: ".webp",
+}
+
+// ErrTooManyPixels 图片像素数超过上限，可能是解压炸弹
+var ErrTooManyPixels = errors.New("media: image has too many pixels")
+
+// Supported 类型是否支持上传
+func Supported(contentType string) bool {
+	_, ok := extensions[contentType]
+	return ok
+}
+
+// Extension 类型对应的扩展名，不支持的类型返回空串
+func Extension(contentType string) string { return extensions[contentType] }
+
+// Sniff 按文件头（magic bytes）识别类型，不信任客户端声明的 Content-Type 和文件名
+func Sniff(data []byte) string {
+	t := http.DetectContentType(data)
+	if i := strings.IndexByte(t, ';'); i >= 0 {
+		t = t[:i]
+	}
+	return t
+}
+
+// Decodable 是否可以解码出像素，用于读取尺寸和生成缩略图
+func Decodable(contentType string) bool {
+	switch contentType {
+	case TypeJPEG, TypePNG, TypeGIF:
+		return true
+	}
+	return false
+}
+
+// Decode 解码图片。先只读取头部的尺寸，超过 maxPixels 时不解码像素，直接返回 ErrTooManyPixels
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxPixels/cfg.Height {
+		return nil, ErrTooManyPixels
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Thumbnail 等比缩小到最长边不超过 maxSide，按区域平均取色；本身不超过时原样返回
+func Thumbnail(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw <= maxSide && sh <= maxSide {
+		return src
+	}
+	dw, dh := maxSide, sh*maxSide/sw
+	if sh > sw {
+		dw, dh = sw*maxSide/sh, maxSide
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	// 转成 RGBA 后直接读写像素，避免逐点调用 At
+	rgba := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, max((dy+1)*sh/dh, dy*sh/dh+1)
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, max((dx+1)*sw/dw, dx*sw/dw+1)
+			var r, g, bl, a, n int
+			for y := y0; y < y1; y++ {
+				row := rgba.Pix[y*rgba.Stride+x0*4 : y*rgba.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += int(row[i])
+					g += int(row[i+1])
+					bl += int(row[i+2])
+					a += int(row[i+3])
+					n++
+				}
+			}
+			o := dy*dst.Stride + dx*4
+			dst.Pix[o], dst.Pix[o+1], dst.Pix[o+2], dst.Pix[o+3] = uint8(r/n), uint8(g/n), uint8(bl/n), uint8(a/n)
+		}
+	}
+	return dst
+}
+
+// EncodeThumbnail 编码缩略图：JPEG 原图仍用 JPEG，其余用 PNG 以保留透明度，返回编码后的类型
+func EncodeThumbnail(w io.Writer, img image.Image, sourceType string) (string, error) {
+	if sourceType == TypeJPEG {
+		return TypeJPEG, jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return TypePNG, png.Encode(w, img)
+}
@@ -0,0 +1,109 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encoded(t *testing.T, w, h int, enc func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := enc(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	pngData := encoded(t, 4, 4, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+	jpegData := encoded(t, 4, 4, func(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) })
+	gifData := encoded(t, 4, 4, func(b *bytes.Buffer, img image.Image) error { return gif.Encode(b, img, nil) })
+	webp := append([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), make([]byte, 16)...)
+
+	for _, tc := range []struct {
+		data []byte
+		want string
+		ok   bool
+	}{
+		{pngData, TypePNG, true},
+		{jpegData, TypeJPEG, true},
+		{gifData, TypeGIF, true},
+		{webp, TypeWebP, true},
+		{[]byte("<html><script>alert(1)</script>"), "text/html", false},
+		{[]byte("%PDF-1.7"), "application/pdf", false},
+	} {
+		got := Sniff(tc.data)
+		if got != tc.want || Supported(got) != tc.ok {
+			t.Errorf("Sniff = %q (supported %v), want %q (%v)", got, Supported(got), tc.want, tc.ok)
+		}
+	}
+	if Extension(TypeJPEG) != ".jpg" || Extension("text/html") != "" {
+		t.Error("unexpected extensions")
+	}
+}
+
+func TestDecodeRejectsTooManyPixels(t *testing.T) {
+	data := encoded(t, 100, 50, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+	if _, err := Decode(data, 4999); !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("expected ErrTooManyPixels, got %v", err)
+	}
+	img, err := Decode(data, 5000)
+	if err != nil || img.Bounds().Dx() != 100 {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, err := Decode([]byte("\x89PNG\r\n\x1a\ngarbage"), 5000); err == nil {
+		t.Error("expected error for corrupt image")
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.RGBA{A: 255}
+			if x < 200 {
+				c.R = 255
+			} else {
+				c.B = 255
+			}
+			src.Set(x, y, c)
+		}
+	}
+	thumb := Thumbnail(src, 100)
+	if b := thumb.Bounds(); b.Dx() != 100 || b.Dy() != 25 {
+		t.Fatalf("unexpected thumbnail size %v", b)
+	}
+	if r, _, b, _ := thumb.At(10, 10).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("left half should stay red, got %v", thumb.At(10, 10))
+	}
+	if r, _, b, _ := thumb.At(90, 10).RGBA(); r != 0 || b>>8 != 255 {
+		t.Errorf("right half should stay blue, got %v", thumb.At(90, 10))
+	}
+
+	tall := Thumbnail(image.NewRGBA(image.Rect(0, 0, 30, 600)), 120)
+	if b := tall.Bounds(); b.Dx() != 6 || b.Dy() != 120 {
+		t.Errorf("unexpected tall thumbnail size %v", b)
+	}
+	small := image.NewRGBA(image.Rect(0, 0, 50, 50))
+	if Thumbnail(small, 100) != image.Image(small) {
+		t.Error("small images should be returned as is")
+	}
+
+	var buf bytes.Buffer
+	typ, err := EncodeThumbnail(&buf, thumb, TypeGIF)
+	if err != nil || typ != TypePNG || Sniff(buf.Bytes()) != TypePNG {
+		t.Errorf("gif thumbnails should be encoded as png, got %s %v", typ, err)
+	}
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HTTP HTTP 请求指标，route 取路由模板（如 /api/post/get），未匹配的请求记为 unmatched
+type HTTP struct {
+	Requests *CounterVec
+	Duration *HistogramVec
+}
+
+// NewHTTP 创建并注册 HTTP 请求指标
+func NewHTTP(reg *Registry) *HTTP {
+	m := &HTTP{
+		Requests: NewCounterVec("my_blog_http_requests_total",
+			"HTTP requests by method, route and status code.", "method", "route", "status"),
+		Duration: NewHistogramVec("my_blog_http_request_duration_seconds",
+			"HTTP request latency by method and route.", DefBuckets, "method", "route"),
+	}
+	reg.MustRegister(m.Requests, m.Duration)
+	return m
+}
+
+// DB gorm 查询指标，通过 gorm 插件采集
+type DB struct {
+	Duration *HistogramVec
+	Errors   *CounterVec
+}
+
+// NewDB 创建并注册数据库查询指标
+func NewDB(reg *Registry) *DB {
+	m := &DB{
+		Duration: NewHistogramVec("my_blog_db_query_duration_seconds",
+			"Database query latency by operation and table.",
+			[]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}, "operation", "table"),
+		Errors: NewCounterVec("my_blog_db_query_errors_total",
+			"Failed database queries by operation and table.", "operation", "table"),
+	}
+	reg.MustRegister(m.Duration, m.Errors)
+	return m
+}
+
+const startKey = "metrics:start"
+
+// Name 实现 gorm.Plugin
+func (m *DB) Name() string { return "metrics" }
+
+// Initialize 实现 gorm.Plugin，在各类操作前后注册计时回调
+func (m *DB) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	type registrar struct {
+		op     string
+		before func(string, func(*gorm.DB)) error
+		after  func(string, func(*gorm.DB)) error
+	}
+	regs := []registrar{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, r := range regs {
+		if err := r.before("metrics:before_"+r.op, start); err != nil {
+			return err
+		}
+		if err := r.after("metrics:after_"+r.op, m.observe(r.op)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func start(db *gorm.DB) { db.InstanceSet(startKey, time.Now()) }
+
+func (m *DB) observe(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		m.Duration.Observe(time.Since(v.(time.Time)).Seconds(), op, table)
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			m.Errors.Inc(op, table)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	reg := NewRegistry()
+	requests := NewCounterVec("requests_total", "Requests.", "route")
+	latency := NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	reg.MustRegister(requests, latency)
+
+	requests.Inc(`/a"b`)
+	requests.Add(2, "/c")
+	latency.Observe(0.05, "/c")
+	latency.Observe(0.1, "/c")
+	latency.Observe(3, "/c")
+
+	var b strings.Builder
+	if err := reg.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{route="/a\"b"} 1
+requests_total{route="/c"} 2
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/c",le="0.1"} 2
+latency_seconds_bucket{route="/c",le="1"} 2
+latency_seconds_bucket{route="/c",le="+Inf"} 3
+latency_seconds_sum{route="/c"} 3.15
+latency_seconds_count{route="/c"} 3
+`
+	if b.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+	if requests.Value("/c") != 2 || latency.Count("/c") != 3 {
+		t.Error("unexpected values")
+	}
+}
@@ -1,38 +1,48 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
 	"my_blog/internal/util"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// Authenticator 校验 access token，返回其中的用户信息
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*util.Claims, error)
+}
+
+func AuthMiddleware(auth Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "缺少 Authorization 头"})
+			respond.Error(c, service.ErrAuthHeaderMissing)
 			return
 		}
 
 		// 格式应为 "Bearer <token>"
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization 格式错误"})
+			respond.Error(c, service.ErrAuthHeaderFormat)
 			return
 		}
 
-		tokenString := parts[1]
-		claims, err := util.ParseToken(tokenString)
+		claims, err := auth.Authenticate(c.Request.Context(), parts[1])
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "无效或过期的 token"})
+			respond.Error(c, err)
 			return
 		}
 
 		// 将用户信息存入上下文，供后续 handler 使用
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Set("jti", claims.ID)
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
 		c.Next()
 	}
 }
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/conf"
+)
+
+// CORS 跨域中间件，每次请求读取当前配置，因此支持热更新
+func CORS(current func() conf.CORSConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := current()
+		origin := c.GetHeader("Origin")
+		if origin == "" || len(cfg.AllowOrigins) == 0 {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		allowAll := slices.Contains(cfg.AllowOrigins, "*")
+		if !cfg.AllowOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		if allowAll && !cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+		}
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			h.Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowMethods, ", "))
+			h.Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowHeaders, ", "))
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
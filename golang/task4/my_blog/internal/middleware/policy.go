@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"my_blog/internal/apperr"
+	"my_blog/internal/model"
+	"my_blog/internal/respond"
+	"my_blog/internal/service"
+)
+
+// Resource 描述一类受所有权保护的资源
+type Resource struct {
+	Key       string                                                  // 加载后存入 gin.Context 的键，handler 通过 c.MustGet(Key) 取用
+	Load      func(ctx context.Context, id uint) (model.Owned, error) // 按 ID 加载资源，不存在时返回对应的领域错误
+	Forbidden *apperr.Error                                           // 非所有者且无权限时的错误
+}
+
+// HasRole 当前登录用户是否具有给定角色之一
+func HasRole(c *gin.Context, roles ...string) bool {
+	role := c.GetString("role")
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole 仅允许具有给定角色之一的用户访问，需放在 AuthMiddleware 之后
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasRole(c, roles...) {
+			respond.Error(c, service.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// VerifiedChecker 判断用户是否已完成身份验证
+type VerifiedChecker interface {
+	CheckVerified(ctx context.Context, userID uint) error
+}
+
+// RequireVerifiedEmail 仅允许已验证邮箱（或绑定了以太坊地址）的用户继续，需放在 AuthMiddleware 之后
+func RequireVerifiedEmail(checker VerifiedChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := checker.CheckVerified(c.Request.Context(), c.GetUint("user_id")); err != nil {
+			respond.Error(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// OwnerOrRole 按请求体中的 id 加载资源，仅允许所有者或具有给定角色的用户继续，
+// 加载到的资源以 res.Key 存入上下文。请求体通过 ShouldBindBodyWith 缓存，handler 需用同样方式绑定。
+func OwnerOrRole(res Resource, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			ID uint `json:"id" binding:"required"`
+		}
+		if err := c.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+			respond.Error(c, respond.BindError(err))
+			return
+		}
+
+		obj, err := res.Load(c.Request.Context(), input.ID)
+		if err != nil {
+			respond.Error(c, err)
+			return
+		}
+
+		if obj.OwnerID() != c.GetUint("user_id") && !HasRole(c, roles...) {
+			respond.Error(c, res.Forbidden)
+			return
+		}
+
+		c.Set(res.Key, obj)
+		c.Next()
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"my_blog/internal/util"
+)
+
+// RequestIDHeader 请求 ID 头，上游（如网关）已设置时沿用，否则生成新的
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID 为每个请求分配请求 ID，写入上下文 "request_id" 与响应头
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			var err error
+			if id, err = util.RandomToken(8); err != nil {
+				id = "unknown"
+			}
+		}
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID 只接受长度适中的可打印 ASCII，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
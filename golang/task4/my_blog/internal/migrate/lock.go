@@ -0,0 +1,78 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"my_blog/internal/conf"
+)
+
+const (
+	lockName = "my_blog_schema_migrations"
+	// pgLockKey PostgreSQL advisory lock 的键，任意固定值，各实例一致即可
+	pgLockKey int64 = 0x6d795f626c6f67
+)
+
+// lock 获取数据库级的迁移锁，保证多个实例同时启动时只有一个执行迁移。
+// MySQL 用 GET_LOCK，PostgreSQL 用 advisory lock，二者都绑定在会话上，
+// 因此占用一个专用连接直到解锁；SQLite 只允许单个写者，迁移事务本身即串行，无需加锁
+func (m *Migrator) lock(ctx context.Context) (unlock func(), err error) {
+	if m.driver != conf.DriverMySQL && m.driver != conf.DriverPostgres {
+		return func() {}, nil
+	}
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch m.driver {
+	case conf.DriverMySQL:
+		var got sql.NullInt64
+		seconds := int(m.LockTimeout / time.Second)
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, seconds).Scan(&got); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if !got.Valid || got.Int64 != 1 {
+			conn.Close()
+			return nil, ErrLockTimeout
+		}
+		return func() {
+			conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+			conn.Close()
+		}, nil
+
+	default:
+		deadline := time.Now().Add(m.LockTimeout)
+		for {
+			var got bool
+			if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", pgLockKey).Scan(&got); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			if got {
+				break
+			}
+			if time.Now().After(deadline) {
+				conn.Close()
+				return nil, ErrLockTimeout
+			}
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return nil, ctx.Err()
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+		return func() {
+			conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", pgLockKey)
+			conn.Close()
+		}, nil
+	}
+}
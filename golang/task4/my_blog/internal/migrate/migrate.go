@@ -0,0 +1,375 @@
+// Package migrate 版本化数据库迁移：按版本号顺序执行 sql/<driver>/ 下的
+// NNNN_name.up.sql / NNNN_name.down.sql，执行记录与校验和保存在 schema_migrations 表
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var files embed.FS
+
+var (
+	ErrChecksumMismatch = errors.New("migrate: applied migration has been modified")
+	ErrMissingMigration = errors.New("migrate: applied migration file is missing")
+	ErrLockTimeout      = errors.New("migrate: timed out waiting for migration lock")
+)
+
+// Migration 一个版本的迁移脚本
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // up 脚本的 sha256，已执行的迁移被改动时拒绝继续
+}
+
+// Record schema_migrations 表中的执行记录
+type Record struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (Record) TableName() string { return "schema_migrations" }
+
+// Status 单个迁移的执行状态
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Modified  bool // 已执行后脚本被改动
+	Missing   bool // 已执行但脚本文件不存在
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migrations 返回内置的某个驱动的迁移，按版本号升序
+func Migrations(driver string) ([]Migration, error) {
+	return Load(files, path.Join("sql", driver))
+}
+
+// Load 从 fsys 的 dir 目录加载迁移，每个版本必须同时有 up 与 down 脚本
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migrate: invalid migration file name %q", e.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrate: version %d has conflicting names %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+			sum := sha256.Sum256(body)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migrate: version %d (%s) needs both up and down scripts", mig.Version, mig.Name)
+		}
+		out = append(out, *mig)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Migrator 对某个数据库执行迁移
+type Migrator struct {
+	db          *gorm.DB
+	driver      string
+	migrations  []Migration
+	LockTimeout time.Duration // 等待其他实例释放迁移锁的最长时间
+	// Legacy 执行第一个迁移前，为 AutoMigrate 创建的旧库补齐列和索引的脚本，见 upgradeLegacy
+	Legacy string
+}
+
+// New 使用内置迁移创建 Migrator
+func New(db *gorm.DB, driver string) (*Migrator, error) {
+	migrations, err := Migrations(driver)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: no migrations for driver %q: %w", driver, err)
+	}
+	m := NewWithMigrations(db, driver, migrations)
+	if legacy, err := fs.ReadFile(files, path.Join("sql", driver, "legacy", "baseline.sql")); err == nil {
+		m.Legacy = string(legacy)
+	}
+	return m, nil
+}
+
+// NewWithMigrations 使用给定的迁移创建 Migrator
+func NewWithMigrations(db *gorm.DB, driver string, migrations []Migration) *Migrator {
+	return &Migrator{db: db, driver: driver, migrations: migrations, LockTimeout: time.Minute}
+}
+
+// ensureTable 创建 schema_migrations 表
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		checksum varchar(64) NOT NULL,
+		applied_at timestamp NOT NULL
+	)`).Error
+}
+
+// applied 已执行的迁移，只读；schema_migrations 表不存在时视为全部未执行
+func (m *Migrator) applied(ctx context.Context) (map[int64]Record, error) {
+	if !m.db.WithContext(ctx).Migrator().HasTable(&Record{}) {
+		return map[int64]Record{}, nil
+	}
+	var records []Record
+	if err := m.db.WithContext(ctx).Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int64]Record, len(records))
+	for _, r := range records {
+		out[r.Version] = r
+	}
+	return out, nil
+}
+
+// Status 返回所有迁移（含已执行但文件缺失的）的状态，按版本号升序
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Status, 0, len(m.migrations))
+	known := make(map[int64]bool, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if r, ok := applied[mig.Version]; ok {
+			at := r.AppliedAt
+			s.Applied, s.AppliedAt, s.Modified = true, &at, r.Checksum != mig.Checksum
+		}
+		out = append(out, s)
+	}
+	for v, r := range applied {
+		if !known[v] {
+			at := r.AppliedAt
+			out = append(out, Status{Version: v, Name: r.Name, Applied: true, AppliedAt: &at, Missing: true})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Pending 返回尚未执行的迁移数，只读取数据库，可用于就绪检查
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, s := range status {
+		if !s.Applied {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Up 按版本号顺序执行至多 steps 个未执行的迁移，steps <= 0 表示全部执行。
+// 已执行的迁移脚本被改动时返回 ErrChecksumMismatch，不执行任何迁移
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, mig := range m.migrations {
+		if r, ok := applied[mig.Version]; ok && r.Checksum != mig.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+
+	var done []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if steps > 0 && len(done) == steps {
+			break
+		}
+		if mig.Version == m.migrations[0].Version {
+			if err := m.upgradeLegacy(ctx); err != nil {
+				return done, fmt.Errorf("migrate: upgrade legacy schema: %w", err)
+			}
+		}
+		err := m.exec(ctx, mig.Up, func(tx *gorm.DB) error {
+			return tx.Create(&Record{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migrate: %d_%s up: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down 按版本号倒序回滚至多 steps 个已执行的迁移，steps <= 0 时回滚一个
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	byVersion := make(map[int64]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		byVersion[mig.Version] = mig
+	}
+
+	var done []Migration
+	for _, v := range versions {
+		if len(done) == steps {
+			break
+		}
+		mig, ok := byVersion[v]
+		if !ok {
+			return done, fmt.Errorf("%w: version %d", ErrMissingMigration, v)
+		}
+		err := m.exec(ctx, mig.Down, func(tx *gorm.DB) error {
+			return tx.Delete(&Record{}, "version = ?", mig.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migrate: %d_%s down: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+var (
+	addColumnStmt   = regexp.MustCompile("(?is)^ALTER TABLE\\s+[`\"]?(\\w+)[`\"]?\\s+ADD COLUMN\\s+[`\"]?(\\w+)")
+	createIndexStmt = regexp.MustCompile("(?is)^CREATE\\s+(?:UNIQUE\\s+)?INDEX\\s+(?:IF NOT EXISTS\\s+)?[`\"]?(\\w+)[`\"]?\\s+ON\\s+[`\"]?(\\w+)")
+)
+
+// upgradeLegacy 为迁移系统上线前由 AutoMigrate 创建的库补齐列和索引。
+// 第一个迁移用 CREATE TABLE IF NOT EXISTS 建表，会跳过已存在的旧表，因此先逐条执行 Legacy 脚本：
+// ADD COLUMN 与 CREATE INDEX 只在表存在且列、索引缺失时执行，其余语句（如回填数据）在旧库上总是执行。
+// 空库上什么都不做
+func (m *Migrator) upgradeLegacy(ctx context.Context) error {
+	if m.Legacy == "" {
+		return nil
+	}
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable("users") {
+		return nil
+	}
+
+	var stmts []string
+	for _, stmt := range splitStatements(m.Legacy) {
+		if g := addColumnStmt.FindStringSubmatch(stmt); g != nil {
+			if !db.Migrator().HasTable(g[1]) || db.Migrator().HasColumn(g[1], g[2]) {
+				continue
+			}
+		} else if g := createIndexStmt.FindStringSubmatch(stmt); g != nil {
+			if !db.Migrator().HasTable(g[2]) || db.Migrator().HasIndex(g[2], g[1]) {
+				continue
+			}
+		}
+		stmts = append(stmts, stmt)
+	}
+	return m.exec(ctx, strings.Join(stmts, "\n"), func(*gorm.DB) error { return nil })
+}
+
+// exec 在一个事务中执行脚本并更新执行记录。
+// 注意 MySQL 的 DDL 会隐式提交，脚本中途失败时需人工处理
+func (m *Migrator) exec(ctx context.Context, script string, record func(tx *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return record(tx)
+	})
+}
+
+// splitStatements 按行尾分号拆分脚本，忽略 -- 注释行
+func splitStatements(script string) []string {
+	var (
+		out []string
+		buf strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		if strings.HasSuffix(trimmed, ";") {
+			out = append(out, strings.TrimSpace(buf.String()))
+			buf.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(buf.String()); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
@@ -0,0 +1,268 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"my_blog/internal/conf"
+	"my_blog/internal/database"
+	"my_blog/internal/model"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenSQLite(":memory:", &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_add_bio.up.sql":   {Data: []byte("ALTER TABLE t ADD COLUMN bio text;")},
+		"m/0002_add_bio.down.sql": {Data: []byte("ALTER TABLE t DROP COLUMN bio;")},
+		"m/0001_init.up.sql":      {Data: []byte("CREATE TABLE t (id integer);")},
+		"m/0001_init.down.sql":    {Data: []byte("DROP TABLE t;")},
+	}
+	migrations, err := Load(fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Name != "add_bio" {
+		t.Errorf("unexpected migrations %+v", migrations)
+	}
+
+	delete(fsys, "m/0002_add_bio.down.sql")
+	if _, err := Load(fsys, "m"); err == nil {
+		t.Error("expected error for migration without down script")
+	}
+
+	fsys["m/init.sql"] = &fstest.MapFile{Data: []byte("")}
+	if _, err := Load(fsys, "m"); err == nil {
+		t.Error("expected error for invalid file name")
+	}
+}
+
+func TestBundledMigrations(t *testing.T) {
+	for _, driver := range []string{conf.DriverMySQL, conf.DriverPostgres, conf.DriverSQLite} {
+		migrations, err := Migrations(driver)
+		if err != nil {
+			t.Fatalf("%s: %v", driver, err)
+		}
+		if len(migrations) == 0 || migrations[0].Version != 1 {
+			t.Errorf("%s: unexpected migrations %+v", driver, migrations)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := "-- comment\nCREATE TABLE t (\n  id integer\n);\n\nINSERT INTO t VALUES (1);\nSELECT 1"
+	got := splitStatements(script)
+	if len(got) != 3 || got[0] != "CREATE TABLE t (\n  id integer\n);" || got[2] != "SELECT 1" {
+		t.Errorf("unexpected statements %q", got)
+	}
+}
+
+func TestUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	fsys := fstest.MapFS{
+		"m/0001_init.up.sql":      {Data: []byte("CREATE TABLE t (id integer);")},
+		"m/0001_init.down.sql":    {Data: []byte("DROP TABLE t;")},
+		"m/0002_add_bio.up.sql":   {Data: []byte("ALTER TABLE t ADD COLUMN bio text;")},
+		"m/0002_add_bio.down.sql": {Data: []byte("ALTER TABLE t DROP COLUMN bio;")},
+	}
+	migrations, err := Load(fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewWithMigrations(db, conf.DriverSQLite, migrations)
+
+	if n, err := m.Pending(ctx); err != nil || n != 2 {
+		t.Fatalf("expected 2 pending, got %d %v", n, err)
+	}
+	// 就绪检查只读数据库，不会创建 schema_migrations
+	if db.Migrator().HasTable("schema_migrations") {
+		t.Error("Pending should not create schema_migrations")
+	}
+	if applied, err := m.Up(ctx, 1); err != nil || len(applied) != 1 {
+		t.Fatalf("up 1: %v %+v", err, applied)
+	}
+	if db.Migrator().HasColumn("t", "bio") {
+		t.Error("bio should not exist before second migration")
+	}
+	if applied, err := m.Up(ctx, 0); err != nil || len(applied) != 1 || applied[0].Version != 2 {
+		t.Fatalf("up: %v %+v", err, applied)
+	}
+	if !db.Migrator().HasColumn("t", "bio") {
+		t.Error("bio should exist after second migration")
+	}
+	if applied, _ := m.Up(ctx, 0); len(applied) != 0 {
+		t.Errorf("expected nothing to apply, got %+v", applied)
+	}
+
+	if reverted, err := m.Down(ctx, 0); err != nil || len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Fatalf("down: %v %+v", err, reverted)
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 || !status[0].Applied || status[1].Applied {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// 已执行的脚本被修改后拒绝继续迁移
+	migrations[0].Checksum = "changed"
+	m = NewWithMigrations(db, conf.DriverSQLite, migrations)
+	if _, err := m.Up(ctx, 0); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+	if status, _ := m.Status(ctx); !status[0].Modified {
+		t.Errorf("expected modified status, got %+v", status[0])
+	}
+
+	// 脚本文件缺失的已执行迁移无法回滚
+	m = NewWithMigrations(db, conf.DriverSQLite, migrations[1:])
+	if _, err := m.Down(ctx, 1); !errors.Is(err, ErrMissingMigration) {
+		t.Errorf("expected missing migration, got %v", err)
+	}
+}
+
+// TestSchemaMatchesModels 内置迁移生成的表结构须覆盖所有模型字段
+func TestSchemaMatchesModels(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m, err := New(db, conf.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	models := []interface{}{
+		&model.User{}, &model.Post{}, &model.Comment{},
+		&model.RefreshToken{}, &model.RevokedToken{},
+		&model.Tag{}, &model.Category{}, &model.PostRevision{},
+		&model.ActionToken{}, &model.PasswordResetToken{}, &model.LoginEvent{},
+		&model.RecoveryCode{}, &model.SIWENonce{},
+		&model.Notification{}, &model.NotificationPreference{},
+		&model.PostLike{}, &model.Bookmark{}, &model.Follow{},
+		&model.Attachment{},
+	}
+	for _, mdl := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(mdl); err != nil {
+			t.Fatal(err)
+		}
+		if !db.Migrator().HasTable(stmt.Schema.Table) {
+			t.Errorf("missing table %s", stmt.Schema.Table)
+			continue
+		}
+		for _, f := range stmt.Schema.Fields {
+			if f.DBName == "" || f.IgnoreMigration {
+				continue
+			}
+			if !db.Migrator().HasColumn(stmt.Schema.Table, f.DBName) {
+				t.Errorf("missing column %s.%s", stmt.Schema.Table, f.DBName)
+			}
+		}
+	}
+	for _, table := range []string{"post_tags", "post_categories"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("missing join table %s", table)
+		}
+	}
+
+	// 全部回滚后只剩 schema_migrations
+	if _, err := m.Down(ctx, 100); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("users should be dropped after rolling back")
+	}
+}
+
+// 迁移系统上线前 AutoMigrate 创建的最初表结构
+type legacyUser struct {
+	gorm.Model
+	Username string `gorm:"unique;not null"`
+	Password string `gorm:"not null"`
+	Email    string `gorm:"unique;not null"`
+}
+
+func (legacyUser) TableName() string { return "users" }
+
+type legacyPost struct {
+	gorm.Model
+	Title   string `gorm:"not null"`
+	Content string `gorm:"not null"`
+	UserID  uint
+}
+
+func (legacyPost) TableName() string { return "posts" }
+
+type legacyComment struct {
+	gorm.Model
+	Content string `gorm:"not null"`
+	UserID  uint
+	PostID  uint
+}
+
+func (legacyComment) TableName() string { return "comments" }
+
+func TestUpgradeLegacySchema(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&legacyUser{}, &legacyPost{}, &legacyComment{}); err != nil {
+		t.Fatal(err)
+	}
+	user := legacyUser{Username: "alice", Password: "x", Email: "alice@example.com"}
+	db.Create(&user)
+	post := legacyPost{Title: "t", Content: "c", UserID: user.ID}
+	db.Create(&post)
+	db.Create(&legacyComment{Content: "c", UserID: user.ID, PostID: post.ID})
+
+	m, err := New(db, conf.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for table, columns := range map[string][]string{
+		"users":    {"role", "email_verified_at"},
+		"posts":    {"status", "published_at", "content_html", "like_count"},
+		"comments": {"parent_id", "root_id", "hidden_at"},
+	} {
+		for _, col := range columns {
+			if !db.Migrator().HasColumn(table, col) {
+				t.Errorf("missing column %s.%s after upgrade", table, col)
+			}
+		}
+	}
+	if !db.Migrator().HasIndex("posts", "idx_posts_status") {
+		t.Error("missing index idx_posts_status after upgrade")
+	}
+
+	var got model.Post
+	if err := db.Preload("User").First(&got, post.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != model.PostStatusPublished || got.PublishedAt == nil || got.User.Role != model.RoleAuthor {
+		t.Errorf("legacy rows should get defaults, got status=%q published_at=%v role=%q", got.Status, got.PublishedAt, got.User.Role)
+	}
+}
@@ -0,0 +1,139 @@
+-- 初始表结构，与此前 AutoMigrate 生成的结构一致，已有库上执行不会报错
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `username` varchar(191) NOT NULL,
+  `password` longtext NOT NULL,
+  `email` varchar(191) NOT NULL,
+  `role` varchar(20) NOT NULL DEFAULT 'author',
+  PRIMARY KEY (`id`),
+  CONSTRAINT `uni_users_username` UNIQUE (`username`),
+  CONSTRAINT `uni_users_email` UNIQUE (`email`),
+  INDEX `idx_users_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `posts` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `title` longtext NOT NULL,
+  `content` longtext NOT NULL,
+  `content_html` longtext,
+  `excerpt` varchar(512),
+  `reading_time` bigint,
+  `user_id` bigint unsigned,
+  `status` varchar(20) NOT NULL DEFAULT 'published',
+  `publish_at` datetime(3) NULL,
+  `published_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_posts_publish_at` (`publish_at`),
+  INDEX `idx_posts_status` (`status`),
+  INDEX `idx_posts_user_id` (`user_id`),
+  INDEX `idx_posts_deleted_at` (`deleted_at`),
+  CONSTRAINT `fk_posts_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `categories` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `name` varchar(32) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_categories_name` (`name`),
+  INDEX `idx_categories_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `post_categories` (
+  `post_id` bigint unsigned,
+  `category_id` bigint unsigned,
+  PRIMARY KEY (`post_id`, `category_id`),
+  CONSTRAINT `fk_post_categories_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+  CONSTRAINT `fk_post_categories_category` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `tags` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `name` varchar(32) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_tags_name` (`name`),
+  INDEX `idx_tags_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `post_tags` (
+  `post_id` bigint unsigned,
+  `tag_id` bigint unsigned,
+  PRIMARY KEY (`post_id`, `tag_id`),
+  CONSTRAINT `fk_post_tags_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+  CONSTRAINT `fk_post_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `comments` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `content` longtext NOT NULL,
+  `user_id` bigint unsigned,
+  `post_id` bigint unsigned,
+  `parent_id` bigint unsigned,
+  `root_id` bigint unsigned,
+  `hidden_at` datetime(3) NULL,
+  `hidden_by` bigint unsigned,
+  PRIMARY KEY (`id`),
+  INDEX `idx_comments_root_id` (`root_id`),
+  INDEX `idx_comments_parent_id` (`parent_id`),
+  INDEX `idx_comments_post_id` (`post_id`),
+  INDEX `idx_comments_deleted_at` (`deleted_at`),
+  CONSTRAINT `fk_comments_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+  CONSTRAINT `fk_comments_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `token_hash` varchar(64) NOT NULL,
+  `family_id` varchar(64) NOT NULL,
+  `access_jti` varchar(64),
+  `expires_at` datetime(3) NOT NULL,
+  `revoked_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_refresh_tokens_family_id` (`family_id`),
+  UNIQUE INDEX `idx_refresh_tokens_token_hash` (`token_hash`),
+  INDEX `idx_refresh_tokens_user_id` (`user_id`),
+  INDEX `idx_refresh_tokens_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `revoked_tokens` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `jti` varchar(64) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_revoked_tokens_expires_at` (`expires_at`),
+  UNIQUE INDEX `idx_revoked_tokens_jti` (`jti`)
+);
+
+CREATE TABLE IF NOT EXISTS `post_revisions` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `post_id` bigint unsigned NOT NULL,
+  `version` bigint NOT NULL,
+  `title` longtext,
+  `content` longtext,
+  `editor_id` bigint unsigned,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_post_version` (`post_id`, `version`),
+  INDEX `idx_post_revisions_deleted_at` (`deleted_at`)
+);
@@ -0,0 +1,16 @@
+-- 邮箱验证：已有账号视为已验证，避免升级后无法发文
+ALTER TABLE `users` ADD COLUMN `email_verified_at` datetime(3) NULL;
+UPDATE `users` SET `email_verified_at` = CURRENT_TIMESTAMP(3);
+
+CREATE TABLE `action_tokens` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `purpose` varchar(32) NOT NULL,
+  `jti` varchar(64) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_action_tokens_user_id` (`user_id`),
+  UNIQUE INDEX `idx_action_tokens_jti` (`jti`)
+);
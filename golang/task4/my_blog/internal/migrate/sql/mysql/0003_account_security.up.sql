@@ -0,0 +1,28 @@
+-- 账号安全：登录失败锁定、重置密码令牌、登录审计
+ALTER TABLE `users` ADD COLUMN `failed_logins` bigint NOT NULL DEFAULT 0;
+ALTER TABLE `users` ADD COLUMN `locked_until` datetime(3) NULL;
+
+CREATE TABLE `password_reset_tokens` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `token_hash` varchar(64) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_password_reset_tokens_user_id` (`user_id`),
+  UNIQUE INDEX `idx_password_reset_tokens_token_hash` (`token_hash`)
+);
+
+CREATE TABLE `login_events` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `ip` varchar(64),
+  `user_agent` varchar(255),
+  `success` boolean NOT NULL,
+  `reason` varchar(32),
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_login_events_user_id` (`user_id`),
+  INDEX `idx_login_events_created_at` (`created_at`)
+);
@@ -0,0 +1,4 @@
+DROP TABLE `recovery_codes`;
+ALTER TABLE `users` DROP COLUMN `mfa_enabled_at`;
+ALTER TABLE `users` DROP COLUMN `totp_last_step`;
+ALTER TABLE `users` DROP COLUMN `totp_secret`;
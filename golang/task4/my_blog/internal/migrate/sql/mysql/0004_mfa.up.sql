@@ -0,0 +1,14 @@
+-- 两步验证：TOTP 密钥与恢复码
+ALTER TABLE `users` ADD COLUMN `totp_secret` varchar(64);
+ALTER TABLE `users` ADD COLUMN `totp_last_step` bigint NOT NULL DEFAULT 0;
+ALTER TABLE `users` ADD COLUMN `mfa_enabled_at` datetime(3) NULL;
+
+CREATE TABLE `recovery_codes` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `code_hash` varchar(64) NOT NULL,
+  `used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_recovery_codes_user_id` (`user_id`)
+);
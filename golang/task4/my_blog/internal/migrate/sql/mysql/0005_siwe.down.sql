@@ -0,0 +1,3 @@
+DROP TABLE `siwe_nonces`;
+DROP INDEX `idx_users_eth_address` ON `users`;
+ALTER TABLE `users` DROP COLUMN `eth_address`;
@@ -0,0 +1,14 @@
+-- 以太坊钱包登录：用户绑定的地址与一次性 nonce
+ALTER TABLE `users` ADD COLUMN `eth_address` varchar(42) NULL;
+CREATE UNIQUE INDEX `idx_users_eth_address` ON `users` (`eth_address`);
+
+CREATE TABLE `siwe_nonces` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `nonce` varchar(64) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_siwe_nonces_nonce` (`nonce`),
+  INDEX `idx_siwe_nonces_expires_at` (`expires_at`)
+);
@@ -0,0 +1,27 @@
+-- 站内通知与通知设置
+CREATE TABLE `notifications` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `actor_id` bigint unsigned NOT NULL,
+  `actor` varchar(191),
+  `type` varchar(20) NOT NULL,
+  `post_id` bigint unsigned NOT NULL,
+  `comment_id` bigint unsigned NOT NULL,
+  `excerpt` varchar(255),
+  `read_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_notifications_user_read` (`user_id`, `read_at`),
+  INDEX `idx_notifications_created_at` (`created_at`)
+);
+
+CREATE TABLE `notification_preferences` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `in_app` boolean NOT NULL,
+  `email` boolean NOT NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_notification_preferences_user_type` (`user_id`, `type`)
+);
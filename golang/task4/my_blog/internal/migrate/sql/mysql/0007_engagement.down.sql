@@ -0,0 +1,7 @@
+DROP TABLE `bookmarks`;
+DROP TABLE `post_likes`;
+DROP INDEX `idx_posts_comment_count` ON `posts`;
+DROP INDEX `idx_posts_like_count` ON `posts`;
+ALTER TABLE `posts` DROP COLUMN `view_count`;
+ALTER TABLE `posts` DROP COLUMN `comment_count`;
+ALTER TABLE `posts` DROP COLUMN `like_count`;
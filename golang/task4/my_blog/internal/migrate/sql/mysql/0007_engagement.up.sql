@@ -0,0 +1,27 @@
+-- 点赞、收藏与文章互动计数
+ALTER TABLE `posts` ADD COLUMN `like_count` bigint NOT NULL DEFAULT 0;
+ALTER TABLE `posts` ADD COLUMN `comment_count` bigint NOT NULL DEFAULT 0;
+ALTER TABLE `posts` ADD COLUMN `view_count` bigint NOT NULL DEFAULT 0;
+CREATE INDEX `idx_posts_like_count` ON `posts`(`like_count`);
+CREATE INDEX `idx_posts_comment_count` ON `posts`(`comment_count`);
+UPDATE `posts` SET `comment_count` = (SELECT COUNT(*) FROM `comments` WHERE `comments`.`post_id` = `posts`.`id` AND `comments`.`deleted_at` IS NULL);
+
+CREATE TABLE `post_likes` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `post_id` bigint unsigned NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_post_likes_user_post` (`user_id`, `post_id`),
+  INDEX `idx_post_likes_post_id` (`post_id`)
+);
+
+CREATE TABLE `bookmarks` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `post_id` bigint unsigned NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_bookmarks_user_post` (`user_id`, `post_id`),
+  INDEX `idx_bookmarks_post_id` (`post_id`)
+);
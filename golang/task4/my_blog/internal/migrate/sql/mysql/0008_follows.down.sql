@@ -0,0 +1,4 @@
+DROP INDEX `idx_posts_user_published` ON `posts`;
+DROP TABLE `follows`;
+ALTER TABLE `users` DROP COLUMN `following_count`;
+ALTER TABLE `users` DROP COLUMN `follower_count`;
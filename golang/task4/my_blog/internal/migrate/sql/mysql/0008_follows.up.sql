@@ -0,0 +1,16 @@
+-- 用户关注关系与关注计数
+ALTER TABLE `users` ADD COLUMN `follower_count` bigint NOT NULL DEFAULT 0;
+ALTER TABLE `users` ADD COLUMN `following_count` bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE `follows` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `follower_id` bigint unsigned NOT NULL,
+  `followee_id` bigint unsigned NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_follows_pair` (`follower_id`, `followee_id`),
+  INDEX `idx_follows_followee_id` (`followee_id`)
+);
+
+-- 信息流按发布时间读取关注作者的文章
+CREATE INDEX `idx_posts_user_published` ON `posts`(`user_id`, `published_at`);
@@ -0,0 +1,22 @@
+-- 文章图片附件
+CREATE TABLE `attachments` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `post_id` bigint unsigned NULL,
+  `filename` varchar(255),
+  `content_type` varchar(64) NOT NULL,
+  `size` bigint NOT NULL,
+  `width` bigint,
+  `height` bigint,
+  `key` varchar(255) NOT NULL,
+  `thumbnail_key` varchar(255),
+  `url` varchar(1024) NOT NULL,
+  `thumbnail_url` varchar(1024),
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_attachments_user_id` (`user_id`),
+  INDEX `idx_attachments_post_id` (`post_id`),
+  UNIQUE INDEX `idx_attachments_key` (`key`),
+  INDEX `idx_attachments_updated_at` (`updated_at`)
+);
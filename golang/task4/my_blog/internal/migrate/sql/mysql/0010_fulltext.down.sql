@@ -0,0 +1,2 @@
+DROP INDEX `ft_comments_content` ON `comments`;
+DROP INDEX `ft_posts_title_content` ON `posts`;
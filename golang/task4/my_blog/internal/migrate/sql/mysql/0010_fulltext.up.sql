@@ -0,0 +1,15 @@
+-- 全文检索（search.engine = mysql）使用的 FULLTEXT 索引，ngram 分词支持中文。
+-- 此前由程序启动时创建，已存在时跳过；MySQL 不支持 ADD INDEX IF NOT EXISTS，按 information_schema 判断
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'posts' AND index_name = 'ft_posts_title_content') = 0,
+  'ALTER TABLE `posts` ADD FULLTEXT INDEX `ft_posts_title_content` (`title`, `content`) WITH PARSER ngram',
+  'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'comments' AND index_name = 'ft_comments_content') = 0,
+  'ALTER TABLE `comments` ADD FULLTEXT INDEX `ft_comments_content` (`content`) WITH PARSER ngram',
+  'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,131 @@
+-- 初始表结构，与此前 AutoMigrate 生成的结构一致，已有库上执行不会报错
+CREATE TABLE IF NOT EXISTS users (
+  id bigserial PRIMARY KEY,
+  created_at timestamptz,
+  updated_at timestamptz,
+  deleted_at timestamptz,
+  username text NOT NULL,
+  password text NOT NULL,
+  email text NOT NULL,
+  role varchar(20) NOT NULL DEFAULT 'author',
+  CONSTRAINT uni_users_username UNIQUE (username),
+  CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS posts (
+  id bigserial PRIMARY KEY,
+  created_at timestamptz,
+  updated_at timestamptz,
+  deleted_at timestamptz,
+  title text NOT NULL,
+  content text NOT NULL,
+  content_html text,
+  excerpt varchar(512),
+  reading_time bigint,
+  user_id bigint,
+  status varchar(20) NOT NULL DEFAULT 'published',
+  publish_at timestamptz,
+  published_at timestamptz,
+  CONSTRAINT fk_posts_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_posts_publish_at ON posts (publish_at);
+CREATE INDEX IF NOT EXISTS idx_posts_status ON posts (status);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts (user_id);
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS categories (
+  id bigserial PRIMARY KEY,
+  created_at timestamptz,
+  updated_at timestamptz,
+  deleted_at timestamptz,
+  name varchar(32) NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_name ON categories (name);
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories (deleted_at);
+
+CREATE TABLE IF NOT EXISTS post_categories (
+  post_id bigint,
+  category_id bigint,
+  PRIMARY KEY (post_id, category_id),
+  CONSTRAINT fk_post_categories_post FOREIGN KEY (post_id) REFERENCES posts(id),
+  CONSTRAINT fk_post_categories_category FOREIGN KEY (category_id) REFERENCES categories(id)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+  id bigserial PRIMARY KEY,
+  created_at timestamptz,
+  updated_at timestamptz,
+  deleted_at timestamptz,
+  name varchar(32) NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+CREATE INDEX IF NOT EXISTS idx_tags_deleted_at ON tags (deleted_at);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+  post_id bigint,
+  tag_id bigint,
+  PRIMARY KEY (post_id, tag_id),
+  CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id) REFERENCES posts(id),
+  CONSTRAINT fk_post_tags_tag FOREIGN KEY (tag_id) REFERENCES tags(id)
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+  id bigserial PRIMARY KEY,
+  created_at timestamptz,
+  updated_at timestamptz,
+  deleted_at timestamptz,
+  content text NOT NULL,
+  user_id bigint,
+  post_id bigint,
+  parent_id bigint,
+  root_id bigint,
+  hidden_at timestamptz,
+  hidden_by bigint,
+  CONSTRAINT fk_comments_post FOREIGN KEY (post_id) REFERENCES posts(id),
+  CONSTRAINT fk_comments_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_comments_root_id ON comments (root_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments (post_id);
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+  id bigserial PRIMARY KEY,
+  created_at timestamptz,
+  updated_at timestamptz,
+  deleted_at timestamptz,
+  user_id bigint NOT NULL,
+  token_hash varchar(64) NOT NULL,
+  family_id varchar(64) NOT NULL,
+  access_jti varchar(64),
+  expires_at timestamptz NOT NULL,
+  revoked_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_deleted_at ON refresh_tokens (deleted_at);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+  id bigserial PRIMARY KEY,
+  jti varchar(64) NOT NULL,
+  expires_at timestamptz NOT NULL,
+  created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_revoked_tokens_jti ON revoked_tokens (jti);
+
+CREATE TABLE IF NOT EXISTS post_revisions (
+  id bigserial PRIMARY KEY,
+  created_at timestamptz,
+  updated_at timestamptz,
+  deleted_at timestamptz,
+  post_id bigint NOT NULL,
+  version bigint NOT NULL,
+  title text,
+  content text,
+  editor_id bigint
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_version ON post_revisions (post_id, version);
+CREATE INDEX IF NOT EXISTS idx_post_revisions_deleted_at ON post_revisions (deleted_at);
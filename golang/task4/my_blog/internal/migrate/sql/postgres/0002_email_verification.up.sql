@@ -0,0 +1,15 @@
+-- 邮箱验证：已有账号视为已验证，避免升级后无法发文
+ALTER TABLE users ADD COLUMN email_verified_at timestamptz;
+UPDATE users SET email_verified_at = CURRENT_TIMESTAMP;
+
+CREATE TABLE action_tokens (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  purpose varchar(32) NOT NULL,
+  jti varchar(64) NOT NULL,
+  expires_at timestamptz NOT NULL,
+  used_at timestamptz,
+  created_at timestamptz
+);
+CREATE INDEX idx_action_tokens_user_id ON action_tokens (user_id);
+CREATE UNIQUE INDEX idx_action_tokens_jti ON action_tokens (jti);
@@ -0,0 +1,26 @@
+-- 账号安全：登录失败锁定、重置密码令牌、登录审计
+ALTER TABLE users ADD COLUMN failed_logins bigint NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until timestamptz;
+
+CREATE TABLE password_reset_tokens (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  token_hash varchar(64) NOT NULL,
+  expires_at timestamptz NOT NULL,
+  used_at timestamptz,
+  created_at timestamptz
+);
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+CREATE UNIQUE INDEX idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+
+CREATE TABLE login_events (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  ip varchar(64),
+  user_agent varchar(255),
+  success boolean NOT NULL,
+  reason varchar(32),
+  created_at timestamptz
+);
+CREATE INDEX idx_login_events_user_id ON login_events (user_id);
+CREATE INDEX idx_login_events_created_at ON login_events (created_at);
@@ -0,0 +1,13 @@
+-- 两步验证：TOTP 密钥与恢复码
+ALTER TABLE users ADD COLUMN totp_secret varchar(64);
+ALTER TABLE users ADD COLUMN totp_last_step bigint NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN mfa_enabled_at timestamptz;
+
+CREATE TABLE recovery_codes (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  code_hash varchar(64) NOT NULL,
+  used_at timestamptz,
+  created_at timestamptz
+);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
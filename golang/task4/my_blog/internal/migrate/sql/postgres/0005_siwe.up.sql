@@ -0,0 +1,13 @@
+-- 以太坊钱包登录：用户绑定的地址与一次性 nonce
+ALTER TABLE users ADD COLUMN eth_address varchar(42);
+CREATE UNIQUE INDEX idx_users_eth_address ON users (eth_address);
+
+CREATE TABLE siwe_nonces (
+  id bigserial PRIMARY KEY,
+  nonce varchar(64) NOT NULL,
+  expires_at timestamptz NOT NULL,
+  used_at timestamptz,
+  created_at timestamptz
+);
+CREATE UNIQUE INDEX idx_siwe_nonces_nonce ON siwe_nonces (nonce);
+CREATE INDEX idx_siwe_nonces_expires_at ON siwe_nonces (expires_at);
@@ -0,0 +1,25 @@
+-- 站内通知与通知设置
+CREATE TABLE notifications (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  actor_id bigint NOT NULL,
+  actor varchar(191),
+  type varchar(20) NOT NULL,
+  post_id bigint NOT NULL,
+  comment_id bigint NOT NULL,
+  excerpt varchar(255),
+  read_at timestamptz,
+  created_at timestamptz
+);
+CREATE INDEX idx_notifications_user_read ON notifications (user_id, read_at);
+CREATE INDEX idx_notifications_created_at ON notifications (created_at);
+
+CREATE TABLE notification_preferences (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  type varchar(20) NOT NULL,
+  in_app boolean NOT NULL,
+  email boolean NOT NULL,
+  updated_at timestamptz
+);
+CREATE UNIQUE INDEX idx_notification_preferences_user_type ON notification_preferences (user_id, type);
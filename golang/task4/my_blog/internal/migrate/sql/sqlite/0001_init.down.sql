@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS `post_revisions`;
+DROP TABLE IF EXISTS `revoked_tokens`;
+DROP TABLE IF EXISTS `refresh_tokens`;
+DROP TABLE IF EXISTS `comments`;
+DROP TABLE IF EXISTS `post_tags`;
+DROP TABLE IF EXISTS `tags`;
+DROP TABLE IF EXISTS `post_categories`;
+DROP TABLE IF EXISTS `categories`;
+DROP TABLE IF EXISTS `posts`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,131 @@
+-- 初始表结构，与此前 AutoMigrate 生成的结构一致，已有库上执行不会报错
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `username` text NOT NULL,
+  `password` text NOT NULL,
+  `email` text NOT NULL,
+  `role` text NOT NULL DEFAULT 'author',
+  CONSTRAINT `uni_users_username` UNIQUE (`username`),
+  CONSTRAINT `uni_users_email` UNIQUE (`email`)
+);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `posts` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `title` text NOT NULL,
+  `content` text NOT NULL,
+  `content_html` text,
+  `excerpt` text,
+  `reading_time` integer,
+  `user_id` integer,
+  `status` text NOT NULL DEFAULT 'published',
+  `publish_at` datetime,
+  `published_at` datetime,
+  CONSTRAINT `fk_posts_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_posts_publish_at` ON `posts`(`publish_at`);
+CREATE INDEX IF NOT EXISTS `idx_posts_status` ON `posts`(`status`);
+CREATE INDEX IF NOT EXISTS `idx_posts_user_id` ON `posts`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_posts_deleted_at` ON `posts`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `categories` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `name` text NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_categories_name` ON `categories`(`name`);
+CREATE INDEX IF NOT EXISTS `idx_categories_deleted_at` ON `categories`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `post_categories` (
+  `post_id` integer,
+  `category_id` integer,
+  PRIMARY KEY (`post_id`, `category_id`),
+  CONSTRAINT `fk_post_categories_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+  CONSTRAINT `fk_post_categories_category` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `tags` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `name` text NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_tags_name` ON `tags`(`name`);
+CREATE INDEX IF NOT EXISTS `idx_tags_deleted_at` ON `tags`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `post_tags` (
+  `post_id` integer,
+  `tag_id` integer,
+  PRIMARY KEY (`post_id`, `tag_id`),
+  CONSTRAINT `fk_post_tags_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+  CONSTRAINT `fk_post_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `comments` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `content` text NOT NULL,
+  `user_id` integer,
+  `post_id` integer,
+  `parent_id` integer,
+  `root_id` integer,
+  `hidden_at` datetime,
+  `hidden_by` integer,
+  CONSTRAINT `fk_comments_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+  CONSTRAINT `fk_comments_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_comments_root_id` ON `comments`(`root_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_parent_id` ON `comments`(`parent_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_post_id` ON `comments`(`post_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_deleted_at` ON `comments`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `user_id` integer NOT NULL,
+  `token_hash` text NOT NULL,
+  `family_id` text NOT NULL,
+  `access_jti` text,
+  `expires_at` datetime NOT NULL,
+  `revoked_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_family_id` ON `refresh_tokens`(`family_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_refresh_tokens_token_hash` ON `refresh_tokens`(`token_hash`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_user_id` ON `refresh_tokens`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_deleted_at` ON `refresh_tokens`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `revoked_tokens` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `jti` text NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `created_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_revoked_tokens_expires_at` ON `revoked_tokens`(`expires_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_revoked_tokens_jti` ON `revoked_tokens`(`jti`);
+
+CREATE TABLE IF NOT EXISTS `post_revisions` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `post_id` integer NOT NULL,
+  `version` integer NOT NULL,
+  `title` text,
+  `content` text,
+  `editor_id` integer
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_post_version` ON `post_revisions`(`post_id`, `version`);
+CREATE INDEX IF NOT EXISTS `idx_post_revisions_deleted_at` ON `post_revisions`(`deleted_at`);
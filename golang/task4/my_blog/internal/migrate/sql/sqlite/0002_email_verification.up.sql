@@ -0,0 +1,15 @@
+-- 邮箱验证：已有账号视为已验证，避免升级后无法发文
+ALTER TABLE `users` ADD COLUMN `email_verified_at` datetime;
+UPDATE `users` SET `email_verified_at` = CURRENT_TIMESTAMP;
+
+CREATE TABLE `action_tokens` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `purpose` text NOT NULL,
+  `jti` text NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime,
+  `created_at` datetime
+);
+CREATE INDEX `idx_action_tokens_user_id` ON `action_tokens`(`user_id`);
+CREATE UNIQUE INDEX `idx_action_tokens_jti` ON `action_tokens`(`jti`);
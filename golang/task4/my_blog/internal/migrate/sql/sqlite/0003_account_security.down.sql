@@ -0,0 +1,4 @@
+DROP TABLE `login_events`;
+DROP TABLE `password_reset_tokens`;
+ALTER TABLE `users` DROP COLUMN `locked_until`;
+ALTER TABLE `users` DROP COLUMN `failed_logins`;
@@ -0,0 +1,26 @@
+-- 账号安全：登录失败锁定、重置密码令牌、登录审计
+ALTER TABLE `users` ADD COLUMN `failed_logins` integer NOT NULL DEFAULT 0;
+ALTER TABLE `users` ADD COLUMN `locked_until` datetime;
+
+CREATE TABLE `password_reset_tokens` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `token_hash` text NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime,
+  `created_at` datetime
+);
+CREATE INDEX `idx_password_reset_tokens_user_id` ON `password_reset_tokens`(`user_id`);
+CREATE UNIQUE INDEX `idx_password_reset_tokens_token_hash` ON `password_reset_tokens`(`token_hash`);
+
+CREATE TABLE `login_events` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `ip` text,
+  `user_agent` text,
+  `success` numeric NOT NULL,
+  `reason` text,
+  `created_at` datetime
+);
+CREATE INDEX `idx_login_events_user_id` ON `login_events`(`user_id`);
+CREATE INDEX `idx_login_events_created_at` ON `login_events`(`created_at`);
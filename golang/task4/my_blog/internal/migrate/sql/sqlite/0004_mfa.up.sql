@@ -0,0 +1,13 @@
+-- 两步验证：TOTP 密钥与恢复码
+ALTER TABLE `users` ADD COLUMN `totp_secret` text;
+ALTER TABLE `users` ADD COLUMN `totp_last_step` integer NOT NULL DEFAULT 0;
+ALTER TABLE `users` ADD COLUMN `mfa_enabled_at` datetime;
+
+CREATE TABLE `recovery_codes` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `code_hash` text NOT NULL,
+  `used_at` datetime,
+  `created_at` datetime
+);
+CREATE INDEX `idx_recovery_codes_user_id` ON `recovery_codes`(`user_id`);
@@ -0,0 +1,13 @@
+-- 以太坊钱包登录：用户绑定的地址与一次性 nonce
+ALTER TABLE `users` ADD COLUMN `eth_address` text;
+CREATE UNIQUE INDEX `idx_users_eth_address` ON `users`(`eth_address`);
+
+CREATE TABLE `siwe_nonces` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `nonce` text NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime,
+  `created_at` datetime
+);
+CREATE UNIQUE INDEX `idx_siwe_nonces_nonce` ON `siwe_nonces`(`nonce`);
+CREATE INDEX `idx_siwe_nonces_expires_at` ON `siwe_nonces`(`expires_at`);
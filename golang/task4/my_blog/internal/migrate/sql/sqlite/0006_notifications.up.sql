@@ -0,0 +1,25 @@
+-- 站内通知与通知设置
+CREATE TABLE `notifications` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `actor_id` integer NOT NULL,
+  `actor` text,
+  `type` text NOT NULL,
+  `post_id` integer NOT NULL,
+  `comment_id` integer NOT NULL,
+  `excerpt` text,
+  `read_at` datetime,
+  `created_at` datetime
+);
+CREATE INDEX `idx_notifications_user_read` ON `notifications`(`user_id`, `read_at`);
+CREATE INDEX `idx_notifications_created_at` ON `notifications`(`created_at`);
+
+CREATE TABLE `notification_preferences` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `type` text NOT NULL,
+  `in_app` numeric NOT NULL,
+  `email` numeric NOT NULL,
+  `updated_at` datetime
+);
+CREATE UNIQUE INDEX `idx_notification_preferences_user_type` ON `notification_preferences`(`user_id`, `type`);
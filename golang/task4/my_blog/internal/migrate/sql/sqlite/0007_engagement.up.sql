@@ -0,0 +1,25 @@
+-- 点赞、收藏与文章互动计数
+ALTER TABLE `posts` ADD COLUMN `like_count` integer NOT NULL DEFAULT 0;
+ALTER TABLE `posts` ADD COLUMN `comment_count` integer NOT NULL DEFAULT 0;
+ALTER TABLE `posts` ADD COLUMN `view_count` integer NOT NULL DEFAULT 0;
+CREATE INDEX `idx_posts_like_count` ON `posts`(`like_count`);
+CREATE INDEX `idx_posts_comment_count` ON `posts`(`comment_count`);
+UPDATE `posts` SET `comment_count` = (SELECT COUNT(*) FROM `comments` WHERE `comments`.`post_id` = `posts`.`id` AND `comments`.`deleted_at` IS NULL);
+
+CREATE TABLE `post_likes` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `post_id` integer NOT NULL,
+  `created_at` datetime
+);
+CREATE UNIQUE INDEX `idx_post_likes_user_post` ON `post_likes`(`user_id`, `post_id`);
+CREATE INDEX `idx_post_likes_post_id` ON `post_likes`(`post_id`);
+
+CREATE TABLE `bookmarks` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `post_id` integer NOT NULL,
+  `created_at` datetime
+);
+CREATE UNIQUE INDEX `idx_bookmarks_user_post` ON `bookmarks`(`user_id`, `post_id`);
+CREATE INDEX `idx_bookmarks_post_id` ON `bookmarks`(`post_id`);
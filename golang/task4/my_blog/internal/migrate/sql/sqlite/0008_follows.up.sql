@@ -0,0 +1,15 @@
+-- 用户关注关系与关注计数
+ALTER TABLE `users` ADD COLUMN `follower_count` integer NOT NULL DEFAULT 0;
+ALTER TABLE `users` ADD COLUMN `following_count` integer NOT NULL DEFAULT 0;
+
+CREATE TABLE `follows` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `follower_id` integer NOT NULL,
+  `followee_id` integer NOT NULL,
+  `created_at` datetime
+);
+CREATE UNIQUE INDEX `idx_follows_pair` ON `follows`(`follower_id`, `followee_id`);
+CREATE INDEX `idx_follows_followee_id` ON `follows`(`followee_id`);
+
+-- 信息流按发布时间读取关注作者的文章
+CREATE INDEX `idx_posts_user_published` ON `posts`(`user_id`, `published_at`);
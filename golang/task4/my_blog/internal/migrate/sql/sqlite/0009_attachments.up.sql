@@ -0,0 +1,21 @@
+-- 文章图片附件
+CREATE TABLE `attachments` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `post_id` integer,
+  `filename` varchar(255),
+  `content_type` varchar(64) NOT NULL,
+  `size` integer NOT NULL,
+  `width` integer,
+  `height` integer,
+  `key` varchar(255) NOT NULL,
+  `thumbnail_key` varchar(255),
+  `url` varchar(1024) NOT NULL,
+  `thumbnail_url` varchar(1024),
+  `created_at` datetime,
+  `updated_at` datetime
+);
+CREATE INDEX `idx_attachments_user_id` ON `attachments`(`user_id`);
+CREATE INDEX `idx_attachments_post_id` ON `attachments`(`post_id`);
+CREATE UNIQUE INDEX `idx_attachments_key` ON `attachments`(`key`);
+CREATE INDEX `idx_attachments_updated_at` ON `attachments`(`updated_at`);
@@ -0,0 +1,2 @@
+-- 全文检索的 FULLTEXT 索引只用于 MySQL，其他驱动使用内存索引，此版本不做改动
+SELECT 1;
@@ -0,0 +1,22 @@
+-- 迁移系统上线前由 AutoMigrate 创建的库缺少 0001_init 中后来加入的列和索引。
+-- 执行 0001 之前逐条检查，只补齐缺少的列和索引；最初版本的文章没有状态，视为已发布
+ALTER TABLE `users` ADD COLUMN `role` text NOT NULL DEFAULT 'author';
+
+ALTER TABLE `posts` ADD COLUMN `content_html` text;
+ALTER TABLE `posts` ADD COLUMN `excerpt` text;
+ALTER TABLE `posts` ADD COLUMN `reading_time` integer;
+ALTER TABLE `posts` ADD COLUMN `status` text NOT NULL DEFAULT 'published';
+ALTER TABLE `posts` ADD COLUMN `publish_at` datetime;
+ALTER TABLE `posts` ADD COLUMN `published_at` datetime;
+CREATE INDEX IF NOT EXISTS `idx_posts_publish_at` ON `posts`(`publish_at`);
+CREATE INDEX IF NOT EXISTS `idx_posts_status` ON `posts`(`status`);
+CREATE INDEX IF NOT EXISTS `idx_posts_user_id` ON `posts`(`user_id`);
+UPDATE `posts` SET `published_at` = `created_at` WHERE `status` = 'published' AND `published_at` IS NULL;
+
+ALTER TABLE `comments` ADD COLUMN `parent_id` integer;
+ALTER TABLE `comments` ADD COLUMN `root_id` integer;
+ALTER TABLE `comments` ADD COLUMN `hidden_at` datetime;
+ALTER TABLE `comments` ADD COLUMN `hidden_by` integer;
+CREATE INDEX IF NOT EXISTS `idx_comments_root_id` ON `comments`(`root_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_parent_id` ON `comments`(`parent_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_post_id` ON `comments`(`post_id`);
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Attachment 用户上传的图片，文件本身在存储后端中，这里只保存元数据。
+// PostID 为空的附件尚未关联文章（刚上传或已从文章移除），超过保留期后由后台任务连同文件一起删除
+type Attachment struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	UserID       uint      `gorm:"not null;index" json:"user_id"`
+	PostID       *uint     `gorm:"index" json:"post_id"`
+	Filename     string    `gorm:"size:255" json:"filename"` // 上传时的原始文件名，仅用于展示
+	ContentType  string    `gorm:"size:64;not null" json:"content_type"`
+	Size         int64     `gorm:"not null" json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Key          string    `gorm:"size:255;not null;uniqueIndex" json:"-"` // 存储后端中的对象键
+	ThumbnailKey string    `gorm:"size:255" json:"-"`
+	URL          string    `gorm:"size:1024;not null" json:"url"`
+	ThumbnailURL string    `gorm:"size:1024" json:"thumbnail_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `gorm:"index" json:"-"` // 关联或解除关联时更新，未关联的附件按它判断是否过期
+}
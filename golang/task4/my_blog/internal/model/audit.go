@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// 登录失败原因
+const (
+	LoginFailBadPassword = "bad_password"
+	LoginFailLocked      = "locked"
+	LoginFailBadMFACode  = "bad_mfa_code"
+)
+
+// LoginEvent 登录审计记录，用户可查看自己账号的登录历史
+type LoginEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"-"`
+	IP        string    `gorm:"size:64" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	Success   bool      `gorm:"not null" json:"success"`
+	Reason    string    `gorm:"size:32" json:"reason,omitempty"` // 失败原因
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
@@ -1,12 +1,24 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Comment struct {
 	gorm.Model
-	Content string `gorm:"not null"`
-	UserID  uint
-	User    User
-	PostID  uint
-	Post    Post
+	Content  string `gorm:"not null"`
+	UserID   uint
+	User     Author
+	PostID   uint `gorm:"index"`
+	Post     Post
+	ParentID *uint      `gorm:"index"` // 回复的评论，顶层评论为 nil
+	RootID   *uint      `gorm:"index"` // 所属楼层的顶层评论，便于整楼加载
+	HiddenAt *time.Time // 被版主隐藏的时间，与软删除（DeletedAt）相互独立
+	HiddenBy *uint
+	Replies  []*Comment `gorm:"-"`
 }
+
+// Hidden 评论是否已被版主隐藏
+func (c *Comment) Hidden() bool { return c.HiddenAt != nil }
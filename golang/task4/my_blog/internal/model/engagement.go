@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// PostLike 用户对文章的点赞，(user_id, post_id) 唯一，重复点赞不会新增记录
+type PostLike struct {
+	ID        uint `gorm:"primarykey" json:"-"`
+	UserID    uint `gorm:"not null;uniqueIndex:idx_post_likes_user_post"`
+	PostID    uint `gorm:"not null;uniqueIndex:idx_post_likes_user_post;index"`
+	CreatedAt time.Time
+}
+
+// Bookmark 用户收藏的文章，(user_id, post_id) 唯一
+type Bookmark struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_bookmarks_user_post" json:"-"`
+	PostID    uint      `gorm:"not null;uniqueIndex:idx_bookmarks_user_post;index" json:"post_id"`
+	Post      *Post     `json:"post,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
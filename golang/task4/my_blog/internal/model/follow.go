@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Follow 关注关系：FollowerID 关注了 FolloweeID，同一对用户只有一条记录
+type Follow struct {
+	ID         uint  `gorm:"primarykey"`
+	FollowerID uint  `gorm:"not null;uniqueIndex:idx_follows_pair"`
+	FolloweeID uint  `gorm:"not null;uniqueIndex:idx_follows_pair;index"`
+	Follower   *User `gorm:"foreignKey:FollowerID"`
+	Followee   *User `gorm:"foreignKey:FolloweeID"`
+	CreatedAt  time.Time
+}
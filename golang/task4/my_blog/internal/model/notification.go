@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// 通知类型
+const (
+	NotifyComment = "comment" // 文章收到评论
+	NotifyReply   = "reply"   // 评论收到回复
+	NotifyMention = "mention" // 在评论中被 @
+)
+
+// NotificationTypes 全部通知类型
+var NotificationTypes = []string{NotifyComment, NotifyReply, NotifyMention}
+
+// ValidNotificationType 判断通知类型是否合法
+func ValidNotificationType(typ string) bool {
+	switch typ {
+	case NotifyComment, NotifyReply, NotifyMention:
+		return true
+	}
+	return false
+}
+
+// Notification 站内通知，由评论触发
+type Notification struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index:idx_notifications_user_read,priority:1;not null" json:"-"` // 接收者
+	ActorID   uint       `gorm:"not null" json:"actor_id"`                                       // 触发通知的用户
+	Actor     string     `gorm:"size:191" json:"actor"`                                          // 触发者用户名，便于直接展示
+	Type      string     `gorm:"size:20;not null" json:"type"`
+	PostID    uint       `gorm:"not null" json:"post_id"`
+	CommentID uint       `gorm:"not null" json:"comment_id"`
+	Excerpt   string     `gorm:"size:255" json:"excerpt"` // 评论内容摘要
+	ReadAt    *time.Time `gorm:"index:idx_notifications_user_read,priority:2" json:"read_at"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
+
+// NotificationPreference 用户对某类通知的接收设置，没有记录时使用 DefaultNotificationPreference
+type NotificationPreference struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	UserID    uint      `gorm:"uniqueIndex:idx_notification_preferences_user_type;not null" json:"-"`
+	Type      string    `gorm:"size:20;uniqueIndex:idx_notification_preferences_user_type;not null" json:"type"`
+	InApp     bool      `gorm:"not null" json:"in_app"` // 站内通知
+	Email     bool      `gorm:"not null" json:"email"`  // 邮件提醒
+	UpdatedAt time.Time `json:"-"`
+}
+
+// DefaultNotificationPreference 未设置时站内通知与邮件提醒均开启
+func DefaultNotificationPreference(userID uint, typ string) NotificationPreference {
+	return NotificationPreference{UserID: userID, Type: typ, InApp: true, Email: true}
+}
@@ -0,0 +1,10 @@
+package model
+
+// Owned 归属于某个用户的资源，供授权策略做所有者检查
+type Owned interface {
+	OwnerID() uint
+}
+
+func (p *Post) OwnerID() uint { return p.UserID }
+
+func (c *Comment) OwnerID() uint { return c.UserID }
@@ -1,11 +1,58 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 文章状态
+const (
+	PostStatusDraft     = "draft"     // 草稿，仅作者可见
+	PostStatusScheduled = "scheduled" // 定时发布，到 PublishAt 后由后台任务发布
+	PostStatusPublished = "published" // 已发布，公开可见
+	PostStatusArchived  = "archived"  // 已归档，不再公开
+)
+
+// ValidPostStatus 判断文章状态是否合法
+func ValidPostStatus(status string) bool {
+	switch status {
+	case PostStatusDraft, PostStatusScheduled, PostStatusPublished, PostStatusArchived:
+		return true
+	}
+	return false
+}
 
 type Post struct {
 	gorm.Model
-	Title   string `gorm:"not null"`
-	Content string `gorm:"not null"`
-	UserID  uint
-	User    User
+	Title       string `gorm:"not null"`
+	Content     string `gorm:"not null"` // Markdown 源文
+	ContentHTML string `json:"-"`        // 渲染并净化后的 HTML，写入时生成
+	Excerpt     string `gorm:"size:512"`
+	ReadingTime int    // 预计阅读分钟数
+	UserID      uint   `gorm:"index"`
+	User        Author
+	Status      string     `gorm:"size:20;not null;default:published;index"`
+	PublishAt   *time.Time `gorm:"index"` // 定时发布时间
+	PublishedAt *time.Time
+	Tags        []Tag      `gorm:"many2many:post_tags"`
+	Categories  []Category `gorm:"many2many:post_categories"`
+	Attachments []Attachment
+	// 互动计数是冗余字段：点赞、评论数随对应记录在同一事务中增减，阅读数由计数器批量写入
+	LikeCount    int64 `gorm:"not null;default:0;index"`
+	CommentCount int64 `gorm:"not null;default:0;index"`
+	ViewCount    int64 `gorm:"not null;default:0"`
+}
+
+// Published 文章是否公开可见
+func (p *Post) Published() bool { return p.Status == PostStatusPublished }
+
+// PostRevision 文章的历史版本，每次创建或编辑都会保存一份
+type PostRevision struct {
+	gorm.Model
+	PostID   uint `gorm:"uniqueIndex:idx_post_version;not null"`
+	Version  int  `gorm:"uniqueIndex:idx_post_version;not null"`
+	Title    string
+	Content  string
+	EditorID uint
 }
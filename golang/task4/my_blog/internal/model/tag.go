@@ -0,0 +1,15 @@
+package model
+
+import "gorm.io/gorm"
+
+// Tag 文章标签，名称统一小写，作者发文时可自动创建
+type Tag struct {
+	gorm.Model
+	Name string `gorm:"size:32;uniqueIndex;not null"`
+}
+
+// Category 文章分类，由管理员或版主维护
+type Category struct {
+	gorm.Model
+	Name string `gorm:"size:32;uniqueIndex;not null"`
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken 刷新令牌，每次刷新都会轮换，同一次登录派生出的令牌共享 FamilyID
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"size:64;uniqueIndex;not null"`
+	FamilyID  string    `gorm:"size:64;index;not null"`
+	AccessJTI string    `gorm:"size:64"` // 与之一同签发的 access token，整族吊销时一并吊销
+	ExpiresAt time.Time `gorm:"not null"`
+	RevokedAt *time.Time
+}
+
+// RevokedToken 已吊销的 access token（按 jti 记录），过期后由后台任务清理
+type RevokedToken struct {
+	ID        uint      `gorm:"primarykey"`
+	JTI       string    `gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
+
+// 一次性操作令牌的用途
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposeMFALogin          = "mfa_pending" // 密码校验通过、等待两步验证的登录
+)
+
+// ActionToken 已签发的一次性操作令牌（如邮箱验证），按 jti 记录以保证只能使用一次
+type ActionToken struct {
+	ID        uint      `gorm:"primarykey"`
+	UserID    uint      `gorm:"index;not null"`
+	Purpose   string    `gorm:"size:32;not null"`
+	JTI       string    `gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// PasswordResetToken 重置密码令牌，只保存哈希，使用后或过期即失效
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primarykey"`
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// RecoveryCode 两步验证恢复码，只保存哈希，每个只能使用一次
+type RecoveryCode struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index;not null"`
+	CodeHash  string `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// SIWENonce 钱包登录的 nonce，签名消息必须包含服务端签发且未使用的 nonce，防止重放
+type SIWENonce struct {
+	ID        uint      `gorm:"primarykey"`
+	Nonce     string    `gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -1,10 +1,68 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"strings"
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// 用户角色
+const (
+	RoleAdmin     = "admin"     // 管理员：可管理用户
+	RoleModerator = "moderator" // 版主：可编辑、删除任何文章和评论
+	RoleAuthor    = "author"    // 作者：可发表文章
+	RoleReader    = "reader"    // 读者：只能评论
+)
+
+// ValidRole 判断角色是否合法
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleModerator, RoleAuthor, RoleReader:
+		return true
+	}
+	return false
+}
+
+// WalletEmailDomain 钱包登录创建的账号使用的占位邮箱域名（RFC 2606 保留域名，不会真正投递）
+const WalletEmailDomain = "wallet.invalid"
+
+// User 用户。密码、邮箱和账号安全相关字段不参与 JSON 序列化，对外展示时使用 Author 或专门的视图
 type User struct {
 	gorm.Model
-	Username string `gorm:"unique;not null"`
-	Password string `gorm:"not null"`
-	Email    string `gorm:"unique;not null"`
+	Username        string     `gorm:"unique;not null"`
+	Password        string     `gorm:"not null" json:"-"`
+	Email           string     `gorm:"unique;not null" json:"-"`
+	Role            string     `gorm:"size:20;not null;default:author"`
+	EmailVerifiedAt *time.Time `json:"-"`                            // 邮箱验证时间，未验证的账号不能发文和评论
+	FailedLogins    int        `gorm:"not null;default:0" json:"-"`  // 连续登录失败次数，登录成功或重置密码后清零
+	LockedUntil     *time.Time `json:"-"`                            // 账号锁定截止时间
+	TOTPSecret      string     `gorm:"size:64" json:"-"`             // TOTP 密钥，启用前为待确认的密钥
+	TOTPLastStep    int64      `gorm:"not null;default:0" json:"-"`  // 最近一次使用的 TOTP 时间步，防止验证码重放
+	MFAEnabledAt    *time.Time `json:"-"`                            // 启用两步验证的时间，为空表示未启用
+	EthAddress      *string    `gorm:"size:42;uniqueIndex" json:"-"` // 绑定的以太坊地址（EIP-55 格式），用于钱包登录
+	FollowerCount   int64      `gorm:"not null;default:0"`           // 粉丝数，随关注关系在同一事务中增减
+	FollowingCount  int64      `gorm:"not null;default:0"`           // 关注数
+}
+
+// Author 文章和评论中展示的作者，只映射 users 表的公开字段，序列化时不会带出密码等敏感信息
+type Author struct {
+	ID        uint
+	Username  string
+	Role      string
+	DeletedAt gorm.DeletedAt `json:"-"` // 已删除的作者与 User 一样不会被加载
 }
+
+func (Author) TableName() string { return "users" }
+
+// EmailVerified 邮箱是否已验证
+func (u *User) EmailVerified() bool { return u.EmailVerifiedAt != nil }
+
+// MFAEnabled 是否已启用两步验证
+func (u *User) MFAEnabled() bool { return u.MFAEnabledAt != nil }
+
+// WalletOnly 是否为钱包登录创建、尚未设置真实邮箱的账号
+func (u *User) WalletOnly() bool { return strings.HasSuffix(u.Email, "@"+WalletEmailDomain) }
+
+// Locked 账号在 now 时是否处于锁定状态
+func (u *User) Locked(now time.Time) bool { return u.LockedUntil != nil && now.Before(*u.LockedUntil) }
@@ -0,0 +1,30 @@
+// Package publisher 定时发布：把到期的 scheduled 文章切换为 published
+package publisher
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"my_blog/internal/service"
+)
+
+// Run 每隔 interval 发布一次到期的定时文章，直到 ctx 取消
+func Run(ctx context.Context, posts *service.Posts, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := posts.PublishDue(ctx, time.Now()); err != nil {
+			log.Printf("publisher: %v", err)
+		} else if n > 0 {
+			log.Printf("publisher: published %d scheduled posts", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
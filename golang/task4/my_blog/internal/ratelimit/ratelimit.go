@@ -0,0 +1,105 @@
+// Package ratelimit 令牌桶限流。Store 保存各个键的令牌桶，
+// 单实例使用 Memory，多实例部署可实现基于 Redis 等共享存储的 Store
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Rule 令牌桶参数：每秒补充 Rate 个令牌，最多积攒 Burst 个
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+// Result 一次取令牌的结果
+type Result struct {
+	Allowed    bool
+	Remaining  int           // 剩余令牌数（向下取整）
+	RetryAfter time.Duration // 被拒绝时距下一个令牌可用的时间
+}
+
+// Store 令牌桶存储
+type Store interface {
+	// Take 从 key 对应的桶中取一个令牌
+	Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // 按该桶自己的规则回满的时间，之后与新建的桶等价
+}
+
+// Memory 进程内令牌桶，空闲到已回满的桶会被定期清理
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+const sweepInterval = time.Minute
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket)}
+}
+
+func (m *Memory) Take(_ context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+		m.lastSweep = now
+	}
+
+	burst := float64(rule.Burst)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		m.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * rule.Rate
+		b.last = now
+	}
+	// 规则热更新后 Burst 变小时同样截断
+	b.tokens = math.Min(b.tokens, burst)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.full = now.Add(refill(burst-b.tokens, rule.Rate))
+		return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+	}
+	b.full = now.Add(refill(burst-b.tokens, rule.Rate))
+	wait := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+	return Result{Allowed: false, RetryAfter: wait}, nil
+}
+
+// refill 以 rate 补充 missing 个令牌需要的时间，rate 不大于 0 时视为立即回满
+func refill(missing, rate float64) time.Duration {
+	if rate <= 0 || missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / rate * float64(time.Second))
+}
+
+// sweep 删除已经回满的桶。回满时间按各桶最近一次使用的规则计算，
+// 不同分组的规则互不影响
+func (m *Memory) sweep(now time.Time) {
+	for k, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, k)
+		}
+	}
+}
+
+// Len 当前的桶数，主要供测试使用
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.buckets)
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryTake(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	rule := Rule{Rate: 2, Burst: 3}
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < 3; i++ {
+		res, _ := m.Take(ctx, "ip:1", rule, now)
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d: unexpected result %+v", i, res)
+		}
+	}
+	res, _ := m.Take(ctx, "ip:1", rule, now)
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("expected rejection with 500ms retry, got %+v", res)
+	}
+
+	// 其他键互不影响
+	if res, _ := m.Take(ctx, "ip:2", rule, now); !res.Allowed {
+		t.Error("expected other key to be allowed")
+	}
+
+	// 半秒后补充一个令牌
+	now = now.Add(500 * time.Millisecond)
+	if res, _ := m.Take(ctx, "ip:1", rule, now); !res.Allowed {
+		t.Error("expected token after refill")
+	}
+	if res, _ := m.Take(ctx, "ip:1", rule, now); res.Allowed {
+		t.Error("expected bucket to be empty again")
+	}
+
+	// 长时间空闲后最多回满到 Burst
+	now = now.Add(time.Hour)
+	if res, _ := m.Take(ctx, "ip:1", rule, now); res.Remaining != 2 {
+		t.Errorf("expected refill capped at burst, got %+v", res)
+	}
+	// 已回满的空闲桶被清理
+	if n := m.Len(); n != 1 {
+		t.Errorf("expected idle buckets to be swept, got %d", n)
+	}
+}
+
+func TestMemorySweepPerRule(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	public := Rule{Rate: 10, Burst: 10}    // 1 秒回满
+	auth := Rule{Rate: 1.0 / 60, Burst: 5} // 5 分钟回满
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < 5; i++ {
+		m.Take(ctx, "auth:ip:1", auth, now)
+	}
+	// 公共分组的请求触发清理时，未回满的 auth 桶必须保留
+	now = now.Add(2 * time.Minute)
+	m.Take(ctx, "public:ip:1", public, now)
+	if n := m.Len(); n != 2 {
+		t.Fatalf("half-drained auth bucket should survive a sweep, got %d buckets", n)
+	}
+	if res, _ := m.Take(ctx, "auth:ip:1", auth, now); !res.Allowed || res.Remaining != 1 {
+		t.Errorf("auth bucket should keep its state, got %+v", res)
+	}
+
+	// 两个桶都回满后才被清理
+	now = now.Add(10 * time.Minute)
+	m.Take(ctx, "public:ip:2", public, now)
+	if n := m.Len(); n != 1 {
+		t.Errorf("refilled buckets should be swept, got %d buckets", n)
+	}
+}
@@ -0,0 +1,338 @@
+// Package realtime 进程内的发布/订阅中心：业务层按主题发布事件，SSE 与 WebSocket 连接订阅主题后推送给客户端。
+// 每个主题保留最近的若干事件，断线重连的客户端可凭 Last-Event-ID 补齐错过的事件
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 订阅被关闭的原因
+var (
+	ErrSlowConsumer = errors.New("realtime: subscriber too slow")
+	ErrHubClosed    = errors.New("realtime: hub closed")
+)
+
+// Event 推送给客户端的事件，ID 在整个进程内单调递增
+type Event struct {
+	ID    uint64          `json:"id"`
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+	Time  time.Time       `json:"time"`
+}
+
+// Options Hub 的容量配置
+type Options struct {
+	ReplayBuffer int           // 每个主题保留的最近事件数
+	ReplayTTL    time.Duration // 无订阅者的主题在最后一条事件后保留多久
+	ClientBuffer int           // 单个订阅者允许积压的事件数，超出后断开
+}
+
+// Hub 按主题分发事件
+type Hub struct {
+	opts Options
+
+	mu        sync.Mutex
+	base      uint64 // 本次启动的第一个事件 ID，更早的 ID 来自上一次运行
+	seq       uint64
+	topics    map[string]*topic
+	subs      map[*Subscription]struct{}
+	lastSweep time.Time
+	closed    bool
+}
+
+type topic struct {
+	ring    []Event // 最近的事件，按 ID 升序
+	evicted uint64  // 被挤出 ring 的最后一条事件 ID
+	last    time.Time
+	subs    map[*Subscription]struct{}
+}
+
+// NewHub 创建 Hub。事件 ID 以启动时的毫秒时间戳 ×1000 起步，
+// 重启后的 ID 总是大于上一次运行的 ID，可据此识别来自上一次运行的 Last-Event-ID
+func NewHub(opts Options) *Hub {
+	if opts.ReplayBuffer < 0 {
+		opts.ReplayBuffer = 0
+	}
+	if opts.ClientBuffer <= 0 {
+		opts.ClientBuffer = 1
+	}
+	now := time.Now()
+	base := uint64(now.UnixMilli()) * 1000
+	return &Hub{
+		opts:      opts,
+		base:      base,
+		seq:       base,
+		topics:    make(map[string]*topic),
+		subs:      make(map[*Subscription]struct{}),
+		lastSweep: now,
+	}
+}
+
+// Publish 向主题发布事件，data 序列化为 JSON；没有订阅者时只进入补发缓冲
+func (h *Hub) Publish(topicName, typ string, data any) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("realtime: marshal %s %s: %v", topicName, typ, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	now := time.Now()
+	h.seq++
+	ev := Event{ID: h.seq, Topic: topicName, Type: typ, Data: raw, Time: now}
+
+	t := h.topic(topicName)
+	t.last = now
+	if h.opts.ReplayBuffer > 0 {
+		if len(t.ring) == h.opts.ReplayBuffer {
+			t.evicted = t.ring[0].ID
+			copy(t.ring, t.ring[1:])
+			t.ring[len(t.ring)-1] = ev
+		} else {
+			t.ring = append(t.ring, ev)
+		}
+	} else {
+		t.evicted = ev.ID
+	}
+
+	for sub := range t.subs {
+		if !sub.push(ev, h.opts.ClientBuffer) {
+			h.detach(sub, ErrSlowConsumer)
+		}
+	}
+	h.sweep(now)
+}
+
+// Subscribe 创建一个尚未订阅任何主题的订阅，用完必须 Close
+func (h *Hub) Subscribe() *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		topics: make(map[string]struct{}),
+		ready:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		sub.finish(ErrHubClosed)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close 关闭 Hub 及全部订阅，此后发布的事件被丢弃。用于停机时让长连接尽快退出
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for sub := range h.subs {
+		h.detach(sub, ErrHubClosed)
+	}
+}
+
+// topic 返回主题，不存在时创建；调用方持有 h.mu
+func (h *Hub) topic(name string) *topic {
+	t := h.topics[name]
+	if t == nil {
+		t = &topic{subs: make(map[*Subscription]struct{})}
+		h.topics[name] = t
+	}
+	return t
+}
+
+// detach 将订阅从所有主题中移除并关闭；调用方持有 h.mu
+func (h *Hub) detach(sub *Subscription, reason error) {
+	for name := range sub.topics {
+		if t := h.topics[name]; t != nil {
+			delete(t.subs, sub)
+		}
+	}
+	delete(h.subs, sub)
+	sub.finish(reason)
+}
+
+// sweep 清理长时间没有事件且没有订阅者的主题，避免补发缓冲随文章数无限增长；调用方持有 h.mu
+func (h *Hub) sweep(now time.Time) {
+	if h.opts.ReplayTTL <= 0 || now.Sub(h.lastSweep) < h.opts.ReplayTTL {
+		return
+	}
+	h.lastSweep = now
+	for name, t := range h.topics {
+		if len(t.subs) == 0 && now.Sub(t.last) >= h.opts.ReplayTTL {
+			delete(h.topics, name)
+		}
+	}
+}
+
+// missed 判断 lastID 之后是否有事件已无法补发；调用方持有 h.mu
+func (h *Hub) missed(t *topic, lastID uint64) bool {
+	if lastID < h.base || lastID > h.seq {
+		// 来自上一次运行，或客户端给出了不存在的 ID
+		return true
+	}
+	return t != nil && lastID < t.evicted
+}
+
+// Subscription 一个客户端连接的订阅，事件先进入队列，由连接所在的 goroutine 取出推送
+type Subscription struct {
+	hub    *Hub
+	topics map[string]struct{} // 由 hub.mu 保护
+
+	mu     sync.Mutex
+	queue  []Event
+	ready  chan struct{}
+	done   chan struct{}
+	err    error
+	closed bool
+}
+
+// Add 订阅主题。lastID 非 0 时把各主题中 ID 大于 lastID 的缓冲事件按顺序排入队列；
+// 返回 missed 表示有事件已超出缓冲无法补发（此时该主题不再补发），客户端应重新拉取完整数据
+func (s *Subscription) Add(topics []string, lastID uint64) (missed bool) {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s.isClosed() {
+		return false
+	}
+
+	var replay []Event
+	for _, name := range topics {
+		t := h.topics[name]
+		if lastID != 0 {
+			if h.missed(t, lastID) {
+				missed = true
+			} else if t != nil {
+				i := sort.Search(len(t.ring), func(i int) bool { return t.ring[i].ID > lastID })
+				replay = append(replay, t.ring[i:]...)
+			}
+		}
+		if _, ok := s.topics[name]; ok {
+			continue
+		}
+		if t == nil {
+			t = h.topic(name)
+			t.last = time.Now()
+		}
+		t.subs[s] = struct{}{}
+		s.topics[name] = struct{}{}
+	}
+
+	if len(replay) > 0 {
+		sort.Slice(replay, func(i, j int) bool { return replay[i].ID < replay[j].ID })
+		s.mu.Lock()
+		s.queue = append(s.queue, replay...)
+		s.mu.Unlock()
+		s.notify()
+	}
+	return missed
+}
+
+// Remove 取消订阅主题
+func (s *Subscription) Remove(topics []string) {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, name := range topics {
+		if t := h.topics[name]; t != nil {
+			delete(t.subs, s)
+		}
+		delete(s.topics, name)
+	}
+}
+
+// Topics 当前订阅的主题，按名称排序
+func (s *Subscription) Topics() []string {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	names := make([]string, 0, len(s.topics))
+	for name := range s.topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Ready 队列中有新事件时可读
+func (s *Subscription) Ready() <-chan struct{} { return s.ready }
+
+// Done 订阅被关闭时关闭，原因见 Err
+func (s *Subscription) Done() <-chan struct{} { return s.done }
+
+// Err 订阅被关闭的原因，主动 Close 时为 nil
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Drain 取出队列中的全部事件
+func (s *Subscription) Drain() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.queue
+	s.queue = nil
+	return events
+}
+
+// Close 取消全部订阅
+func (s *Subscription) Close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !s.isClosed() {
+		h.detach(s, nil)
+	}
+}
+
+// push 事件入队，积压超过 limit 时返回 false
+func (s *Subscription) push(ev Event, limit int) bool {
+	s.mu.Lock()
+	if len(s.queue) >= limit {
+		s.mu.Unlock()
+		return false
+	}
+	s.queue = append(s.queue, ev)
+	s.mu.Unlock()
+	s.notify()
+	return true
+}
+
+func (s *Subscription) notify() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Subscription) finish(reason error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed, s.err, s.queue = true, reason, nil
+	close(s.done)
+}
+
+func (s *Subscription) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
@@ -0,0 +1,171 @@
+package realtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func ids(events []Event) []uint64 {
+	out := make([]uint64, len(events))
+	for i, ev := range events {
+		out[i] = ev.ID
+	}
+	return out
+}
+
+func TestHubPublishAndReplay(t *testing.T) {
+	h := NewHub(Options{ReplayBuffer: 3, ClientBuffer: 10})
+	sub := h.Subscribe()
+	defer sub.Close()
+	if missed := sub.Add([]string{PostTopic(1)}, 0); missed {
+		t.Fatal("fresh subscription should not report missed events")
+	}
+
+	h.Publish(PostTopic(1), CommentCreated, map[string]int{"id": 1})
+	h.Publish(PostTopic(2), CommentCreated, map[string]int{"id": 2}) // 未订阅
+	h.Publish(PostTopic(1), CommentUpdated, map[string]int{"id": 1})
+
+	select {
+	case <-sub.Ready():
+	default:
+		t.Fatal("expected ready signal")
+	}
+	events := sub.Drain()
+	if len(events) != 2 || events[0].Type != CommentCreated || events[1].Type != CommentUpdated {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if string(events[0].Data) != `{"id":1}` || events[0].ID >= events[1].ID {
+		t.Fatalf("unexpected event payload or order %+v", events)
+	}
+	lastID := events[0].ID
+
+	// 断线重连：补发 lastID 之后同一主题的事件
+	re := h.Subscribe()
+	defer re.Close()
+	if missed := re.Add([]string{PostTopic(1), PostTopic(2)}, lastID); missed {
+		t.Fatal("events still buffered, nothing should be missed")
+	}
+	got := re.Drain()
+	if len(got) != 2 || got[0].Topic != PostTopic(2) || got[1].ID != events[1].ID {
+		t.Fatalf("unexpected replay %+v", got)
+	}
+
+	// 超出缓冲后无法补发
+	for i := 0; i < 4; i++ {
+		h.Publish(PostTopic(1), CommentCreated, i)
+	}
+	late := h.Subscribe()
+	defer late.Close()
+	if missed := late.Add([]string{PostTopic(1)}, lastID); !missed {
+		t.Error("expected missed events after buffer overflow")
+	}
+	if got := late.Drain(); len(got) != 0 {
+		t.Errorf("client must refetch after a gap, got replay %v", ids(got))
+	}
+
+	// 上一次运行或不存在的 ID
+	for _, id := range []uint64{1, h.seq + 1} {
+		s := h.Subscribe()
+		if missed := s.Add([]string{PostTopic(1)}, id); !missed {
+			t.Errorf("id %d: expected missed", id)
+		}
+		s.Close()
+	}
+}
+
+func TestHubSlowConsumer(t *testing.T) {
+	h := NewHub(Options{ReplayBuffer: 10, ClientBuffer: 2})
+	sub := h.Subscribe()
+	sub.Add([]string{TopicPosts}, 0)
+
+	for i := 0; i < 3; i++ {
+		h.Publish(TopicPosts, PostPublished, i)
+	}
+	select {
+	case <-sub.Done():
+	default:
+		t.Fatal("expected slow subscriber to be closed")
+	}
+	if !errors.Is(sub.Err(), ErrSlowConsumer) {
+		t.Errorf("unexpected reason %v", sub.Err())
+	}
+	if len(h.topics[TopicPosts].subs) != 0 {
+		t.Error("closed subscriber should be detached from topic")
+	}
+}
+
+func TestHubRemoveAndClose(t *testing.T) {
+	h := NewHub(Options{ReplayBuffer: 10, ClientBuffer: 10})
+	sub := h.Subscribe()
+	sub.Add([]string{TopicPosts, UserTopic(7)}, 0)
+	sub.Remove([]string{TopicPosts})
+	if got := sub.Topics(); len(got) != 1 || got[0] != "user:7" {
+		t.Fatalf("unexpected topics %v", got)
+	}
+	h.Publish(TopicPosts, PostPublished, 1)
+	if got := sub.Drain(); len(got) != 0 {
+		t.Fatalf("unsubscribed topic delivered %v", ids(got))
+	}
+
+	h.Close()
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("hub close should close subscribers")
+	}
+	if !errors.Is(sub.Err(), ErrHubClosed) {
+		t.Errorf("unexpected reason %v", sub.Err())
+	}
+	if s := h.Subscribe(); s.Err() != ErrHubClosed {
+		t.Error("subscribe after close should return a closed subscription")
+	}
+}
+
+func TestHubSweep(t *testing.T) {
+	h := NewHub(Options{ReplayBuffer: 10, ReplayTTL: time.Minute, ClientBuffer: 10})
+	h.Publish(PostTopic(1), PostUpdated, 1)
+	sub := h.Subscribe()
+	defer sub.Close()
+	sub.Add([]string{PostTopic(2)}, 0)
+
+	// 模拟时间流逝
+	h.mu.Lock()
+	for _, tp := range h.topics {
+		tp.last = tp.last.Add(-2 * time.Minute)
+	}
+	h.lastSweep = h.lastSweep.Add(-2 * time.Minute)
+	h.mu.Unlock()
+
+	h.Publish(PostTopic(3), PostUpdated, 1)
+	if _, ok := h.topics[PostTopic(1)]; ok {
+		t.Error("idle topic without subscribers should be swept")
+	}
+	if _, ok := h.topics[PostTopic(2)]; !ok {
+		t.Error("topic with subscribers must be kept")
+	}
+}
+
+func TestParseTopic(t *testing.T) {
+	cases := []struct {
+		in   string
+		kind string
+		id   uint
+		ok   bool
+	}{
+		{"posts", KindPosts, 0, true},
+		{"post:12", KindPost, 12, true},
+		{"user:3", KindUser, 3, true},
+		{"post:0", "", 0, false},
+		{"post:012", "", 0, false},
+		{"post:x", "", 0, false},
+		{"comment:1", "", 0, false},
+		{"user", "", 0, false},
+	}
+	for _, c := range cases {
+		kind, id, ok := ParseTopic(c.in)
+		if kind != c.kind || id != c.id || ok != c.ok {
+			t.Errorf("ParseTopic(%q) = %q, %d, %v", c.in, kind, id, ok)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package realtime
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TopicPosts 全站文章的发布、更新与删除
+const TopicPosts = "posts"
+
+// 主题种类
+const (
+	KindPosts = "posts"
+	KindPost  = "post" // post:<id>，单篇文章及其评论
+	KindUser  = "user" // user:<id>，只推送给用户本人
+)
+
+// 事件类型
+const (
+	PostPublished   = "post.published"
+	PostUpdated     = "post.updated"
+	PostUnpublished = "post.unpublished"
+	PostDeleted     = "post.deleted"
+
+	CommentCreated  = "comment.created"
+	CommentUpdated  = "comment.updated"
+	CommentDeleted  = "comment.deleted"
+	CommentHidden   = "comment.hidden"
+	CommentUnhidden = "comment.unhidden"
+
+	NotificationCreated = "notification.created" // user:<id>
+	NotificationRead    = "notification.read"    // user:<id>，其他标签页据此同步未读数
+
+	// Reset 客户端错过的事件已无法补发，应重新拉取完整数据
+	Reset = "reset"
+)
+
+// PostTopic 单篇文章的主题
+func PostTopic(id uint) string { return KindPost + ":" + strconv.FormatUint(uint64(id), 10) }
+
+// UserTopic 用户私有主题
+func UserTopic(id uint) string { return KindUser + ":" + strconv.FormatUint(uint64(id), 10) }
+
+// ParseTopic 解析主题名，返回种类与 ID（posts 的 ID 为 0）
+func ParseTopic(name string) (kind string, id uint, ok bool) {
+	if name == TopicPosts {
+		return KindPosts, 0, true
+	}
+	kind, rest, found := strings.Cut(name, ":")
+	if !found || (kind != KindPost && kind != KindUser) {
+		return "", 0, false
+	}
+	n, err := strconv.ParseUint(rest, 10, 32)
+	if err != nil || n == 0 || strconv.FormatUint(n, 10) != rest {
+		return "", 0, false
+	}
+	return kind, uint(n), true
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"my_blog/internal/model"
+)
+
+// Attachments 上传的附件
+type Attachments struct {
+	db *gorm.DB
+}
+
+func (r *Attachments) Create(ctx context.Context, a *model.Attachment) error {
+	return r.db.WithContext(ctx).Create(a).Error
+}
+
+// Attach 将 userID 上传的附件关联到文章，已关联其他文章的附件不会被改动；返回实际关联的数量
+func (r *Attachments) Attach(ctx context.Context, postID, userID uint, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res := r.db.WithContext(ctx).Model(&model.Attachment{}).
+		Where("id IN ? AND user_id = ? AND (post_id IS NULL OR post_id = ?)", ids, userID, postID).
+		Updates(map[string]interface{}{"post_id": postID, "updated_at": time.Now()})
+	return res.RowsAffected, res.Error
+}
+
+// Detach 解除文章与附件的关联，keep 中的附件保留；被解除的附件成为待清理的孤儿
+func (r *Attachments) Detach(ctx context.Context, postID uint, keep []uint) error {
+	query := r.db.WithContext(ctx).Model(&model.Attachment{}).Where("post_id = ?", postID)
+	if len(keep) > 0 {
+		query = query.Where("id NOT IN ?", keep)
+	}
+	return query.Updates(map[string]interface{}{"post_id": nil, "updated_at": time.Now()}).Error
+}
+
+// Orphans 在 before 之前就已未关联文章的附件，最多 limit 条
+func (r *Attachments) Orphans(ctx context.Context, before time.Time, limit int) ([]model.Attachment, error) {
+	var list []model.Attachment
+	err := r.db.WithContext(ctx).
+		Where("post_id IS NULL AND updated_at < ?", before).
+		Order("id").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// Claim 删除仍在 before 之前就未关联文章的附件记录，返回是否删除。
+// 条件删除保证读取孤儿之后被重新关联的附件不会被误删，只有删除成功的调用方可以删除其文件
+func (r *Attachments) Claim(ctx context.Context, id uint, before time.Time) (bool, error) {
+	return affected(r.db.WithContext(ctx).
+		Where("id = ? AND post_id IS NULL AND updated_at < ?", id, before).
+		Delete(&model.Attachment{}))
+}
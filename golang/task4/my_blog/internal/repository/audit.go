@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"my_blog/internal/model"
+)
+
+// Audit 登录审计记录
+type Audit struct {
+	db *gorm.DB
+}
+
+func (r *Audit) CreateLoginEvent(ctx context.Context, e *model.LoginEvent) error {
+	return r.db.WithContext(ctx).Create(e).Error
+}
+
+// LoginEvents 用户的登录记录，按时间倒序，after 非空时从该位置之后开始
+func (r *Audit) LoginEvents(ctx context.Context, userID uint, after *Cursor, limit int) ([]model.LoginEvent, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if after != nil {
+		query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))", after.At, after.At, after.ID)
+	}
+	var events []model.LoginEvent
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
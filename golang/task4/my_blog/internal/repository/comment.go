@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"my_blog/internal/model"
+)
+
+// Comments 评论
+type Comments struct {
+	db *gorm.DB
+}
+
+func (r *Comments) Find(ctx context.Context, id uint) (*model.Comment, error) {
+	return first[model.Comment](r.db.WithContext(ctx), id)
+}
+
+func (r *Comments) Create(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+func (r *Comments) SetContent(ctx context.Context, comment *model.Comment, content string) error {
+	return r.db.WithContext(ctx).Model(comment).Update("content", content).Error
+}
+
+// SaveHidden 保存评论的隐藏状态
+func (r *Comments) SaveHidden(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Model(comment).Select("hidden_at", "hidden_by").Updates(comment).Error
+}
+
+// Visible 文章下未隐藏的全部评论
+func (r *Comments) Visible(ctx context.Context, postID uint) ([]model.Comment, error) {
+	var list []model.Comment
+	err := r.db.WithContext(ctx).Where("post_id = ? AND hidden_at IS NULL", postID).Find(&list).Error
+	return list, err
+}
+
+// DeleteTree 删除评论及其全部回复，返回被删除的评论 ID
+func (r *Comments) DeleteTree(ctx context.Context, id uint) ([]uint, error) {
+	var deleted []uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := []uint{id}
+		for level := ids; len(level) > 0; {
+			var children []uint
+			if err := tx.Model(&model.Comment{}).Where("parent_id IN ?", level).Pluck("id", &children).Error; err != nil {
+				return err
+			}
+			ids = append(ids, children...)
+			level = children
+		}
+		if err := tx.Delete(&model.Comment{}, ids).Error; err != nil {
+			return err
+		}
+		deleted = ids
+		return nil
+	})
+	return deleted, err
+}
+
+// Roots 文章的顶层评论，按时间正序，after 非空时从该位置之后开始
+func (r *Comments) Roots(ctx context.Context, postID uint, after *Cursor, limit int) ([]*model.Comment, error) {
+	query := r.db.WithContext(ctx).
+		Preload("User"). // 加载评论作者
+		Where("post_id = ? AND parent_id IS NULL", postID)
+	if after != nil {
+		query = query.Where("(created_at > ? OR (created_at = ? AND id > ?))", after.At, after.At, after.ID)
+	}
+	var roots []*model.Comment
+	err := query.Order("created_at ASC, id ASC").Limit(limit).Find(&roots).Error
+	return roots, err
+}
+
+// Replies 若干楼层下的全部回复，按时间正序
+func (r *Comments) Replies(ctx context.Context, rootIDs []uint) ([]*model.Comment, error) {
+	var replies []*model.Comment
+	if len(rootIDs) == 0 {
+		return replies, nil
+	}
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("root_id IN ?", rootIDs).
+		Order("created_at ASC, id ASC").
+		Find(&replies).Error
+	return replies, err
+}
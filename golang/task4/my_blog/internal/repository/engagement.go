@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"my_blog/internal/model"
+)
+
+// Likes 文章点赞
+type Likes struct {
+	db *gorm.DB
+}
+
+// Add 点赞，已点过赞时不新增记录，返回是否新增
+func (r *Likes) Add(ctx context.Context, userID, postID uint) (bool, error) {
+	return affected(r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.PostLike{UserID: userID, PostID: postID}))
+}
+
+// Remove 取消点赞，返回是否删除了记录
+func (r *Likes) Remove(ctx context.Context, userID, postID uint) (bool, error) {
+	return affected(r.db.WithContext(ctx).Where("user_id = ? AND post_id = ?", userID, postID).Delete(&model.PostLike{}))
+}
+
+// Exists 用户是否赞过文章
+func (r *Likes) Exists(ctx context.Context, userID, postID uint) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&model.PostLike{}).Where("user_id = ? AND post_id = ?", userID, postID).Count(&n).Error
+	return n > 0, err
+}
+
+// Bookmarks 文章收藏
+type Bookmarks struct {
+	db *gorm.DB
+}
+
+// Add 收藏文章，已收藏时不新增记录，返回是否新增
+func (r *Bookmarks) Add(ctx context.Context, userID, postID uint) (bool, error) {
+	return affected(r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.Bookmark{UserID: userID, PostID: postID}))
+}
+
+// Remove 取消收藏，返回是否删除了记录
+func (r *Bookmarks) Remove(ctx context.Context, userID, postID uint) (bool, error) {
+	return affected(r.db.WithContext(ctx).Where("user_id = ? AND post_id = ?", userID, postID).Delete(&model.Bookmark{}))
+}
+
+// Exists 用户是否收藏了文章
+func (r *Bookmarks) Exists(ctx context.Context, userID, postID uint) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&model.Bookmark{}).Where("user_id = ? AND post_id = ?", userID, postID).Count(&n).Error
+	return n > 0, err
+}
+
+// List 用户的收藏及文章，按收藏时间倒序；文章已删除或不再公开的收藏不返回
+func (r *Bookmarks) List(ctx context.Context, userID uint, after *Cursor, limit int) ([]model.Bookmark, error) {
+	db := r.db.WithContext(ctx)
+	query := db.Where("user_id = ?", userID).
+		Where("post_id IN (?)", db.Model(&model.Post{}).Select("id").Where("status = ?", model.PostStatusPublished)).
+		Preload("Post").Preload("Post.User").Preload("Post.Tags").Preload("Post.Categories")
+	if after != nil {
+		query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))", after.At, after.At, after.ID)
+	}
+	var list []model.Bookmark
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"my_blog/internal/model"
+)
+
+// Follows 用户关注关系
+type Follows struct {
+	db *gorm.DB
+}
+
+// Add 关注，已关注时不新增记录，返回是否新增
+func (r *Follows) Add(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	return affected(r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.Follow{FollowerID: followerID, FolloweeID: followeeID}))
+}
+
+// Remove 取消关注，返回是否删除了记录
+func (r *Follows) Remove(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	return affected(r.db.WithContext(ctx).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&model.Follow{}))
+}
+
+// RemoveUser 删除用户作为关注者或被关注者的全部关系，返回被删除的关系
+func (r *Follows) RemoveUser(ctx context.Context, userID uint) ([]model.Follow, error) {
+	db := r.db.WithContext(ctx)
+	var edges []model.Follow
+	if err := db.Where("follower_id = ? OR followee_id = ?", userID, userID).Find(&edges).Error; err != nil {
+		return nil, err
+	}
+	if len(edges) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(edges))
+	for i, e := range edges {
+		ids[i] = e.ID
+	}
+	return edges, db.Delete(&model.Follow{}, ids).Error
+}
+
+// Exists followerID 是否关注了 followeeID
+func (r *Follows) Exists(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&model.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// Followers 关注了 userID 的用户，按关注时间倒序，Follower 已加载；已注销的用户不返回
+func (r *Follows) Followers(ctx context.Context, userID uint, after *Cursor, limit int) ([]model.Follow, error) {
+	return r.list(ctx, "followee_id", "follower_id", "Follower", userID, after, limit)
+}
+
+// Following userID 关注的用户，按关注时间倒序，Followee 已加载；已注销的用户不返回
+func (r *Follows) Following(ctx context.Context, userID uint, after *Cursor, limit int) ([]model.Follow, error) {
+	return r.list(ctx, "follower_id", "followee_id", "Followee", userID, after, limit)
+}
+
+// list 按 by 列筛选关系，并加载 other 列对应的用户
+func (r *Follows) list(ctx context.Context, by, other, preload string, userID uint, after *Cursor, limit int) ([]model.Follow, error) {
+	query := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = follows."+other+" AND users.deleted_at IS NULL").
+		Where("follows."+by+" = ?", userID).
+		Preload(preload)
+	if after != nil {
+		query = query.Where("(follows.created_at < ? OR (follows.created_at = ? AND follows.id < ?))", after.At, after.At, after.ID)
+	}
+	var list []model.Follow
+	err := query.Order("follows.created_at DESC, follows.id DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"my_blog/internal/model"
+)
+
+// Notifications 站内通知与通知设置
+type Notifications struct {
+	db *gorm.DB
+}
+
+func (r *Notifications) Create(ctx context.Context, n *model.Notification) error {
+	return r.db.WithContext(ctx).Create(n).Error
+}
+
+// List 用户的通知，按时间倒序；unreadOnly 时只返回未读，after 非空时从该位置之后开始
+func (r *Notifications) List(ctx context.Context, userID uint, unreadOnly bool, after *Cursor, limit int) ([]model.Notification, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if after != nil {
+		query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))", after.At, after.At, after.ID)
+	}
+	var list []model.Notification
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// UnreadCounts 用户各类型的未读通知数
+func (r *Notifications) UnreadCounts(ctx context.Context, userID uint) (map[string]int64, error) {
+	var rows []struct {
+		Type  string
+		Count int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Select("type, COUNT(*) AS count").
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Group("type").Scan(&rows).Error
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts, err
+}
+
+// MarkRead 将用户自己的指定通知标记为已读，返回实际更新的条数
+func (r *Notifications) MarkRead(ctx context.Context, userID uint, ids []uint, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND id IN ? AND read_at IS NULL", userID, ids).
+		Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
+
+// MarkAllRead 将用户的全部未读通知标记为已读
+func (r *Notifications) MarkAllRead(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
+
+// Preferences 用户已保存的通知设置，未设置的类型不在结果中
+func (r *Notifications) Preferences(ctx context.Context, userIDs ...uint) ([]model.NotificationPreference, error) {
+	var prefs []model.NotificationPreference
+	err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&prefs).Error
+	return prefs, err
+}
+
+// SavePreferences 保存通知设置，同一用户同一类型已存在时覆盖
+func (r *Notifications) SavePreferences(ctx context.Context, prefs []model.NotificationPreference) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "updated_at"}),
+	}).Create(&prefs).Error
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"my_blog/internal/model"
+)
+
+// 公开文章列表的排序方式
+const (
+	SortNewest        = "newest"
+	SortMostCommented = "most_commented"
+	SortMostLiked     = "most_liked"
+	SortTrending      = "trending" // 按互动热度，通常配合 Since 只统计近期发布的文章
+)
+
+// 热度 = 点赞 × 3 + 评论 × 5 + 阅读
+const (
+	trendingLikeWeight    = 3
+	trendingCommentWeight = 5
+	trendingViewWeight    = 1
+)
+
+// sortKeys 按计数排序时的排序表达式
+var sortKeys = map[string]string{
+	SortMostCommented: "posts.comment_count",
+	SortMostLiked:     "posts.like_count",
+	SortTrending: fmt.Sprintf("(posts.like_count * %d + posts.comment_count * %d + posts.view_count * %d)",
+		trendingLikeWeight, trendingCommentWeight, trendingViewWeight),
+}
+
+// SortKey 文章在按计数排序时的排序键，用于生成分页游标；SortNewest 返回 0
+func SortKey(sort string, p *model.Post) int64 {
+	switch sort {
+	case SortMostCommented:
+		return p.CommentCount
+	case SortMostLiked:
+		return p.LikeCount
+	case SortTrending:
+		return p.LikeCount*trendingLikeWeight + p.CommentCount*trendingCommentWeight + p.ViewCount*trendingViewWeight
+	}
+	return 0
+}
+
+// PostQuery 公开文章列表的筛选与分页条件，Tag、Category 需已规范化为小写
+type PostQuery struct {
+	AuthorID uint
+	Tag      string
+	Category string
+	From, To *time.Time // 按发布时间筛选
+	Since    *time.Time // 只包含此后发布的文章
+	Sort     string
+	After    *Cursor    // Sort 为 SortNewest（按发布时间倒序）时的分页位置
+	AfterKey *KeyCursor // 按计数排序时的分页位置
+	Limit    int
+}
+
+// Posts 文章与历史版本
+type Posts struct {
+	db *gorm.DB
+}
+
+// preload 加载文章详情需要的关联
+func (r *Posts) preload(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Preload("User").Preload("Tags").Preload("Categories").
+		Preload("Attachments", func(db *gorm.DB) *gorm.DB { return db.Order("attachments.id") })
+}
+
+// Find 按 ID 查询文章（任意状态，不加载关联）
+func (r *Posts) Find(ctx context.Context, id uint) (*model.Post, error) {
+	return first[model.Post](r.db.WithContext(ctx), id)
+}
+
+// FindPublished 查询已发布的文章及其关联
+func (r *Posts) FindPublished(ctx context.Context, id uint) (*model.Post, error) {
+	return first[model.Post](r.preload(ctx).Where("status = ?", model.PostStatusPublished), id)
+}
+
+// Reload 重新读取文章及其关联
+func (r *Posts) Reload(ctx context.Context, post *model.Post) error {
+	return notFound(r.preload(ctx).First(post, post.ID).Error)
+}
+
+// DueScheduled 发布时间不晚于 now 的定时文章及其关联
+func (r *Posts) DueScheduled(ctx context.Context, now time.Time) ([]model.Post, error) {
+	var due []model.Post
+	err := r.preload(ctx).Where("status = ? AND publish_at <= ?", model.PostStatusScheduled, now).Find(&due).Error
+	return due, err
+}
+
+// PublishScheduled 把仍处于定时状态的文章切换为已发布，发布时间取 publish_at；
+// 条件更新保证作者在此期间改回草稿等情况下不会被误发布，返回是否发布成功
+func (r *Posts) PublishScheduled(ctx context.Context, post *model.Post) (bool, error) {
+	return affected(r.db.WithContext(ctx).Model(&model.Post{}).
+		Where("id = ? AND status = ?", post.ID, model.PostStatusScheduled).
+		Updates(map[string]interface{}{
+			"status":       model.PostStatusPublished,
+			"published_at": post.PublishAt,
+			"publish_at":   nil,
+		}))
+}
+
+// ListPublished 已发布文章的一页（最多 q.Limit 条）及符合条件的总数
+func (r *Posts) ListPublished(ctx context.Context, q PostQuery) ([]model.Post, int64, error) {
+	db := r.db.WithContext(ctx)
+	// 筛选条件同时用于统计总数和分页查询
+	filter := db.Model(&model.Post{}).Where("posts.status = ?", model.PostStatusPublished)
+	if q.AuthorID > 0 {
+		filter = filter.Where("posts.user_id = ?", q.AuthorID)
+	}
+	if q.Tag != "" {
+		filter = filter.Where("posts.id IN (?)", db.Table("post_tags").
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name = ?", q.Tag))
+	}
+	if q.Category != "" {
+		filter = filter.Where("posts.id IN (?)", db.Table("post_categories").
+			Select("post_categories.post_id").
+			Joins("JOIN categories ON categories.id = post_categories.category_id").
+			Where("categories.name = ?", q.Category))
+	}
+	if q.From != nil {
+		filter = filter.Where("posts.published_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		filter = filter.Where("posts.published_at < ?", *q.To)
+	}
+	if q.Since != nil {
+		filter = filter.Where("posts.published_at >= ?", *q.Since)
+	}
+
+	var total int64
+	if err := filter.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := filter.Session(&gorm.Session{}).Preload("User").Preload("Tags").Preload("Categories")
+	if key, ok := sortKeys[q.Sort]; ok {
+		if k := q.AfterKey; k != nil {
+			query = query.Where("("+key+" < ? OR ("+key+" = ? AND posts.id < ?))", k.Key, k.Key, k.ID)
+		}
+		query = query.Order(key + " DESC").Order("posts.id DESC")
+	} else {
+		if a := q.After; a != nil {
+			query = query.Where("(posts.published_at < ? OR (posts.published_at = ? AND posts.id < ?))", a.At, a.At, a.ID)
+		}
+		query = query.Order("posts.published_at DESC").Order("posts.id DESC")
+	}
+
+	var posts []model.Post
+	err := query.Limit(q.Limit).Find(&posts).Error
+	return posts, total, err
+}
+
+// ListFollowed followerID 关注的作者已发布的文章，按发布时间倒序，after 非空时从该位置之后开始。
+// 每次读取时按关注关系实时合并（读扩散）
+func (r *Posts) ListFollowed(ctx context.Context, followerID uint, after *Cursor, limit int) ([]model.Post, error) {
+	db := r.db.WithContext(ctx)
+	query := r.preload(ctx).
+		Where("posts.status = ?", model.PostStatusPublished).
+		Where("posts.user_id IN (?)", db.Model(&model.Follow{}).Select("followee_id").Where("follower_id = ?", followerID))
+	if after != nil {
+		query = query.Where("(posts.published_at < ? OR (posts.published_at = ? AND posts.id < ?))", after.At, after.At, after.ID)
+	}
+	var posts []model.Post
+	err := query.Order("posts.published_at DESC").Order("posts.id DESC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// ListByUser 用户自己的文章（任意状态），status 非空时只返回该状态
+func (r *Posts) ListByUser(ctx context.Context, userID uint, status string) ([]model.Post, error) {
+	query := r.preload(ctx).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var posts []model.Post
+	err := query.Order("updated_at DESC").Find(&posts).Error
+	return posts, err
+}
+
+// Create 创建文章，post.Tags、post.Categories 一并关联
+func (r *Posts) Create(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Create(post).Error
+}
+
+// Save 保存文章的全部字段（不含关联和互动计数，计数只通过 Add* 增减）
+func (r *Posts) Save(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Omit("Tags", "Categories", "Attachments", "User", "LikeCount", "CommentCount", "ViewCount").Save(post).Error
+}
+
+// AddLikes 原子地增减点赞数，需与点赞记录在同一事务中调用
+func (r *Posts) AddLikes(ctx context.Context, id uint, delta int64) error {
+	return r.addCount(ctx, id, "like_count", delta)
+}
+
+// AddComments 原子地增减评论数，需与评论记录在同一事务中调用
+func (r *Posts) AddComments(ctx context.Context, id uint, delta int64) error {
+	return r.addCount(ctx, id, "comment_count", delta)
+}
+
+// AddViews 批量累加阅读数，views 为文章 ID 到新增阅读数的映射
+func (r *Posts) AddViews(ctx context.Context, views map[uint]int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for id, n := range views {
+			// 已删除的文章同样累加，计数不对外展示
+			if err := tx.Unscoped().Model(&model.Post{}).Where("id = ?", id).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// addCount 增减计数列，不会减到 0 以下；UpdateColumn 不修改 updated_at
+func (r *Posts) addCount(ctx context.Context, id uint, column string, delta int64) error {
+	expr := gorm.Expr(column+" + ?", delta)
+	if delta < 0 {
+		expr = gorm.Expr("CASE WHEN "+column+" > ? THEN "+column+" - ? ELSE 0 END", -delta, -delta)
+	}
+	return r.db.WithContext(ctx).Unscoped().Model(&model.Post{}).Where("id = ?", id).UpdateColumn(column, expr).Error
+}
+
+// SaveContent 只保存标题、正文及其渲染结果
+func (r *Posts) SaveContent(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Model(post).
+		Select("title", "content", "content_html", "excerpt", "reading_time").
+		Updates(post).Error
+}
+
+func (r *Posts) ReplaceTags(ctx context.Context, post *model.Post, tags []model.Tag) error {
+	return r.db.WithContext(ctx).Model(post).Association("Tags").Replace(tags)
+}
+
+func (r *Posts) ReplaceCategories(ctx context.Context, post *model.Post, categories []model.Category) error {
+	return r.db.WithContext(ctx).Model(post).Association("Categories").Replace(categories)
+}
+
+func (r *Posts) Delete(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Delete(post).Error
+}
+
+// CreateRevision 将文章当前内容保存为一个新版本，需在事务中调用
+func (r *Posts) CreateRevision(ctx context.Context, post *model.Post, editorID uint) error {
+	db := r.db.WithContext(ctx)
+	var latest int
+	if err := db.Model(&model.PostRevision{}).
+		Where("post_id = ?", post.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return err
+	}
+	return db.Create(&model.PostRevision{
+		PostID:   post.ID,
+		Version:  latest + 1,
+		Title:    post.Title,
+		Content:  post.Content,
+		EditorID: editorID,
+	}).Error
+}
+
+// Revisions 文章的历史版本，不含正文，按版本号倒序
+func (r *Posts) Revisions(ctx context.Context, postID uint) ([]model.PostRevision, error) {
+	var revisions []model.PostRevision
+	err := r.db.WithContext(ctx).
+		Select("id", "created_at", "post_id", "version", "title", "editor_id").
+		Where("post_id = ?", postID).
+		Order("version DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *Posts) FindRevision(ctx context.Context, postID uint, version int) (*model.PostRevision, error) {
+	return first[model.PostRevision](r.db.WithContext(ctx).Where("post_id = ? AND version = ?", postID, version))
+}
@@ -0,0 +1,98 @@
+// Package repository 数据访问层：封装对 *gorm.DB 的全部查询，上层只依赖这里的方法和 ErrNotFound
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound 记录不存在
+var ErrNotFound = errors.New("record not found")
+
+// Store 按聚合划分的数据访问对象，同一个 Store 内的操作共享同一个连接或事务
+type Store struct {
+	db *gorm.DB
+
+	Users         *Users
+	Tokens        *Tokens
+	Audit         *Audit
+	Posts         *Posts
+	Comments      *Comments
+	Tags          *Tags
+	Notifications *Notifications
+	Likes         *Likes
+	Bookmarks     *Bookmarks
+	Follows       *Follows
+	Attachments   *Attachments
+}
+
+// New 基于 db 创建 Store
+func New(db *gorm.DB) *Store {
+	return &Store{
+		db:            db,
+		Users:         &Users{db: db},
+		Tokens:        &Tokens{db: db},
+		Audit:         &Audit{db: db},
+		Posts:         &Posts{db: db},
+		Comments:      &Comments{db: db},
+		Tags:          &Tags{db: db},
+		Notifications: &Notifications{db: db},
+		Likes:         &Likes{db: db},
+		Bookmarks:     &Bookmarks{db: db},
+		Follows:       &Follows{db: db},
+		Attachments:   &Attachments{db: db},
+	}
+}
+
+// Tx 在事务中执行 fn，fn 返回错误时回滚。已在事务中时使用保存点嵌套
+func (s *Store) Tx(ctx context.Context, fn func(tx *Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(New(tx))
+	})
+}
+
+// Cursor 按 (时间, ID) 排序的分页位置
+type Cursor struct {
+	At time.Time
+	ID uint
+}
+
+// KeyCursor 按 (数值, ID) 排序的分页位置
+type KeyCursor struct {
+	Key int64
+	ID  uint
+}
+
+// NameCount 标签或分类及其文章数
+type NameCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// notFound 将 gorm 的记录不存在错误转换为 ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// first 按条件查询单条记录
+func first[T any](db *gorm.DB, conds ...interface{}) (*T, error) {
+	var out T
+	if err := db.First(&out, conds...).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &out, nil
+}
+
+// affected 条件更新是否命中了记录
+func affected(res *gorm.DB) (bool, error) {
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
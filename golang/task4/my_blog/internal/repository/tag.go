@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"my_blog/internal/model"
+)
+
+// Tags 标签与分类
+type Tags struct {
+	db *gorm.DB
+}
+
+// EnsureTags 按名称查找标签，不存在的自动创建
+func (r *Tags) EnsureTags(ctx context.Context, names []string) ([]model.Tag, error) {
+	if len(names) == 0 {
+		return []model.Tag{}, nil
+	}
+	db := r.db.WithContext(ctx)
+	tags := make([]model.Tag, len(names))
+	for i, n := range names {
+		tags[i] = model.Tag{Name: n}
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	var out []model.Tag
+	err := db.Where("name IN ?", names).Find(&out).Error
+	return out, err
+}
+
+// FindCategories 按名称查找已存在的分类
+func (r *Tags) FindCategories(ctx context.Context, names []string) ([]model.Category, error) {
+	out := []model.Category{}
+	if len(names) == 0 {
+		return out, nil
+	}
+	err := r.db.WithContext(ctx).Where("name IN ?", names).Find(&out).Error
+	return out, err
+}
+
+// CreateCategory 新建分类，同名分类已存在时返回 false
+func (r *Tags) CreateCategory(ctx context.Context, category *model.Category) (bool, error) {
+	return affected(r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(category))
+}
+
+// TagCounts 标签及其已发布文章数，按文章数降序；prefix 为 LIKE 前缀（调用方负责转义，转义符为 !）
+func (r *Tags) TagCounts(ctx context.Context, prefix string, limit int) ([]NameCount, error) {
+	query := r.db.WithContext(ctx).Model(&model.Tag{}).
+		Select("tags.name, COUNT(posts.id) AS count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL AND posts.status = ?", model.PostStatusPublished)
+	if prefix != "" {
+		query = query.Where("tags.name LIKE ? ESCAPE '!'", prefix+"%")
+	}
+	var out []NameCount
+	err := query.
+		Group("tags.id, tags.name").
+		Order("count DESC, tags.name ASC").
+		Limit(limit).
+		Scan(&out).Error
+	return out, err
+}
+
+// CategoryCounts 全部分类及已发布文章数，按名称排序
+func (r *Tags) CategoryCounts(ctx context.Context) ([]NameCount, error) {
+	var out []NameCount
+	err := r.db.WithContext(ctx).Model(&model.Category{}).
+		Select("categories.name, COUNT(posts.id) AS count").
+		Joins("LEFT JOIN post_categories ON post_categories.category_id = categories.id").
+		Joins("LEFT JOIN posts ON posts.id = post_categories.post_id AND posts.deleted_at IS NULL AND posts.status = ?", model.PostStatusPublished).
+		Group("categories.id, categories.name").
+		Order("categories.name ASC").
+		Scan(&out).Error
+	return out, err
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"my_blog/internal/model"
+)
+
+// Tokens 刷新令牌、吊销列表、一次性令牌、恢复码与钱包登录 nonce
+type Tokens struct {
+	db *gorm.DB
+}
+
+func (r *Tokens) CreateRefresh(ctx context.Context, rt *model.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(rt).Error
+}
+
+func (r *Tokens) FindRefreshByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	return first[model.RefreshToken](r.db.WithContext(ctx).Where("token_hash = ?", hash))
+}
+
+// FindRefreshByAccessJTI 与某个 access token 一同签发的 refresh token
+func (r *Tokens) FindRefreshByAccessJTI(ctx context.Context, jti string) (*model.RefreshToken, error) {
+	return first[model.RefreshToken](r.db.WithContext(ctx).Where("access_jti = ?", jti))
+}
+
+// RevokeRefresh 条件吊销单个 refresh token，已被吊销时返回 false，保证并发刷新只有一个成功
+func (r *Tokens) RevokeRefresh(ctx context.Context, id uint, at time.Time) (bool, error) {
+	return affected(r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at))
+}
+
+// RevokeFamily 吊销整个令牌族：所有 refresh token 以及随之签发的 access token
+func (r *Tokens) RevokeFamily(ctx context.Context, familyID string, accessTTL time.Duration) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tokens []model.RefreshToken
+		if err := tx.Where("family_id = ?", familyID).Find(&tokens).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		for _, t := range tokens {
+			if t.AccessJTI == "" {
+				continue
+			}
+			if err := revokeJTI(tx, t.AccessJTI, t.CreatedAt.Add(accessTTL)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ActiveFamilies 用户未吊销的令牌族，except 非空时排除该令牌族
+func (r *Tokens) ActiveFamilies(ctx context.Context, userID uint, except string) ([]string, error) {
+	query := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if except != "" {
+		query = query.Where("family_id <> ?", except)
+	}
+	var families []string
+	err := query.Distinct().Pluck("family_id", &families).Error
+	return families, err
+}
+
+// RevokeJTI 将 access token 加入吊销列表，已存在时忽略
+func (r *Tokens) RevokeJTI(ctx context.Context, jti string, expiresAt time.Time) error {
+	return revokeJTI(r.db.WithContext(ctx), jti, expiresAt)
+}
+
+func revokeJTI(db *gorm.DB, jti string, expiresAt time.Time) error {
+	if !expiresAt.After(time.Now()) {
+		return nil // 已自然过期，无需记录
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+// IsRevoked access token 的 jti 是否已被吊销
+func (r *Tokens) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&model.RevokedToken{}).Where("jti = ?", jti).Count(&n).Error
+	return n > 0, err
+}
+
+func (r *Tokens) CreateAction(ctx context.Context, at *model.ActionToken) error {
+	return r.db.WithContext(ctx).Create(at).Error
+}
+
+// UseAction 将一次性令牌标记为已使用，不存在或已使用时返回 false
+func (r *Tokens) UseAction(ctx context.Context, jti, purpose string, userID uint, at time.Time) (bool, error) {
+	return affected(r.db.WithContext(ctx).Model(&model.ActionToken{}).
+		Where("jti = ? AND purpose = ? AND user_id = ? AND used_at IS NULL", jti, purpose, userID).
+		Update("used_at", at))
+}
+
+func (r *Tokens) CreatePasswordReset(ctx context.Context, rt *model.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(rt).Error
+}
+
+func (r *Tokens) FindPasswordReset(ctx context.Context, hash string) (*model.PasswordResetToken, error) {
+	return first[model.PasswordResetToken](r.db.WithContext(ctx).Where("token_hash = ?", hash))
+}
+
+// UsePasswordReset 条件标记重置令牌已使用，已使用或已过期时返回 false
+func (r *Tokens) UsePasswordReset(ctx context.Context, id uint, at time.Time) (bool, error) {
+	return affected(r.db.WithContext(ctx).Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, at).
+		Update("used_at", at))
+}
+
+// ExpirePasswordResets 使用户其余未使用的重置令牌失效
+func (r *Tokens) ExpirePasswordResets(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+// ReplaceRecoveryCodes 删除用户的旧恢复码并保存新的哈希
+func (r *Tokens) ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error {
+	if err := r.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+	rows := make([]model.RecoveryCode, len(hashes))
+	for i, h := range hashes {
+		rows[i] = model.RecoveryCode{UserID: userID, CodeHash: h}
+	}
+	return r.db.WithContext(ctx).Create(&rows).Error
+}
+
+// UseRecoveryCode 将恢复码标记为已使用，不存在或已使用时返回 false
+func (r *Tokens) UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	return affected(r.db.WithContext(ctx).Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at))
+}
+
+// CountRecoveryCodes 未使用的恢复码数量
+func (r *Tokens) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).Count(&n).Error
+	return n, err
+}
+
+func (r *Tokens) DeleteRecoveryCodes(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
+
+func (r *Tokens) CreateNonce(ctx context.Context, n *model.SIWENonce) error {
+	return r.db.WithContext(ctx).Create(n).Error
+}
+
+// UseNonce 条件标记 nonce 已使用，保证每个 nonce 只能使用一次
+func (r *Tokens) UseNonce(ctx context.Context, nonce string, at time.Time) (bool, error) {
+	return affected(r.db.WithContext(ctx).Model(&model.SIWENonce{}).
+		Where("nonce = ? AND used_at IS NULL AND expires_at > ?", nonce, at).
+		Update("used_at", at))
+}
+
+// PurgeExpired 删除 before 之前已过期的刷新令牌、吊销记录、一次性令牌、重置密码令牌和钱包登录 nonce，返回删除的行数。
+// 这些记录过期后不再参与任何校验
+func (r *Tokens) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	var total int64
+	for _, m := range []interface{}{
+		&model.RefreshToken{}, &model.RevokedToken{}, &model.ActionToken{}, &model.PasswordResetToken{}, &model.SIWENonce{},
+	} {
+		res := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(m)
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+	}
+	return total, nil
+}
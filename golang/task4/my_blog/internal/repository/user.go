@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"my_blog/internal/model"
+)
+
+// Users 用户
+type Users struct {
+	db *gorm.DB
+}
+
+func (r *Users) Find(ctx context.Context, id uint) (*model.User, error) {
+	return first[model.User](r.db.WithContext(ctx), id)
+}
+
+func (r *Users) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	return first[model.User](r.db.WithContext(ctx).Where("username = ?", username))
+}
+
+// FindByIDs 按 ID 批量查找，不存在的 ID 忽略
+func (r *Users) FindByIDs(ctx context.Context, ids []uint) ([]model.User, error) {
+	var users []model.User
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// FindByUsernames 按用户名批量查找，不存在的用户名忽略
+func (r *Users) FindByUsernames(ctx context.Context, usernames []string) ([]model.User, error) {
+	var users []model.User
+	err := r.db.WithContext(ctx).Where("username IN ?", usernames).Find(&users).Error
+	return users, err
+}
+
+func (r *Users) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	return first[model.User](r.db.WithContext(ctx).Where("email = ?", email))
+}
+
+func (r *Users) FindByEthAddress(ctx context.Context, address string) (*model.User, error) {
+	return first[model.User](r.db.WithContext(ctx).Where("eth_address = ?", address))
+}
+
+// UsernameTaken 用户名是否已被使用。已删除的用户仍占用唯一索引，一并计入
+func (r *Users) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).Where("username = ?", username).Count(&n).Error
+	return n > 0, err
+}
+
+// EmailTaken 邮箱是否已被注册，包括已删除的用户
+func (r *Users) EmailTaken(ctx context.Context, email string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).Where("email = ?", email).Count(&n).Error
+	return n > 0, err
+}
+
+func (r *Users) Create(ctx context.Context, user *model.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+// List 全部用户，按 ID 升序
+func (r *Users) List(ctx context.Context) ([]model.User, error) {
+	var users []model.User
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&users).Error
+	return users, err
+}
+
+// Delete 软删除用户，不存在时返回 ErrNotFound
+func (r *Users) Delete(ctx context.Context, id uint) error {
+	ok, err := affected(r.db.WithContext(ctx).Delete(&model.User{}, id))
+	if err == nil && !ok {
+		err = ErrNotFound
+	}
+	return err
+}
+
+// AddFollowCounts 原子地增减关注者的关注数与被关注者的粉丝数，需与关注记录在同一事务中调用
+func (r *Users) AddFollowCounts(ctx context.Context, followerID, followeeID uint, delta int64) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&model.User{}).Where("id = ?", followerID).
+		UpdateColumn("following_count", gorm.Expr("following_count + ?", delta)).Error; err != nil {
+		return err
+	}
+	return db.Model(&model.User{}).Where("id = ?", followeeID).
+		UpdateColumn("follower_count", gorm.Expr("follower_count + ?", delta)).Error
+}
+
+func (r *Users) update(ctx context.Context, id uint, columns map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).UpdateColumns(columns).Error
+}
+
+func (r *Users) SetRole(ctx context.Context, id uint, role string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// SetPassword 更新密码哈希，unlock 为 true 时同时解除登录锁定
+func (r *Users) SetPassword(ctx context.Context, id uint, hash string, unlock bool) error {
+	columns := map[string]interface{}{"password": hash}
+	if unlock {
+		columns["failed_logins"], columns["locked_until"] = 0, nil
+	}
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Updates(columns).Error
+}
+
+// MarkEmailVerified 记录邮箱验证时间，已验证的保持不变
+func (r *Users) MarkEmailVerified(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND email_verified_at IS NULL", id).
+		Update("email_verified_at", at).Error
+}
+
+// IncrFailedLogins 连续失败次数加一并返回新值，用自增表达式避免并发请求互相覆盖计数
+func (r *Users) IncrFailedLogins(ctx context.Context, id uint) (int, error) {
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&model.User{}).Where("id = ?", id).
+		UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1")).Error; err != nil {
+		return 0, err
+	}
+	var n int
+	err := db.Model(&model.User{}).Where("id = ?", id).Select("failed_logins").Scan(&n).Error
+	return n, err
+}
+
+func (r *Users) LockUntil(ctx context.Context, id uint, until time.Time) error {
+	return r.update(ctx, id, map[string]interface{}{"locked_until": until})
+}
+
+// ClearLockout 清零失败次数并解除锁定
+func (r *Users) ClearLockout(ctx context.Context, id uint) error {
+	return r.update(ctx, id, map[string]interface{}{"failed_logins": 0, "locked_until": nil})
+}
+
+func (r *Users) SetTOTPSecret(ctx context.Context, id uint, secret string) error {
+	return r.update(ctx, id, map[string]interface{}{"totp_secret": secret})
+}
+
+// AdvanceTOTPStep 记录已使用的时间步，step 不大于已记录值时返回 false（验证码重放）
+func (r *Users) AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	return affected(r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumn("totp_last_step", step))
+}
+
+func (r *Users) EnableMFA(ctx context.Context, id uint, at time.Time) error {
+	return r.update(ctx, id, map[string]interface{}{"mfa_enabled_at": at})
+}
+
+// ClearMFA 清除 TOTP 密钥与启用状态
+func (r *Users) ClearMFA(ctx context.Context, id uint) error {
+	return r.update(ctx, id, map[string]interface{}{"totp_secret": "", "totp_last_step": 0, "mfa_enabled_at": nil})
+}
+
+func (r *Users) SetEthAddress(ctx context.Context, id uint, address string) error {
+	return r.update(ctx, id, map[string]interface{}{"eth_address": address})
+}
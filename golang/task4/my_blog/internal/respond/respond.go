@@ -0,0 +1,163 @@
+// Package respond 统一的 JSON 响应：领域错误集中映射为 HTTP 状态码与错误信封，消息按 Accept-Language 翻译
+package respond
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"my_blog/internal/apperr"
+	"my_blog/internal/i18n"
+)
+
+func init() {
+	// 校验错误中使用请求里的字段名（json / form 标签），而不是 Go 结构体字段名
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, key := range []string{"json", "form"} {
+				if name, _, _ := strings.Cut(f.Tag.Get(key), ","); name != "" && name != "-" {
+					return name
+				}
+			}
+			return f.Name
+		})
+	}
+}
+
+// 请求本身的错误
+var (
+	ErrMalformed   = apperr.New(apperr.Invalid, "request.malformed")
+	ErrInvalid     = apperr.New(apperr.Invalid, "request.invalid")
+	ErrRateLimited = apperr.New(apperr.TooManyRequests, "rate_limited")
+)
+
+// Envelope 错误响应体
+type Envelope struct {
+	Error     Body   `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Body 错误详情
+type Body struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Fields  []Field        `json:"fields,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Field 字段校验错误
+type Field struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Status 错误类别对应的 HTTP 状态码
+func Status(kind apperr.Kind) int {
+	switch kind {
+	case apperr.Invalid:
+		return http.StatusBadRequest
+	case apperr.Unauthorized:
+		return http.StatusUnauthorized
+	case apperr.Forbidden:
+		return http.StatusForbidden
+	case apperr.NotFound:
+		return http.StatusNotFound
+	case apperr.Conflict:
+		return http.StatusConflict
+	case apperr.Locked:
+		return http.StatusLocked
+	case apperr.TooManyRequests:
+		return http.StatusTooManyRequests
+	case apperr.TooLarge:
+		return http.StatusRequestEntityTooLarge
+	case apperr.Unsupported:
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusInternalServerError
+}
+
+// Lang 当前请求的语言
+func Lang(c *gin.Context) i18n.Lang {
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// Error 写出错误响应，内部错误记录日志但不对外暴露细节
+func Error(c *gin.Context, err error) {
+	e := apperr.From(err)
+	status := Status(e.Kind)
+	if status >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "request failed",
+			"request_id", c.GetString("request_id"), "route", c.FullPath(), "err", err)
+	}
+	if e.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	c.AbortWithStatusJSON(status, envelope(c, e))
+}
+
+// NewEnvelope 构造错误信封但不写出，供 SSE、WebSocket 等流式连接在消息中下发错误
+func NewEnvelope(c *gin.Context, err error) Envelope {
+	return envelope(c, apperr.From(err))
+}
+
+func envelope(c *gin.Context, e *apperr.Error) Envelope {
+	lang := Lang(c)
+	body := Body{Code: e.Code, Message: i18n.T(lang, e.Code, e.Args), Details: e.Meta}
+	for _, f := range e.Fields {
+		body.Fields = append(body.Fields, Field{
+			Field:   f.Field,
+			Rule:    f.Rule,
+			Message: fieldMessage(lang, f),
+		})
+	}
+	return Envelope{Error: body, RequestID: c.GetString("request_id")}
+}
+
+func fieldMessage(lang i18n.Lang, f apperr.FieldError) string {
+	args := map[string]string{"field": f.Field, "param": f.Param}
+	if code := "validation." + f.Rule; i18n.Has(code) {
+		return i18n.T(lang, code, args)
+	}
+	return i18n.T(lang, "validation.invalid", args)
+}
+
+// BindError 将 gin 的绑定错误转换为领域错误：校验失败列出各字段，其余视为请求格式错误
+func BindError(err error) *apperr.Error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		e := ErrInvalid.Wrap(err)
+		for _, fe := range verrs {
+			e.Fields = append(e.Fields, apperr.FieldError{Field: fe.Field(), Rule: fe.Tag(), Param: fe.Param()})
+		}
+		return e
+	}
+	return ErrMalformed.Wrap(err)
+}
+
+// Bind 绑定失败时写出 400 并返回 false
+func Bind(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	Error(c, BindError(err))
+	return false
+}
+
+// Message 写出只包含提示消息的成功响应
+func Message(c *gin.Context, status int, code string) {
+	c.JSON(status, gin.H{"message": T(c, code)})
+}
+
+// T 按当前请求的语言翻译提示码
+func T(c *gin.Context, code string) string {
+	return i18n.T(Lang(c), code, nil)
+}
@@ -4,34 +4,200 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"log"
+	"my_blog/internal/conf"
+	"my_blog/internal/mail"
+	"my_blog/internal/metrics"
 	"my_blog/internal/middleware"
+	"my_blog/internal/model"
+	"my_blog/internal/realtime"
+	"my_blog/internal/repository"
+	"my_blog/internal/search"
+	"my_blog/internal/service"
+	"my_blog/internal/storage"
 
 	"my_blog/internal/handler"
 )
 
-func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
-	authHandler := &handler.AuthHandler{DB: db}
-	postHandler := &handler.PostHandler{DB: db}
-	commentHandler := &handler.CommentHandler{DB: db}
+// Deps 路由与 handler 的依赖
+type Deps struct {
+	DB         *gorm.DB
+	Searcher   search.Searcher
+	Limiter    *middleware.Limiter // 为 nil 时不限流
+	Mailer     mail.Mailer
+	MailQueue  *mail.Queue                // 通知和重置密码邮件的后台发送队列，为 nil 时通知不发送邮件、重置密码邮件同步发送
+	BaseURL    string                     // 邮件中链接指向的站点地址
+	Security   func() conf.SecurityConfig // 当前的账号安全配置，为 nil 时使用默认值
+	SIWE       func() conf.SIWEConfig     // 当前的钱包登录配置，为 nil 时使用默认值
+	Hub        *realtime.Hub              // 为 nil 时不启用实时推送
+	Realtime   conf.RealtimeConfig
+	CORS       func() conf.CORSConfig // WebSocket 握手时校验跨域来源
+	Views      *service.ViewCounter   // 为 nil 时不统计阅读数
+	Engagement conf.EngagementConfig
+	Storage    storage.Storage // 上传文件的存储，为 nil 时不启用上传
+	Upload     conf.UploadConfig
+}
+
+// RegisterRoutes 注册 API 路由
+func RegisterRoutes(r *gin.Engine, d Deps) {
+	store, searcher, limiter := repository.New(d.DB), d.Searcher, d.Limiter
+	auth := &service.Auth{Store: store, Mailer: d.Mailer, BaseURL: d.BaseURL, Security: d.Security, SIWE: d.SIWE}
+	if d.MailQueue != nil {
+		auth.Queue = d.MailQueue
+	}
+	var events service.Events
+	if d.Hub != nil {
+		events = d.Hub
+	}
+	posts := &service.Posts{
+		Store:          store,
+		Searcher:       searcher,
+		Events:         events,
+		Views:          d.Views,
+		TrendingWindow: d.Engagement.TrendingWindow,
+	}
+	notifications := &service.Notifications{Store: store, BaseURL: d.BaseURL, Events: events}
+	if d.MailQueue != nil {
+		notifications.Mailer = d.MailQueue
+	}
+	comments := &service.Comments{Store: store, Searcher: searcher, Events: events, Notifications: notifications}
+
+	authHandler := &handler.AuthHandler{Auth: auth}
+	postHandler := &handler.PostHandler{Posts: posts}
+	commentHandler := &handler.CommentHandler{Comments: comments}
+	userHandler := &handler.UserHandler{Users: &service.Users{Store: store}}
+	searchHandler := &handler.SearchHandler{Searcher: searcher}
+	tagHandler := &handler.TagHandler{Tags: &service.Tags{Store: store}}
+	notificationHandler := &handler.NotificationHandler{Notifications: notifications}
+	engagementHandler := &handler.EngagementHandler{Engagement: &service.Engagement{Store: store}}
+	followHandler := &handler.FollowHandler{
+		Follows: &service.Follows{Store: store},
+		Feed:    &service.Feed{Source: &service.FanOutOnRead{Store: store}},
+	}
+	uploadHandler := &handler.UploadHandler{Uploads: &service.Uploads{Store: store, Storage: d.Storage, Config: d.Upload}}
+	realtimeHandler := &handler.RealtimeHandler{
+		Hub:      d.Hub,
+		Realtime: &service.Realtime{Store: store, MaxTopics: d.Realtime.MaxTopics},
+		Auth:     auth,
+		Config:   d.Realtime,
+		CORS:     d.CORS,
+	}
+
+	// 受所有权保护的资源，作者本人或具有指定角色的用户可以修改
+	postResource := middleware.Resource{Key: "post", Load: posts.Load, Forbidden: service.ErrPostForbidden}
+	commentResource := middleware.Resource{Key: "comment", Load: comments.Load, Forbidden: service.ErrCommentForbidden}
+
+	// 供其他服务验证本站签发的 JWT
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// 本地存储的上传文件由本服务提供；nosniff 防止浏览器把图片以外的内容当作 HTML 执行
+	if local, ok := d.Storage.(*storage.Local); ok {
+		files := r.Group(local.Prefix, func(c *gin.Context) {
+			c.Header("X-Content-Type-Options", "nosniff")
+		})
+		files.Static("/", local.Dir)
+	}
 
 	public := r.Group("/api")
+	public.Use(limiter.ByIP(conf.RateLimitPublic))
 	{
 		public.GET("/post/list", postHandler.ListPosts)
 		public.GET("/post/get", postHandler.GetPost)
 		public.POST("/comment/list", commentHandler.ListComments)
+		public.POST("/register", limiter.ByIP(conf.RateLimitAuth), authHandler.Register)
+		public.POST("/login", limiter.ByIP(conf.RateLimitAuth), authHandler.Login)
+		public.POST("/token/refresh", limiter.ByIP(conf.RateLimitAuth), authHandler.Refresh)
+		public.GET("/email/verify", limiter.ByIP(conf.RateLimitAuth), authHandler.VerifyEmail)
+		public.POST("/email/verify", limiter.ByIP(conf.RateLimitAuth), authHandler.VerifyEmail)
+		public.POST("/login/mfa", limiter.ByIP(conf.RateLimitAuth), authHandler.LoginMFA)
+		public.GET("/siwe/nonce", limiter.ByIP(conf.RateLimitAuth), authHandler.SIWENonce)
+		public.POST("/siwe/verify", limiter.ByIP(conf.RateLimitAuth), authHandler.SIWELogin)
+		public.POST("/password/forgot", limiter.ByIP(conf.RateLimitAuth), authHandler.ForgotPassword)
+		public.POST("/password/reset", limiter.ByIP(conf.RateLimitAuth), authHandler.ResetPassword)
+		public.GET("/search", searchHandler.Search)
+		public.GET("/tag/autocomplete", tagHandler.Autocomplete)
+		public.GET("/tag/cloud", tagHandler.Cloud)
+		public.GET("/category/list", tagHandler.ListCategories)
+		public.GET("/user/profile", followHandler.Profile)
+		public.GET("/user/followers", followHandler.Followers)
+		public.GET("/user/following", followHandler.Following)
+		if d.Hub != nil {
+			public.GET("/post/events", realtimeHandler.PostEvents)
+			// WebSocket 在握手时自行校验 access token，浏览器无法设置请求头时可用 access_token 参数
+			public.GET("/ws", realtimeHandler.Socket)
+		}
 	}
 	protected := r.Group("/api")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(auth), limiter.ByUser(conf.RateLimitProtected))
 	{
-		protected.POST("/register", authHandler.Register)
-		protected.POST("/login", authHandler.Login)
-		protected.POST("/post/add", postHandler.CreatePost)
-		protected.POST("/post/update", postHandler.UpdatePost)
-		protected.POST("/post/delete", postHandler.DeletePost)
+		protected.POST("/logout", authHandler.Logout)
+		protected.POST("/email/resend", limiter.ByUser(conf.RateLimitAuth), authHandler.ResendVerification)
+		protected.POST("/password/change", limiter.ByUser(conf.RateLimitAuth), authHandler.ChangePassword)
+		protected.GET("/login/events", authHandler.LoginEvents)
+		protected.POST("/siwe/link", limiter.ByUser(conf.RateLimitAuth), authHandler.LinkWallet)
+		protected.GET("/mfa/status", authHandler.MFAStatus)
+		protected.POST("/mfa/totp/setup", authHandler.SetupTOTP)
+		protected.POST("/mfa/totp/confirm", limiter.ByUser(conf.RateLimitAuth), authHandler.ConfirmTOTP)
+		protected.POST("/mfa/disable", limiter.ByUser(conf.RateLimitAuth), authHandler.DisableMFA)
+
+		// 未验证邮箱的账号不能发文和评论
+		verified := middleware.RequireVerifiedEmail(auth)
+
+		// 作者本人或版主、管理员可以编辑/删除文章
+		canWrite := middleware.RequireRole(model.RoleAdmin, model.RoleModerator, model.RoleAuthor)
+		postModerator := middleware.OwnerOrRole(postResource, model.RoleAdmin, model.RoleModerator)
+		protected.POST("/post/add", canWrite, verified, postHandler.CreatePost)
+		protected.POST("/post/update", postModerator, postHandler.UpdatePost)
+		protected.POST("/post/delete", postModerator, postHandler.DeletePost)
+		protected.GET("/post/mine", postHandler.MyPosts)
+		if d.Storage != nil {
+			protected.POST("/upload", canWrite, verified, uploadHandler.Upload)
+		}
+		protected.POST("/post/revision/list", postModerator, postHandler.ListRevisions)
+		protected.POST("/post/revision/diff", postModerator, postHandler.DiffRevisions)
+		protected.POST("/post/revision/restore", postModerator, postHandler.RestoreRevision)
+		protected.POST("/post/like", engagementHandler.Like)
+		protected.POST("/post/unlike", engagementHandler.Unlike)
+		protected.POST("/post/bookmark", engagementHandler.Bookmark)
+		protected.POST("/post/unbookmark", engagementHandler.Unbookmark)
+		protected.GET("/post/reaction", engagementHandler.Reaction)
+		protected.GET("/bookmark/list", engagementHandler.ListBookmarks)
+
+		protected.POST("/user/follow", followHandler.Follow)
+		protected.POST("/user/unfollow", followHandler.Unfollow)
+		protected.GET("/feed", followHandler.HomeFeed)
+
+		protected.POST("/category/add", middleware.RequireRole(model.RoleAdmin, model.RoleModerator), tagHandler.CreateCategory)
+
+		commentModerator := middleware.OwnerOrRole(commentResource, model.RoleAdmin, model.RoleModerator)
+		protected.POST("/comment/add", limiter.ByUser(conf.RateLimitComment), verified, commentHandler.CreateComment)
+		protected.POST("/comment/update", commentModerator, commentHandler.UpdateComment)
+		protected.POST("/comment/delete", commentModerator, commentHandler.DeleteComment)
+		protected.POST("/comment/hide", middleware.RequireRole(model.RoleAdmin, model.RoleModerator), commentHandler.HideComment)
 
-		protected.POST("/comment/add", commentHandler.CreateComment)
+		protected.GET("/notification/list", notificationHandler.ListNotifications)
+		protected.GET("/notification/unread", notificationHandler.UnreadCount)
+		protected.POST("/notification/read", notificationHandler.MarkRead)
+		protected.POST("/notification/read-all", notificationHandler.MarkAllRead)
+		protected.GET("/notification/preferences", notificationHandler.GetPreferences)
+		protected.POST("/notification/preferences", notificationHandler.UpdatePreferences)
+	}
+
+	admin := r.Group("/api/admin")
+	admin.Use(middleware.AuthMiddleware(auth), limiter.ByUser(conf.RateLimitProtected), middleware.RequireRole(model.RoleAdmin))
+	{
+		admin.GET("/user/list", userHandler.ListUsers)
+		admin.POST("/user/role", userHandler.UpdateUserRole)
+		admin.POST("/user/delete", userHandler.DeleteUser)
+		admin.POST("/user/mfa/reset", userHandler.ResetUserMFA)
 	}
 
 	log.Println("✅ Routes registered")
 
 }
+
+// RegisterHealth 注册存活、就绪探针与指标导出，不经过鉴权
+func RegisterHealth(r *gin.Engine, h *handler.HealthHandler, reg *metrics.Registry) {
+	r.GET("/healthz", h.Healthz)
+	r.GET("/readyz", h.Readyz)
+	r.GET("/metrics", handler.Metrics(reg))
+}
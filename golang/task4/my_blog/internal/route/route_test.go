@@ -0,0 +1,2044 @@
+package route
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"my_blog/internal/conf"
+	"my_blog/internal/database"
+	"my_blog/internal/handler"
+	"my_blog/internal/mail"
+	"my_blog/internal/metrics"
+	"my_blog/internal/middleware"
+	"my_blog/internal/migrate"
+	"my_blog/internal/model"
+	"my_blog/internal/ratelimit"
+	"my_blog/internal/realtime"
+	"my_blog/internal/repository"
+	"my_blog/internal/search"
+	"my_blog/internal/service"
+	"my_blog/internal/siwe"
+	"my_blog/internal/storage"
+	"my_blog/internal/util"
+)
+
+// testServer 基于内存 SQLite 的完整 HTTP API
+type testServer struct {
+	t     *testing.T
+	r     *gin.Engine
+	db    *gorm.DB
+	mail  *mail.Memory
+	queue *mail.Queue // 后台邮件队列，测试中不启动后台发送，由 sentMail 同步发送
+	hub   *realtime.Hub
+	views *service.ViewCounter
+	files *storage.Local
+	index *search.Memory
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	return newTestServerWith(t, nil)
+}
+
+// newTestServerWith 在注册路由前调用 setup，用于挂载全局中间件，setup 可返回限流器
+func newTestServerWith(t *testing.T, setup func(r *gin.Engine, db *gorm.DB) *middleware.Limiter) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.OpenSQLite(":memory:", &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(db, conf.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	r := gin.New()
+	var limiter *middleware.Limiter
+	if setup != nil {
+		limiter = setup(r, db)
+	}
+	mailer := &mail.Memory{}
+	queue := mail.NewQueue(mailer, 100, time.Second)
+	rt := conf.Defaults().Realtime
+	hub := realtime.NewHub(realtime.Options{ReplayBuffer: rt.ReplayBuffer, ClientBuffer: rt.ClientBuffer})
+	t.Cleanup(hub.Close)
+	views := service.NewViewCounter(repository.New(db))
+	files := &storage.Local{Dir: t.TempDir(), Prefix: "/uploads"}
+	index := search.NewMemory()
+	RegisterRoutes(r, Deps{
+		DB: db, Searcher: index, Limiter: limiter, Mailer: mailer, MailQueue: queue, BaseURL: "http://blog.test",
+		Hub: hub, Realtime: rt, Views: views, Engagement: conf.Defaults().Engagement,
+		Storage: files, Upload: testUploadConfig(),
+	})
+	return &testServer{t: t, r: r, db: db, mail: mailer, queue: queue, hub: hub, views: views, files: files, index: index}
+}
+
+// sentMail 发送队列中的通知邮件，返回全部已发送的邮件
+func (s *testServer) sentMail() []mail.Message {
+	s.t.Helper()
+	if err := s.queue.Flush(context.Background()); err != nil {
+		s.t.Fatal(err)
+	}
+	return s.mail.Messages()
+}
+
+// testUploadConfig 测试用的上传限制，文件上限较小以便覆盖超限的情况
+func testUploadConfig() conf.UploadConfig {
+	cfg := conf.Defaults().Upload
+	cfg.MaxSize = 256 << 10
+	cfg.ThumbnailSize = 100
+	return cfg
+}
+
+// do 发送请求并把响应体解码到 out（可为 nil），返回状态码
+func (s *testServer) do(method, path, token string, body, out interface{}) int {
+	s.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(b)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, req)
+
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decode %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w.Code
+}
+
+// createUser 直接写库创建已验证邮箱的用户，返回该用户的 access token
+func (s *testServer) createUser(username, role string) (*model.User, string) {
+	s.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	now := time.Now()
+	user := &model.User{Username: username, Password: string(hash), Email: username + "@example.com", Role: role, EmailVerifiedAt: &now}
+	if err := s.db.Create(user).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	token, err := util.GenerateToken(user.ID, user.Username, user.Role)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return user, token
+}
+
+type postResp struct {
+	ID          uint
+	Title       string
+	Content     string
+	Excerpt     string
+	ReadingTime int
+	Status      string
+	Tags        []struct{ Name string }
+
+	LikeCount    int64
+	CommentCount int64
+	ViewCount    int64
+}
+
+func expectStatus(t *testing.T, what string, got, want int) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("%s: status %d, expected %d", what, got, want)
+	}
+}
+
+func TestPostAndCommentAPI(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.createUser("alice", model.RoleAuthor)
+	_, bob := s.createUser("bob", model.RoleAuthor)
+	_, mod := s.createUser("mod", model.RoleModerator)
+	_, reader := s.createUser("reader", model.RoleReader)
+
+	// 读者不能发文
+	code := s.do("POST", "/api/post/add", reader, gin.H{"title": "t", "content": "c"}, nil)
+	expectStatus(t, "reader create post", code, http.StatusForbidden)
+
+	var post postResp
+	code = s.do("POST", "/api/post/add", alice, gin.H{
+		"title": "Gin 入门", "content": "# Hello\n\n使用 **gin** 编写服务", "tags": []string{"Go", "gin"},
+	}, &post)
+	expectStatus(t, "create post", code, http.StatusCreated)
+	if post.Excerpt != "Hello 使用 gin 编写服务" || post.ReadingTime != 1 || len(post.Tags) != 2 {
+		t.Errorf("unexpected post %+v", post)
+	}
+
+	var got postResp
+	code = s.do("GET", "/api/post/get?format=html", "", gin.H{"id": post.ID}, &got)
+	expectStatus(t, "get post", code, http.StatusOK)
+	if got.Content != "<h1>Hello</h1>\n<p>使用 <strong>gin</strong> 编写服务</p>\n" {
+		t.Errorf("unexpected html content %q", got.Content)
+	}
+
+	// 非作者不能修改，版主可以
+	code = s.do("POST", "/api/post/update", bob, gin.H{"id": post.ID, "title": "hacked"}, nil)
+	expectStatus(t, "bob update post", code, http.StatusForbidden)
+	code = s.do("POST", "/api/post/update", mod, gin.H{"id": post.ID, "content": "moderated gin"}, &got)
+	expectStatus(t, "moderator update post", code, http.StatusOK)
+
+	var revisions []struct{ Version int }
+	code = s.do("POST", "/api/post/revision/list", alice, gin.H{"id": post.ID}, &revisions)
+	expectStatus(t, "list revisions", code, http.StatusOK)
+	if len(revisions) != 2 || revisions[0].Version != 2 {
+		t.Errorf("unexpected revisions %+v", revisions)
+	}
+	code = s.do("POST", "/api/post/revision/restore", alice, gin.H{"id": post.ID, "version": 1}, &got)
+	expectStatus(t, "restore revision", code, http.StatusOK)
+	if got.Title != "Gin 入门" || got.Content != "# Hello\n\n使用 **gin** 编写服务" {
+		t.Errorf("unexpected restored post %+v", got)
+	}
+
+	// 评论与回复
+	var root, reply struct{ ID uint }
+	code = s.do("POST", "/api/comment/add", reader, gin.H{"post_id": post.ID, "content": "好文"}, &root)
+	expectStatus(t, "add comment", code, http.StatusCreated)
+	code = s.do("POST", "/api/comment/add", alice, gin.H{"post_id": post.ID, "parent_id": root.ID, "content": "谢谢"}, &reply)
+	expectStatus(t, "reply comment", code, http.StatusCreated)
+
+	var tree struct {
+		Comments []struct {
+			ID      uint
+			Content string
+			Replies []struct{ ID uint }
+		} `json:"comments"`
+	}
+	code = s.do("POST", "/api/comment/list", "", gin.H{"post_id": post.ID}, &tree)
+	expectStatus(t, "list comments", code, http.StatusOK)
+	if len(tree.Comments) != 1 || len(tree.Comments[0].Replies) != 1 || tree.Comments[0].Replies[0].ID != reply.ID {
+		t.Errorf("unexpected comment tree %+v", tree)
+	}
+
+	code = s.do("POST", "/api/comment/hide", mod, gin.H{"id": root.ID, "hidden": true}, nil)
+	expectStatus(t, "hide comment", code, http.StatusOK)
+	s.do("POST", "/api/comment/list", "", gin.H{"post_id": post.ID}, &tree)
+	if tree.Comments[0].Content != "" {
+		t.Errorf("expected hidden comment content to be blank, got %q", tree.Comments[0].Content)
+	}
+
+	// 检索与标签
+	var result struct {
+		Hits []search.Hit `json:"hits"`
+	}
+	code = s.do("GET", "/api/search?q=gin", "", nil, &result)
+	expectStatus(t, "search", code, http.StatusOK)
+	if len(result.Hits) == 0 || result.Hits[0].ID != post.ID {
+		t.Errorf("unexpected search hits %+v", result.Hits)
+	}
+
+	var cloud []struct {
+		Name  string
+		Count int64
+	}
+	code = s.do("GET", "/api/tag/cloud", "", nil, &cloud)
+	expectStatus(t, "tag cloud", code, http.StatusOK)
+	if len(cloud) != 2 || cloud[0].Count != 1 {
+		t.Errorf("unexpected tag cloud %+v", cloud)
+	}
+
+	// 删除
+	code = s.do("POST", "/api/post/delete", bob, gin.H{"id": post.ID}, nil)
+	expectStatus(t, "bob delete post", code, http.StatusForbidden)
+	code = s.do("POST", "/api/post/delete", alice, gin.H{"id": post.ID}, nil)
+	expectStatus(t, "delete post", code, http.StatusOK)
+	code = s.do("GET", "/api/post/get", "", gin.H{"id": post.ID}, nil)
+	expectStatus(t, "get deleted post", code, http.StatusNotFound)
+}
+
+func TestListPostsPagination(t *testing.T) {
+	s := newTestServer(t)
+	alice, token := s.createUser("alice", model.RoleAuthor)
+
+	for i := 1; i <= 5; i++ {
+		body := gin.H{"title": fmt.Sprintf("post %d", i), "content": "content", "tags": []string{"go"}}
+		if i == 5 {
+			body["status"] = model.PostStatusDraft
+		}
+		code := s.do("POST", "/api/post/add", token, body, nil)
+		expectStatus(t, "create post", code, http.StatusCreated)
+	}
+
+	var titles []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		var resp struct {
+			Posts      []postResp `json:"posts"`
+			NextCursor string     `json:"next_cursor"`
+			Total      int64      `json:"total"`
+		}
+		path := fmt.Sprintf("/api/post/list?size=2&tag=go&author_id=%d&cursor=%s", alice.ID, cursor)
+		code := s.do("GET", path, "", nil, &resp)
+		expectStatus(t, "list posts", code, http.StatusOK)
+		if resp.Total != 4 {
+			t.Errorf("expected total 4 (draft excluded), got %d", resp.Total)
+		}
+		for _, p := range resp.Posts {
+			titles = append(titles, p.Title)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+
+	expected := []string{"post 4", "post 3", "post 2", "post 1"}
+	if fmt.Sprint(titles) != fmt.Sprint(expected) {
+		t.Errorf("paged titles %v, expected %v", titles, expected)
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s := newTestServer(t)
+	s.createUser("alice", model.RoleAuthor)
+
+	type pair struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	var first pair
+	code := s.do("POST", "/api/login", "", gin.H{"username": "alice", "password": "password"}, &first)
+	expectStatus(t, "login", code, http.StatusOK)
+
+	var second pair
+	code = s.do("POST", "/api/token/refresh", "", gin.H{"refresh_token": first.RefreshToken}, &second)
+	expectStatus(t, "refresh", code, http.StatusOK)
+
+	// 旧 refresh token 被重放：整族吊销，新签发的令牌同样失效
+	code = s.do("POST", "/api/token/refresh", "", gin.H{"refresh_token": first.RefreshToken}, nil)
+	expectStatus(t, "reuse refresh token", code, http.StatusUnauthorized)
+	code = s.do("POST", "/api/token/refresh", "", gin.H{"refresh_token": second.RefreshToken}, nil)
+	expectStatus(t, "refresh after family revoked", code, http.StatusUnauthorized)
+	code = s.do("GET", "/api/post/mine", second.Token, nil, nil)
+	expectStatus(t, "access token after family revoked", code, http.StatusUnauthorized)
+
+	// 退出登录后 access token 立即失效
+	var third pair
+	s.do("POST", "/api/login", "", gin.H{"username": "alice", "password": "password"}, &third)
+	code = s.do("POST", "/api/logout", third.Token, nil, nil)
+	expectStatus(t, "logout", code, http.StatusOK)
+	code = s.do("GET", "/api/post/mine", third.Token, nil, nil)
+	expectStatus(t, "access token after logout", code, http.StatusUnauthorized)
+}
+
+type fakeMigrations struct{ pending int }
+
+func (f fakeMigrations) Pending(context.Context) (int, error) { return f.pending, nil }
+
+func TestHealthEndpoints(t *testing.T) {
+	s := newTestServer(t)
+	health := &handler.HealthHandler{DB: s.db, Migrations: fakeMigrations{}}
+	RegisterHealth(s.r, health, metrics.NewRegistry())
+
+	var resp struct {
+		Status string
+		Checks map[string]string
+	}
+	code := s.do("GET", "/healthz", "", nil, &resp)
+	expectStatus(t, "healthz", code, http.StatusOK)
+
+	code = s.do("GET", "/readyz", "", nil, &resp)
+	expectStatus(t, "readyz", code, http.StatusOK)
+	if resp.Status != "ready" || resp.Checks["database"] != "ok" || resp.Checks["migrations"] != "ok" {
+		t.Errorf("unexpected readiness %+v", resp)
+	}
+
+	health.Migrations = fakeMigrations{pending: 2}
+	code = s.do("GET", "/readyz", "", nil, &resp)
+	expectStatus(t, "readyz with pending migrations", code, http.StatusServiceUnavailable)
+	if resp.Checks["migrations"] != "2 pending" {
+		t.Errorf("unexpected migrations check %q", resp.Checks["migrations"])
+	}
+
+	// 停机期间不再就绪，但仍然存活
+	health.Migrations = fakeMigrations{}
+	health.Drain()
+	code = s.do("GET", "/readyz", "", nil, &resp)
+	expectStatus(t, "readyz while draining", code, http.StatusServiceUnavailable)
+	code = s.do("GET", "/healthz", "", nil, nil)
+	expectStatus(t, "healthz while draining", code, http.StatusOK)
+}
+
+func TestRequestLoggingAndMetrics(t *testing.T) {
+	var logs bytes.Buffer
+	reg := metrics.NewRegistry()
+	s := newTestServerWith(t, func(r *gin.Engine, db *gorm.DB) *middleware.Limiter {
+		if err := db.Use(metrics.NewDB(reg)); err != nil {
+			t.Fatal(err)
+		}
+		r.Use(
+			middleware.RequestID(),
+			middleware.Logger(slog.New(slog.NewJSONHandler(&logs, nil))),
+			middleware.Metrics(metrics.NewHTTP(reg)),
+		)
+		return nil
+	})
+	RegisterHealth(s.r, &handler.HealthHandler{DB: s.db}, reg)
+	alice, token := s.createUser("alice", model.RoleAuthor)
+
+	// 沿用上游传入的请求 ID
+	req := httptest.NewRequest("GET", "/api/post/mine", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(middleware.RequestIDHeader, "upstream-123")
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, req)
+	expectStatus(t, "my posts", w.Code, http.StatusOK)
+	if got := w.Header().Get(middleware.RequestIDHeader); got != "upstream-123" {
+		t.Errorf("expected propagated request id, got %q", got)
+	}
+
+	var entry struct {
+		Msg       string  `json:"msg"`
+		RequestID string  `json:"request_id"`
+		Route     string  `json:"route"`
+		Status    int     `json:"status"`
+		UserID    float64 `json:"user_id"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("decode log %q: %v", logs.String(), err)
+	}
+	if entry.RequestID != "upstream-123" || entry.Route != "/api/post/mine" || entry.Status != 200 || uint(entry.UserID) != alice.ID {
+		t.Errorf("unexpected log entry %+v", entry)
+	}
+
+	// 未携带或携带非法请求 ID 时生成新的
+	req = httptest.NewRequest("GET", "/nope", nil)
+	req.Header.Set(middleware.RequestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	s.r.ServeHTTP(w, req)
+	if got := w.Header().Get(middleware.RequestIDHeader); got == "" || got == "bad id\n" {
+		t.Errorf("expected generated request id, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	s.r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`my_blog_http_requests_total{method="GET",route="/api/post/mine",status="200"} 1`,
+		`my_blog_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`my_blog_http_request_duration_seconds_count{method="GET",route="/api/post/mine"} 1`,
+		`my_blog_db_query_duration_seconds_count{operation="query",table="posts"}`,
+		`my_blog_db_query_duration_seconds_count{operation="create",table="users"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg := conf.RateLimitConfig{
+		Enabled: true,
+		Public:  conf.RateLimitRule{Rate: 0.001, Burst: 2},
+		Comment: conf.RateLimitRule{Rate: 0.001, Burst: 1},
+	}
+	s := newTestServerWith(t, func(*gin.Engine, *gorm.DB) *middleware.Limiter {
+		return &middleware.Limiter{Store: ratelimit.NewMemory(), Config: func() conf.RateLimitConfig { return cfg }}
+	})
+	_, alice := s.createUser("alice", model.RoleAuthor)
+	_, bob := s.createUser("bob", model.RoleAuthor)
+
+	// 公开接口按 IP 计数
+	for i := 0; i < 2; i++ {
+		expectStatus(t, "list posts", s.do("GET", "/api/post/list", "", nil, nil), http.StatusOK)
+	}
+	req := httptest.NewRequest("GET", "/api/post/list", nil)
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, req)
+	expectStatus(t, "list posts over limit", w.Code, http.StatusTooManyRequests)
+	if ra := w.Header().Get("Retry-After"); ra != "1000" {
+		t.Errorf("expected Retry-After 1000, got %q", ra)
+	}
+
+	// 评论按用户计数，互不影响
+	var post struct{ ID uint }
+	expectStatus(t, "create post", s.do("POST", "/api/post/add", alice, gin.H{"title": "t", "content": "c"}, &post), http.StatusCreated)
+	comment := gin.H{"post_id": post.ID, "content": "hi"}
+	expectStatus(t, "alice comment", s.do("POST", "/api/comment/add", alice, comment, nil), http.StatusCreated)
+	expectStatus(t, "alice comment again", s.do("POST", "/api/comment/add", alice, comment, nil), http.StatusTooManyRequests)
+	expectStatus(t, "bob comment", s.do("POST", "/api/comment/add", bob, comment, nil), http.StatusCreated)
+
+	// 关闭后不再限流
+	cfg.Enabled = false
+	expectStatus(t, "alice comment when disabled", s.do("POST", "/api/comment/add", alice, comment, nil), http.StatusCreated)
+}
+
+// mailToken 从最近一封邮件的链接中取出令牌
+func (s *testServer) mailToken() string {
+	s.t.Helper()
+	msgs := s.sentMail()
+	if len(msgs) == 0 {
+		s.t.Fatal("no mail sent")
+	}
+	m := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(msgs[len(msgs)-1].Body)
+	if m == nil {
+		s.t.Fatalf("no token in mail %q", msgs[len(msgs)-1].Body)
+	}
+	token, err := url.QueryUnescape(m[1])
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return token
+}
+
+func TestRegisterAndVerifyEmail(t *testing.T) {
+	s := newTestServer(t)
+
+	register := gin.H{"username": "carol", "email": "Carol@Example.com", "password": "secret1"}
+	code := s.do("POST", "/api/register", "", gin.H{"username": "carol", "password": "secret1"}, nil)
+	expectStatus(t, "register without email", code, http.StatusBadRequest)
+	code = s.do("POST", "/api/register", "", gin.H{"username": "carol", "email": "not-an-email", "password": "secret1"}, nil)
+	expectStatus(t, "register with invalid email", code, http.StatusBadRequest)
+	code = s.do("POST", "/api/register", "", register, nil)
+	expectStatus(t, "register", code, http.StatusCreated)
+	code = s.do("POST", "/api/register", "", gin.H{"username": "carol2", "email": "carol@example.com", "password": "secret1"}, nil)
+	expectStatus(t, "register duplicate email", code, http.StatusConflict)
+
+	msgs := s.mail.Messages()
+	if len(msgs) != 1 || msgs[0].To != "carol@example.com" || !strings.Contains(msgs[0].Body, "http://blog.test/api/email/verify?token=") {
+		t.Fatalf("unexpected mails %+v", msgs)
+	}
+
+	var pair struct {
+		Token string `json:"token"`
+	}
+	code = s.do("POST", "/api/login", "", gin.H{"username": "carol", "password": "secret1"}, &pair)
+	expectStatus(t, "login", code, http.StatusOK)
+
+	// 未验证邮箱不能发文和评论
+	code = s.do("POST", "/api/post/add", pair.Token, gin.H{"title": "t", "content": "c"}, nil)
+	expectStatus(t, "unverified create post", code, http.StatusForbidden)
+
+	// 重新发送后旧令牌依然有效，但每个令牌只能使用一次
+	first := s.mailToken()
+	code = s.do("POST", "/api/email/resend", pair.Token, nil, nil)
+	expectStatus(t, "resend", code, http.StatusOK)
+	second := s.mailToken()
+
+	code = s.do("POST", "/api/email/verify", "", gin.H{"token": pair.Token}, nil)
+	expectStatus(t, "verify with access token", code, http.StatusBadRequest)
+	code = s.do("GET", "/api/email/verify?token="+url.QueryEscape(first), "", nil, nil)
+	expectStatus(t, "verify", code, http.StatusOK)
+	code = s.do("GET", "/api/email/verify?token="+url.QueryEscape(first), "", nil, nil)
+	expectStatus(t, "verify reused token", code, http.StatusBadRequest)
+
+	code = s.do("POST", "/api/post/add", pair.Token, gin.H{"title": "t", "content": "c"}, nil)
+	expectStatus(t, "verified create post", code, http.StatusCreated)
+	code = s.do("POST", "/api/email/resend", pair.Token, nil, nil)
+	expectStatus(t, "resend after verified", code, http.StatusConflict)
+
+	// 验证令牌不能当作 access token 使用
+	code = s.do("GET", "/api/post/mine", second, nil, nil)
+	expectStatus(t, "verification token as access token", code, http.StatusUnauthorized)
+}
+
+func TestChangeAndResetPassword(t *testing.T) {
+	s := newTestServer(t)
+	s.createUser("dave", model.RoleAuthor)
+
+	login := func(password string) (int, string, string) {
+		var pair struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		code := s.do("POST", "/api/login", "", gin.H{"username": "dave", "password": password}, &pair)
+		return code, pair.Token, pair.RefreshToken
+	}
+	_, access, _ := login("password")
+	_, _, otherRefresh := login("password")
+
+	code := s.do("POST", "/api/password/change", access, gin.H{"old_password": "wrong", "new_password": "newpass"}, nil)
+	expectStatus(t, "change with wrong old password", code, http.StatusBadRequest)
+	code = s.do("POST", "/api/password/change", access, gin.H{"old_password": "password", "new_password": "newpass"}, nil)
+	expectStatus(t, "change password", code, http.StatusOK)
+
+	// 当前会话保留，其余会话下线
+	code = s.do("GET", "/api/post/mine", access, nil, nil)
+	expectStatus(t, "current session after change", code, http.StatusOK)
+	code = s.do("POST", "/api/token/refresh", "", gin.H{"refresh_token": otherRefresh}, nil)
+	expectStatus(t, "other session after change", code, http.StatusUnauthorized)
+	if code, _, _ = login("password"); code != http.StatusUnauthorized {
+		t.Errorf("old password still works: %d", code)
+	}
+
+	// 未注册的邮箱返回相同结果且不发信
+	code = s.do("POST", "/api/password/forgot", "", gin.H{"email": "nobody@example.com"}, nil)
+	expectStatus(t, "forgot unknown email", code, http.StatusOK)
+	if n := len(s.mail.Messages()); n != 0 {
+		t.Fatalf("expected no mail, got %d", n)
+	}
+	code = s.do("POST", "/api/password/forgot", "", gin.H{"email": "DAVE@example.com"}, nil)
+	expectStatus(t, "forgot", code, http.StatusOK)
+	// 重置邮件在后台发送，请求返回时尚未发出
+	if n := len(s.mail.Messages()); n != 0 {
+		t.Fatalf("expected reset mail to be queued, got %d sent", n)
+	}
+	token := s.mailToken()
+
+	var stored model.PasswordResetToken
+	if err := s.db.Where("user_id = ?", 1).First(&stored).Error; err != nil || stored.TokenHash == token {
+		t.Fatalf("reset token must be stored hashed: %+v %v", stored, err)
+	}
+
+	code = s.do("POST", "/api/password/reset", "", gin.H{"token": "bogus", "new_password": "resetpw"}, nil)
+	expectStatus(t, "reset with bogus token", code, http.StatusBadRequest)
+	code = s.do("POST", "/api/password/reset", "", gin.H{"token": token, "new_password": "resetpw"}, nil)
+	expectStatus(t, "reset", code, http.StatusOK)
+	code = s.do("POST", "/api/password/reset", "", gin.H{"token": token, "new_password": "again1"}, nil)
+	expectStatus(t, "reset reused token", code, http.StatusBadRequest)
+
+	// 重置后所有会话下线，新密码可登录
+	code = s.do("GET", "/api/post/mine", access, nil, nil)
+	expectStatus(t, "session after reset", code, http.StatusUnauthorized)
+	if code, _, _ = login("resetpw"); code != http.StatusOK {
+		t.Errorf("login with new password: %d", code)
+	}
+
+	// 过期的令牌不可用
+	s.do("POST", "/api/password/forgot", "", gin.H{"email": "dave@example.com"}, nil)
+	token = s.mailToken()
+	s.db.Model(&model.PasswordResetToken{}).Where("used_at IS NULL").Update("expires_at", time.Now().Add(-time.Minute))
+	code = s.do("POST", "/api/password/reset", "", gin.H{"token": token, "new_password": "again1"}, nil)
+	expectStatus(t, "reset with expired token", code, http.StatusBadRequest)
+}
+
+func TestLoginLockoutAndAudit(t *testing.T) {
+	s := newTestServer(t)
+	user, _ := s.createUser("erin", model.RoleAuthor)
+	max := conf.Defaults().Security.MaxFailedLogins
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(gin.H{"username": "erin", "password": password})
+		req := httptest.NewRequest("POST", "/api/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "lockout-test")
+		w := httptest.NewRecorder()
+		s.r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < max; i++ {
+		expectStatus(t, "bad password", login("wrong").Code, http.StatusUnauthorized)
+	}
+	// 锁定期间正确密码也无法登录
+	w := login("password")
+	expectStatus(t, "login while locked", w.Code, http.StatusLocked)
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+
+	// 锁定到期后再次失败，锁定时长翻倍
+	s.db.Model(&model.User{}).Where("id = ?", user.ID).Update("locked_until", time.Now().Add(-time.Second))
+	expectStatus(t, "bad password after lockout", login("wrong").Code, http.StatusUnauthorized)
+	var locked model.User
+	s.db.First(&locked, user.ID)
+	if locked.FailedLogins != max+1 || locked.LockedUntil == nil ||
+		time.Until(*locked.LockedUntil) <= conf.Defaults().Security.LockoutDuration {
+		t.Fatalf("expected doubled lockout, got %d %v", locked.FailedLogins, locked.LockedUntil)
+	}
+
+	s.db.Model(&model.User{}).Where("id = ?", user.ID).Update("locked_until", time.Now().Add(-time.Second))
+	w = login("password")
+	expectStatus(t, "login after lockout", w.Code, http.StatusOK)
+	var unlocked model.User
+	s.db.First(&unlocked, user.ID)
+	if unlocked.FailedLogins != 0 || unlocked.LockedUntil != nil {
+		t.Errorf("successful login should reset lockout: %d %v", unlocked.FailedLogins, unlocked.LockedUntil)
+	}
+
+	var pair struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &pair)
+	var page struct {
+		Events []struct {
+			IP        string `json:"ip"`
+			UserAgent string `json:"user_agent"`
+			Success   bool   `json:"success"`
+			Reason    string `json:"reason"`
+		} `json:"events"`
+		NextCursor string `json:"next_cursor"`
+	}
+	code := s.do("GET", "/api/login/events?size=2", pair.Token, nil, &page)
+	expectStatus(t, "login events", code, http.StatusOK)
+	if len(page.Events) != 2 || !page.Events[0].Success || page.Events[1].Reason != model.LoginFailBadPassword ||
+		page.Events[0].UserAgent != "lockout-test" || page.Events[0].IP == "" || page.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	total := len(page.Events)
+	reasons := map[string]int{}
+	for page.NextCursor != "" {
+		cursor := page.NextCursor
+		page.NextCursor = ""
+		s.do("GET", "/api/login/events?size=2&cursor="+url.QueryEscape(cursor), pair.Token, nil, &page)
+		total += len(page.Events)
+		for _, e := range page.Events {
+			reasons[e.Reason]++
+		}
+	}
+	// max 次失败 + 1 次锁定 + 1 次失败 + 1 次成功
+	if total != max+3 || reasons[model.LoginFailLocked] != 1 {
+		t.Errorf("unexpected events total=%d reasons=%v", total, reasons)
+	}
+}
+
+func TestTOTPTwoFactorLogin(t *testing.T) {
+	s := newTestServer(t)
+	s.createUser("frank", model.RoleAuthor)
+	_, adminToken := s.createUser("root", model.RoleAdmin)
+
+	type loginResult struct {
+		Token       string `json:"token"`
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+	}
+	login := func() loginResult {
+		var res loginResult
+		code := s.do("POST", "/api/login", "", gin.H{"username": "frank", "password": "password"}, &res)
+		expectStatus(t, "login", code, http.StatusOK)
+		return res
+	}
+	access := login().Token
+
+	var setup struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+	code := s.do("POST", "/api/mfa/totp/setup", access, nil, &setup)
+	expectStatus(t, "totp setup", code, http.StatusOK)
+	if setup.Secret == "" || !strings.HasPrefix(setup.OTPAuthURI, "otpauth://totp/my_blog:frank?") {
+		t.Fatalf("unexpected setup %+v", setup)
+	}
+
+	now := time.Now()
+	current, _ := util.TOTPCode(setup.Secret, now)
+	wrong := fmt.Sprintf("%06d", (mustAtoi(t, current)+1)%1000000)
+	code = s.do("POST", "/api/mfa/totp/confirm", access, gin.H{"code": wrong}, nil)
+	expectStatus(t, "confirm with wrong code", code, http.StatusBadRequest)
+	var confirm struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	code = s.do("POST", "/api/mfa/totp/confirm", access, gin.H{"code": current}, &confirm)
+	expectStatus(t, "confirm", code, http.StatusOK)
+	if len(confirm.RecoveryCodes) != 10 {
+		t.Fatalf("expected 10 recovery codes, got %v", confirm.RecoveryCodes)
+	}
+	var stored model.RecoveryCode
+	if s.db.Where("code_hash = ?", confirm.RecoveryCodes[0]).First(&stored).Error == nil {
+		t.Fatal("recovery codes must be stored hashed")
+	}
+
+	// 第一步只拿到 mfa_token，不能当作 access token 使用
+	pending := login()
+	if !pending.MFARequired || pending.MFAToken == "" || pending.Token != "" {
+		t.Fatalf("expected mfa challenge, got %+v", pending)
+	}
+	code = s.do("GET", "/api/post/mine", pending.MFAToken, nil, nil)
+	expectStatus(t, "mfa token as access token", code, http.StatusUnauthorized)
+
+	code = s.do("POST", "/api/login/mfa", "", gin.H{"mfa_token": pending.MFAToken, "code": wrong}, nil)
+	expectStatus(t, "mfa wrong code", code, http.StatusUnauthorized)
+	code = s.do("POST", "/api/login/mfa", "", gin.H{"mfa_token": pending.MFAToken, "code": current}, nil)
+	expectStatus(t, "mfa replayed code", code, http.StatusUnauthorized)
+	next, _ := util.TOTPCode(setup.Secret, now.Add(util.TOTPPeriod))
+	var pair loginResult
+	code = s.do("POST", "/api/login/mfa", "", gin.H{"mfa_token": pending.MFAToken, "code": next}, &pair)
+	expectStatus(t, "mfa login", code, http.StatusOK)
+	if pair.Token == "" {
+		t.Fatal("expected access token after mfa")
+	}
+	code = s.do("POST", "/api/login/mfa", "", gin.H{"mfa_token": pending.MFAToken, "code": next}, nil)
+	expectStatus(t, "mfa token reuse", code, http.StatusUnauthorized)
+
+	// 恢复码忽略大小写，只能使用一次
+	recovery := strings.ToUpper(confirm.RecoveryCodes[0])
+	code = s.do("POST", "/api/login/mfa", "", gin.H{"mfa_token": login().MFAToken, "recovery_code": recovery}, nil)
+	expectStatus(t, "mfa recovery code", code, http.StatusOK)
+	code = s.do("POST", "/api/login/mfa", "", gin.H{"mfa_token": login().MFAToken, "recovery_code": recovery}, nil)
+	expectStatus(t, "mfa reused recovery code", code, http.StatusUnauthorized)
+
+	var status struct {
+		Enabled   bool  `json:"enabled"`
+		Remaining int64 `json:"recovery_codes_remaining"`
+	}
+	s.do("GET", "/api/mfa/status", pair.Token, nil, &status)
+	if !status.Enabled || status.Remaining != 9 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// 管理员重置后恢复为仅密码登录
+	code = s.do("POST", "/api/admin/user/mfa/reset", access, gin.H{"id": 1}, nil)
+	expectStatus(t, "non-admin mfa reset", code, http.StatusForbidden)
+	var view struct {
+		MFAEnabled bool `json:"mfa_enabled"`
+	}
+	code = s.do("POST", "/api/admin/user/mfa/reset", adminToken, gin.H{"id": 1}, &view)
+	expectStatus(t, "admin mfa reset", code, http.StatusOK)
+	if view.MFAEnabled {
+		t.Error("mfa should be disabled after reset")
+	}
+	if res := login(); res.MFARequired || res.Token == "" {
+		t.Errorf("expected direct login after reset, got %+v", res)
+	}
+}
+
+func mustAtoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// siweSign 生成并签名一条 EIP-4361 消息，nonce 来自服务端
+func (s *testServer) siweSign(key *ecdsa.PrivateKey, domain string) gin.H {
+	s.t.Helper()
+	var n struct {
+		Nonce string `json:"nonce"`
+	}
+	code := s.do("GET", "/api/siwe/nonce", "", nil, &n)
+	expectStatus(s.t, "siwe nonce", code, http.StatusOK)
+
+	msg := &siwe.Message{
+		Scheme:    "http",
+		Domain:    domain,
+		Address:   crypto.PubkeyToAddress(key.PublicKey),
+		Statement: "Sign in to my_blog",
+		URI:       "http://" + domain,
+		Version:   "1",
+		ChainID:   1,
+		Nonce:     n.Nonce,
+		IssuedAt:  time.Now(),
+	}
+	text := msg.String()
+	sig, err := crypto.Sign(accounts.TextHash([]byte(text)), key)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return gin.H{"message": text, "signature": hexutil.Encode(sig)}
+}
+
+func TestSIWELogin(t *testing.T) {
+	s := newTestServer(t)
+	domain := conf.Defaults().SIWE.Domain
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	var pair struct {
+		Token string `json:"token"`
+	}
+	body := s.siweSign(key, domain)
+	code := s.do("POST", "/api/siwe/verify", "", body, &pair)
+	expectStatus(t, "siwe login", code, http.StatusOK)
+	code = s.do("POST", "/api/siwe/verify", "", body, nil)
+	expectStatus(t, "siwe nonce reuse", code, http.StatusUnauthorized)
+
+	var user model.User
+	if err := s.db.Where("eth_address = ?", address).First(&user).Error; err != nil || user.Username != address || !user.WalletOnly() {
+		t.Fatalf("expected wallet user, got %+v %v", user, err)
+	}
+	// 钱包账号以签名证明身份，可以直接发文
+	code = s.do("POST", "/api/post/add", pair.Token, gin.H{"title": "t", "content": "c"}, nil)
+	expectStatus(t, "wallet user create post", code, http.StatusCreated)
+
+	// 再次登录使用同一账号
+	code = s.do("POST", "/api/siwe/verify", "", s.siweSign(key, domain), nil)
+	expectStatus(t, "siwe second login", code, http.StatusOK)
+	var count int64
+	s.db.Model(&model.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("expected 1 user, got %d", count)
+	}
+
+	code = s.do("POST", "/api/siwe/verify", "", s.siweSign(key, "evil.example"), nil)
+	expectStatus(t, "siwe wrong domain", code, http.StatusUnauthorized)
+	forged := s.siweSign(key, domain)
+	other, _ := crypto.GenerateKey()
+	forged["signature"] = s.siweSign(other, domain)["signature"]
+	code = s.do("POST", "/api/siwe/verify", "", forged, nil)
+	expectStatus(t, "siwe forged signature", code, http.StatusUnauthorized)
+
+	// 已有账号绑定地址后可用钱包登录
+	alice, aliceToken := s.createUser("alice", model.RoleAuthor)
+	code = s.do("POST", "/api/siwe/link", aliceToken, s.siweSign(key, domain), nil)
+	expectStatus(t, "link address owned by another user", code, http.StatusConflict)
+	code = s.do("POST", "/api/siwe/link", aliceToken, s.siweSign(other, domain), nil)
+	expectStatus(t, "link wallet", code, http.StatusOK)
+
+	var claims struct {
+		Token string `json:"token"`
+	}
+	code = s.do("POST", "/api/siwe/verify", "", s.siweSign(other, domain), &claims)
+	expectStatus(t, "siwe login linked account", code, http.StatusOK)
+	parsed, err := util.ParseToken(claims.Token)
+	if err != nil || parsed.UserID != alice.ID {
+		t.Errorf("expected token for alice, got %+v %v", parsed, err)
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	s := newTestServer(t)
+
+	type envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Fields  []struct {
+				Field   string `json:"field"`
+				Rule    string `json:"rule"`
+				Message string `json:"message"`
+			} `json:"fields"`
+		} `json:"error"`
+	}
+	request := func(method, path, lang string, body interface{}) (int, envelope) {
+		t.Helper()
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		s.r.ServeHTTP(w, req)
+		var env envelope
+		if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+			t.Fatalf("decode %q: %v", w.Body.String(), err)
+		}
+		return w.Code, env
+	}
+
+	// 参数校验错误列出每个字段，消息按 Accept-Language 翻译
+	code, env := request("POST", "/api/register", "en-US,en;q=0.9", gin.H{"username": "u", "email": "bad", "password": "123"})
+	expectStatus(t, "register invalid", code, http.StatusBadRequest)
+	if env.Error.Code != "request.invalid" || len(env.Error.Fields) != 2 {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	if f := env.Error.Fields[0]; f.Field != "email" || f.Rule != "email" || f.Message != "email must be a valid email address" {
+		t.Errorf("unexpected field error %+v", f)
+	}
+	if f := env.Error.Fields[1]; f.Field != "password" || f.Rule != "min" || f.Message != "password must be at least 6" {
+		t.Errorf("unexpected field error %+v", f)
+	}
+
+	code, env = request("GET", "/api/post/get", "zh-CN", gin.H{"id": 42})
+	expectStatus(t, "get missing post", code, http.StatusNotFound)
+	if env.Error.Code != "post.not_found" || env.Error.Message != "文章不存在" {
+		t.Errorf("unexpected envelope %+v", env)
+	}
+	code, env = request("GET", "/api/post/get", "en", gin.H{"id": 42})
+	if env.Error.Message != "Post not found" {
+		t.Errorf("expected english message, got %+v", env)
+	}
+
+	code, env = request("GET", "/api/post/mine", "", nil)
+	expectStatus(t, "missing auth header", code, http.StatusUnauthorized)
+	if env.Error.Code != "auth.header_missing" {
+		t.Errorf("unexpected envelope %+v", env)
+	}
+}
+
+// sseFrame 一条 SSE 消息
+type sseFrame struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// readSSE 读取下一条带 event 的消息，跳过心跳注释与 retry 设置
+func readSSE(t *testing.T, r *bufio.Reader) sseFrame {
+	t.Helper()
+	var f sseFrame
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			if f.Event != "" {
+				return f
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			f.ID = value
+		case "event":
+			f.Event = value
+		case "data":
+			f.Data = value
+		}
+	}
+}
+
+func TestRealtimeSSE(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.createUser("alice", model.RoleAuthor)
+	srv := httptest.NewServer(s.r)
+	defer srv.Close()
+
+	var post postResp
+	s.do("POST", "/api/post/add", alice, gin.H{"title": "t", "content": "c"}, &post)
+	var draft postResp
+	s.do("POST", "/api/post/add", alice, gin.H{"title": "d", "content": "c", "status": model.PostStatusDraft}, &draft)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	open := func(id uint, lastEventID string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/post/events?id=%d", srv.URL, id), nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// 草稿不可订阅
+	resp := open(draft.ID, "")
+	resp.Body.Close()
+	expectStatus(t, "draft events", resp.StatusCode, http.StatusNotFound)
+
+	resp = open(post.ID, "")
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	stream := bufio.NewReader(resp.Body)
+
+	var comment struct{ ID uint }
+	s.do("POST", "/api/comment/add", alice, gin.H{"post_id": post.ID, "content": "first"}, &comment)
+	f := readSSE(t, stream)
+	var ev struct {
+		ID    uint64
+		Topic string
+		Type  string
+		Data  struct {
+			ID      uint
+			PostID  uint `json:"post_id"`
+			Content string
+		}
+	}
+	if err := json.Unmarshal([]byte(f.Data), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if f.Event != "comment.created" || ev.Topic != fmt.Sprintf("post:%d", post.ID) ||
+		ev.Data.ID != comment.ID || ev.Data.Content != "first" || f.ID != strconv.FormatUint(ev.ID, 10) {
+		t.Fatalf("unexpected event %+v %+v", f, ev)
+	}
+	if strings.Contains(f.Data, "password") {
+		t.Error("event must not leak user fields")
+	}
+	resp.Body.Close()
+
+	// 断线期间的事件在重连时按 Last-Event-ID 补发
+	s.do("POST", "/api/comment/update", alice, gin.H{"id": comment.ID, "content": "edited"}, nil)
+	s.do("POST", "/api/comment/delete", alice, gin.H{"id": comment.ID}, nil)
+	resp = open(post.ID, f.ID)
+	stream = bufio.NewReader(resp.Body)
+	if f := readSSE(t, stream); f.Event != "comment.updated" {
+		t.Fatalf("expected replayed update, got %+v", f)
+	}
+	if f := readSSE(t, stream); f.Event != "comment.deleted" {
+		t.Fatalf("expected replayed delete, got %+v", f)
+	}
+	resp.Body.Close()
+
+	// 来自上一次运行的 ID 无法补发
+	resp = open(post.ID, "1")
+	stream = bufio.NewReader(resp.Body)
+	if f := readSSE(t, stream); f.Event != "reset" {
+		t.Fatalf("expected reset, got %+v", f)
+	}
+
+	// 文章被删除后推送事件并结束
+	s.do("POST", "/api/post/delete", alice, gin.H{"id": post.ID}, nil)
+	if f := readSSE(t, stream); f.Event != "post.deleted" {
+		t.Fatalf("expected post.deleted, got %+v", f)
+	}
+	if _, err := stream.ReadString('\n'); err == nil {
+		t.Error("expected stream to end after post deletion")
+	}
+	resp.Body.Close()
+}
+
+func TestRealtimeWebSocket(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.createUser("alice", model.RoleAuthor)
+	bob, bobToken := s.createUser("bob", model.RoleReader)
+	srv := httptest.NewServer(s.r)
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/ws"
+
+	var post postResp
+	s.do("POST", "/api/post/add", alice, gin.H{"title": "t", "content": "c"}, &post)
+
+	// 握手时必须携带有效的 access token
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %v", err)
+	}
+	_, resp, err = websocket.DefaultDialer.Dial(wsURL, http.Header{"Origin": {"http://evil.example"}, "Authorization": {"Bearer " + bobToken}})
+	if err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected cross-origin handshake to be rejected, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?access_token="+url.QueryEscape(bobToken), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	type message struct {
+		ID     uint64
+		Type   string
+		Topic  string
+		Topics []string
+		Error  struct{ Code string }
+	}
+	exchange := func(req gin.H) message {
+		t.Helper()
+		if err := conn.WriteJSON(req); err != nil {
+			t.Fatal(err)
+		}
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	for _, c := range []struct {
+		req  gin.H
+		code string
+	}{
+		{gin.H{"action": "watch", "topics": []string{"posts"}}, "request.invalid"},
+		{gin.H{"action": "subscribe", "topics": []string{"comments"}}, "realtime.topic_invalid"},
+		{gin.H{"action": "subscribe", "topics": []string{"user:" + strconv.Itoa(int(bob.ID)+1)}}, "realtime.topic_forbidden"},
+		{gin.H{"action": "subscribe", "topics": []string{"post:999"}}, "post.not_found"},
+	} {
+		if msg := exchange(c.req); msg.Type != "error" || msg.Error.Code != c.code {
+			t.Errorf("%v: unexpected reply %+v", c.req, msg)
+		}
+	}
+
+	topics := []string{"posts", fmt.Sprintf("post:%d", post.ID), fmt.Sprintf("user:%d", bob.ID)}
+	if msg := exchange(gin.H{"action": "subscribe", "topics": topics}); msg.Type != "subscribed" || len(msg.Topics) != 3 {
+		t.Fatalf("unexpected subscribe reply %+v", msg)
+	}
+
+	s.do("POST", "/api/comment/add", alice, gin.H{"post_id": post.ID, "content": "hi"}, nil)
+	s.do("POST", "/api/post/update", alice, gin.H{"id": post.ID, "title": "t2"}, nil)
+	var got []string
+	for i := 0; i < 3; i++ {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, msg.Topic+" "+msg.Type)
+	}
+	want := []string{
+		fmt.Sprintf("post:%d comment.created", post.ID),
+		"posts post.updated",
+		fmt.Sprintf("post:%d post.updated", post.ID),
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected events %v", got)
+	}
+
+	if msg := exchange(gin.H{"action": "unsubscribe", "topics": []string{"posts"}}); msg.Type != "unsubscribed" {
+		t.Fatalf("unexpected unsubscribe reply %+v", msg)
+	}
+
+	// 停机时关闭连接
+	s.hub.Close()
+	var msg message
+	if err := conn.ReadJSON(&msg); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("expected going away close, got %v %+v", err, msg)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	s := newTestServer(t)
+	alice, aliceToken := s.createUser("alice", model.RoleAuthor)
+	_, bob := s.createUser("bob", model.RoleReader)
+	_, carol := s.createUser("carol", model.RoleReader)
+
+	type notification struct {
+		ID        uint
+		Type      string
+		Actor     string
+		PostID    uint `json:"post_id"`
+		CommentID uint `json:"comment_id"`
+		Excerpt   string
+		ReadAt    *time.Time `json:"read_at"`
+	}
+	type unread struct {
+		Total  int64
+		ByType map[string]int64 `json:"by_type"`
+	}
+	list := func(token string) []notification {
+		t.Helper()
+		var page struct{ Notifications []notification }
+		expectStatus(t, "list notifications", s.do("GET", "/api/notification/list", token, nil, &page), http.StatusOK)
+		return page.Notifications
+	}
+
+	// carol 关闭 @ 的邮件提醒，其余保持默认
+	var prefs struct {
+		Preferences []struct {
+			Type  string
+			InApp bool `json:"in_app"`
+			Email bool
+		}
+	}
+	code := s.do("POST", "/api/notification/preferences", carol, gin.H{
+		"preferences": []gin.H{{"type": "mention", "email": false}},
+	}, &prefs)
+	expectStatus(t, "update preferences", code, http.StatusOK)
+	if len(prefs.Preferences) != 3 || prefs.Preferences[2].Type != "mention" || prefs.Preferences[2].Email || !prefs.Preferences[2].InApp {
+		t.Fatalf("unexpected preferences %+v", prefs)
+	}
+	code = s.do("POST", "/api/notification/preferences", carol, gin.H{"preferences": []gin.H{{"type": "like"}}}, nil)
+	expectStatus(t, "invalid preference type", code, http.StatusBadRequest)
+
+	var post postResp
+	s.do("POST", "/api/post/add", aliceToken, gin.H{"title": "Hello", "content": "c"}, &post)
+
+	// 文章收到评论：通知作者并发送邮件
+	var comment struct{ ID uint }
+	s.do("POST", "/api/comment/add", bob, gin.H{"post_id": post.ID, "content": "写得好"}, &comment)
+	got := list(aliceToken)
+	if len(got) != 1 || got[0].Type != "comment" || got[0].Actor != "bob" || got[0].CommentID != comment.ID || got[0].Excerpt != "写得好" {
+		t.Fatalf("unexpected notifications for alice %+v", got)
+	}
+	// 评论请求返回时邮件只是入队
+	if n := len(s.mail.Messages()); n != 0 {
+		t.Fatalf("expected notification mail to be queued, got %d sent", n)
+	}
+	msgs := s.sentMail()
+	if len(msgs) != 1 || msgs[0].To != alice.Email || !strings.Contains(msgs[0].Subject, "Hello") {
+		t.Fatalf("unexpected mail %+v", msgs)
+	}
+
+	// 作者回复并 @ 两人：bob 只收到回复通知，carol 收到 @ 通知但不发邮件，作者本人不通知
+	s.do("POST", "/api/comment/add", aliceToken, gin.H{"post_id": post.ID, "parent_id": comment.ID, "content": "谢谢 @bob，也请 @carol 看看 @alice"}, nil)
+	if got := list(bob); len(got) != 1 || got[0].Type != "reply" || got[0].Actor != "alice" {
+		t.Fatalf("unexpected notifications for bob %+v", got)
+	}
+	carolList := list(carol)
+	if len(carolList) != 1 || carolList[0].Type != "mention" {
+		t.Fatalf("unexpected notifications for carol %+v", carolList)
+	}
+	if got := list(aliceToken); len(got) != 1 {
+		t.Errorf("author should not be notified of own comment, got %+v", got)
+	}
+	if msgs := s.sentMail(); len(msgs) != 2 || !strings.Contains(msgs[1].Subject, "alice 回复了你的评论") {
+		t.Fatalf("expected only bob to be mailed, got %+v", msgs)
+	}
+
+	// 未读数与标记已读，别人的通知不受影响
+	var count unread
+	expectStatus(t, "unread", s.do("GET", "/api/notification/unread", carol, nil, &count), http.StatusOK)
+	if count.Total != 1 || count.ByType["mention"] != 1 || count.ByType["reply"] != 0 {
+		t.Errorf("unexpected unread %+v", count)
+	}
+	var res struct {
+		Updated int64
+		Unread  unread
+	}
+	s.do("POST", "/api/notification/read", bob, gin.H{"ids": []uint{carolList[0].ID}}, &res)
+	if res.Updated != 0 {
+		t.Errorf("marking another user's notification should be ignored, got %+v", res)
+	}
+	s.do("POST", "/api/notification/read", carol, gin.H{"ids": []uint{carolList[0].ID}}, &res)
+	if res.Updated != 1 || res.Unread.Total != 0 {
+		t.Errorf("unexpected mark read result %+v", res)
+	}
+	var unreadPage struct{ Notifications []notification }
+	s.do("GET", "/api/notification/list?unread=true", carol, nil, &unreadPage)
+	if len(unreadPage.Notifications) != 0 {
+		t.Errorf("expected no unread notifications, got %+v", unreadPage)
+	}
+
+	s.do("POST", "/api/notification/read-all", aliceToken, nil, &res)
+	if res.Updated != 1 || res.Unread.Total != 0 {
+		t.Errorf("unexpected read-all result %+v", res)
+	}
+	if got := list(aliceToken); got[0].ReadAt == nil {
+		t.Error("expected notification to be marked read")
+	}
+	code = s.do("POST", "/api/notification/read", bob, gin.H{"ids": []uint{}}, nil)
+	expectStatus(t, "empty ids", code, http.StatusBadRequest)
+}
+
+func TestEngagement(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.createUser("alice", model.RoleAuthor)
+	_, bob := s.createUser("bob", model.RoleReader)
+	_, carol := s.createUser("carol", model.RoleReader)
+
+	ids := make([]uint, 3)
+	for i := range ids {
+		var post postResp
+		code := s.do("POST", "/api/post/add", alice, gin.H{"title": fmt.Sprintf("post %d", i+1), "content": "c"}, &post)
+		expectStatus(t, "create post", code, http.StatusCreated)
+		ids[i] = post.ID
+	}
+	get := func(id uint) postResp {
+		t.Helper()
+		var post postResp
+		expectStatus(t, "get post", s.do("GET", "/api/post/get", "", gin.H{"id": id}, &post), http.StatusOK)
+		return post
+	}
+	type likeResult struct {
+		Liked     bool
+		LikeCount int64 `json:"like_count"`
+	}
+	like := func(token, action string, id uint) likeResult {
+		t.Helper()
+		var res likeResult
+		expectStatus(t, action, s.do("POST", "/api/post/"+action, token, gin.H{"id": id}, &res), http.StatusOK)
+		return res
+	}
+	list := func(query string) []string {
+		t.Helper()
+		var titles []string
+		for cursor := ""; ; {
+			var page struct {
+				Posts      []postResp
+				NextCursor string `json:"next_cursor"`
+			}
+			expectStatus(t, "list posts", s.do("GET", "/api/post/list?size=2&"+query+"&cursor="+cursor, "", nil, &page), http.StatusOK)
+			for _, p := range page.Posts {
+				titles = append(titles, p.Title)
+			}
+			if cursor = page.NextCursor; cursor == "" {
+				return titles
+			}
+		}
+	}
+
+	// 重复点赞不重复计数，没赞过时取消点赞无影响
+	if res := like(bob, "like", ids[0]); !res.Liked || res.LikeCount != 1 {
+		t.Errorf("unexpected like result %+v", res)
+	}
+	if res := like(bob, "like", ids[0]); !res.Liked || res.LikeCount != 1 {
+		t.Errorf("repeated like should be idempotent, got %+v", res)
+	}
+	like(carol, "like", ids[0])
+	like(carol, "like", ids[1])
+	if res := like(bob, "unlike", ids[1]); res.Liked || res.LikeCount != 1 {
+		t.Errorf("unlike without like should not change count, got %+v", res)
+	}
+	if got := list("sort=most_liked"); fmt.Sprint(got) != "[post 1 post 2 post 3]" {
+		t.Errorf("most liked order %v", got)
+	}
+
+	// 编辑文章不会覆盖计数
+	s.do("POST", "/api/post/update", alice, gin.H{"id": ids[0], "title": "post 1 edited"}, nil)
+	if post := get(ids[0]); post.LikeCount != 2 || post.Title != "post 1 edited" {
+		t.Errorf("unexpected post after update %+v", post)
+	}
+	s.do("POST", "/api/post/update", alice, gin.H{"id": ids[0], "title": "post 1"}, nil)
+	if res := like(carol, "unlike", ids[0]); res.Liked || res.LikeCount != 1 {
+		t.Errorf("unexpected unlike result %+v", res)
+	}
+
+	// 评论数随评论增删
+	var root struct{ ID uint }
+	s.do("POST", "/api/comment/add", bob, gin.H{"post_id": ids[2], "content": "first"}, &root)
+	s.do("POST", "/api/comment/add", carol, gin.H{"post_id": ids[2], "parent_id": root.ID, "content": "reply"}, nil)
+	s.do("POST", "/api/comment/add", carol, gin.H{"post_id": ids[2], "content": "second"}, nil)
+	s.do("POST", "/api/comment/add", carol, gin.H{"post_id": ids[1], "content": "hi"}, nil)
+	if got := list("sort=most_commented"); fmt.Sprint(got) != "[post 3 post 2 post 1]" {
+		t.Errorf("most commented order %v", got)
+	}
+	s.do("POST", "/api/comment/delete", bob, gin.H{"id": root.ID}, nil)
+	if post := get(ids[2]); post.CommentCount != 1 {
+		t.Errorf("expected comment count 1 after deleting thread, got %d", post.CommentCount)
+	}
+
+	// 阅读数先计入缓冲区，批量写入数据库
+	var counted model.Post
+	s.db.First(&counted, ids[1])
+	before := counted.ViewCount
+	for i := 0; i < 10; i++ {
+		get(ids[1])
+	}
+	if post := get(ids[1]); post.ViewCount != before+11 {
+		t.Errorf("expected %d views including pending, got %d", before+11, post.ViewCount)
+	}
+	if err := s.views.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s.db.First(&counted, ids[1])
+	if counted.ViewCount != before+11 || s.views.Pending(ids[1]) != 0 {
+		t.Errorf("expected %d views after flush, got %d (pending %d)", before+11, counted.ViewCount, s.views.Pending(ids[1]))
+	}
+
+	// 热门榜按热度排序，只统计近期发布的文章
+	if got := list("sort=trending"); fmt.Sprint(got) != "[post 2 post 3 post 1]" {
+		t.Errorf("trending order %v", got)
+	}
+	s.db.Model(&model.Post{}).Where("id = ?", ids[1]).Update("published_at", time.Now().AddDate(0, -1, 0))
+	if got := list("sort=trending"); fmt.Sprint(got) != "[post 3 post 1]" {
+		t.Errorf("trending should skip old posts, got %v", got)
+	}
+
+	// 收藏：幂等，按收藏时间倒序，不再公开的文章不出现在列表中
+	type bookmarkPage struct {
+		Bookmarks []struct {
+			PostID uint `json:"post_id"`
+			Post   postResp
+		}
+		NextCursor string `json:"next_cursor"`
+	}
+	for _, id := range []uint{ids[0], ids[0], ids[1]} {
+		code := s.do("POST", "/api/post/bookmark", bob, gin.H{"id": id}, nil)
+		expectStatus(t, "bookmark", code, http.StatusOK)
+	}
+	var page bookmarkPage
+	s.do("GET", "/api/bookmark/list?size=1", bob, nil, &page)
+	if len(page.Bookmarks) != 1 || page.Bookmarks[0].Post.ID != ids[1] || page.NextCursor == "" {
+		t.Fatalf("unexpected first bookmark page %+v", page)
+	}
+	s.do("GET", "/api/bookmark/list?size=1&cursor="+page.NextCursor, bob, nil, &page)
+	if len(page.Bookmarks) != 1 || page.Bookmarks[0].Post.Title != "post 1" || page.NextCursor != "" {
+		t.Fatalf("unexpected second bookmark page %+v", page)
+	}
+	s.do("POST", "/api/post/update", alice, gin.H{"id": ids[1], "status": model.PostStatusDraft}, nil)
+	s.do("GET", "/api/bookmark/list", bob, nil, &page)
+	if len(page.Bookmarks) != 1 || page.Bookmarks[0].PostID != ids[0] {
+		t.Errorf("unpublished post should be hidden from bookmarks, got %+v", page)
+	}
+	code := s.do("POST", "/api/post/like", carol, gin.H{"id": ids[1]}, nil)
+	expectStatus(t, "like draft", code, http.StatusNotFound)
+	code = s.do("POST", "/api/post/bookmark", carol, gin.H{"id": 999}, nil)
+	expectStatus(t, "bookmark missing post", code, http.StatusNotFound)
+
+	var reaction struct{ Liked, Bookmarked bool }
+	s.do("GET", fmt.Sprintf("/api/post/reaction?id=%d", ids[0]), bob, nil, &reaction)
+	if !reaction.Liked || !reaction.Bookmarked {
+		t.Errorf("unexpected reaction %+v", reaction)
+	}
+	s.do("POST", "/api/post/unbookmark", bob, gin.H{"id": ids[0]}, nil)
+	s.do("GET", fmt.Sprintf("/api/post/reaction?id=%d", ids[0]), bob, nil, &reaction)
+	if reaction.Bookmarked {
+		t.Error("expected bookmark to be removed")
+	}
+}
+
+func TestFollowFeed(t *testing.T) {
+	s := newTestServer(t)
+	alice, aliceToken := s.createUser("alice", model.RoleAuthor)
+	bob, bobToken := s.createUser("bob", model.RoleAuthor)
+	carol, carolToken := s.createUser("carol", model.RoleReader)
+	_, daveToken := s.createUser("dave", model.RoleAuthor)
+	_, admin := s.createUser("admin", model.RoleAdmin)
+
+	type followResult struct {
+		Following     bool
+		FollowerCount int64 `json:"follower_count"`
+	}
+	follow := func(action string, id uint) followResult {
+		t.Helper()
+		var res followResult
+		expectStatus(t, action, s.do("POST", "/api/user/"+action, carolToken, gin.H{"id": id}, &res), http.StatusOK)
+		return res
+	}
+	if res := follow("follow", alice.ID); !res.Following || res.FollowerCount != 1 {
+		t.Errorf("unexpected follow result %+v", res)
+	}
+	if res := follow("follow", alice.ID); res.FollowerCount != 1 {
+		t.Errorf("repeated follow should be idempotent, got %+v", res)
+	}
+	follow("follow", bob.ID)
+	code := s.do("POST", "/api/user/follow", carolToken, gin.H{"id": carol.ID}, nil)
+	expectStatus(t, "follow self", code, http.StatusBadRequest)
+	code = s.do("POST", "/api/user/follow", carolToken, gin.H{"id": 999}, nil)
+	expectStatus(t, "follow missing user", code, http.StatusNotFound)
+
+	var profile struct {
+		Username       string
+		FollowerCount  int64 `json:"follower_count"`
+		FollowingCount int64 `json:"following_count"`
+	}
+	s.do("GET", fmt.Sprintf("/api/user/profile?id=%d", carol.ID), "", nil, &profile)
+	if profile.Username != "carol" || profile.FollowingCount != 2 || profile.FollowerCount != 0 {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	type followPage struct {
+		Users []struct {
+			User struct {
+				ID       uint
+				Username string
+			}
+		}
+		NextCursor string `json:"next_cursor"`
+	}
+	var following followPage
+	s.do("GET", fmt.Sprintf("/api/user/following?id=%d&size=1", carol.ID), "", nil, &following)
+	if len(following.Users) != 1 || following.Users[0].User.Username != "bob" || following.NextCursor == "" {
+		t.Fatalf("unexpected following page %+v", following)
+	}
+	s.do("GET", fmt.Sprintf("/api/user/following?id=%d&size=1&cursor=%s", carol.ID, following.NextCursor), "", nil, &following)
+	if len(following.Users) != 1 || following.Users[0].User.Username != "alice" || following.NextCursor != "" {
+		t.Fatalf("unexpected second following page %+v", following)
+	}
+
+	// 信息流只包含关注作者已发布的文章，按发布时间倒序
+	posts := []struct {
+		token, title, status string
+	}{
+		{aliceToken, "alice 1", ""},
+		{bobToken, "bob 1", ""},
+		{daveToken, "dave 1", ""},
+		{aliceToken, "alice draft", model.PostStatusDraft},
+		{bobToken, "bob 2", ""},
+	}
+	for _, p := range posts {
+		body := gin.H{"title": p.title, "content": "c"}
+		if p.status != "" {
+			body["status"] = p.status
+		}
+		expectStatus(t, "create post", s.do("POST", "/api/post/add", p.token, body, nil), http.StatusCreated)
+	}
+	feed := func() []string {
+		t.Helper()
+		var titles []string
+		for cursor := ""; ; {
+			var page struct {
+				Posts      []postResp
+				NextCursor string `json:"next_cursor"`
+			}
+			expectStatus(t, "feed", s.do("GET", "/api/feed?size=2&cursor="+cursor, carolToken, nil, &page), http.StatusOK)
+			for _, p := range page.Posts {
+				titles = append(titles, p.Title)
+			}
+			if cursor = page.NextCursor; cursor == "" {
+				return titles
+			}
+		}
+	}
+	if got := feed(); fmt.Sprint(got) != "[bob 2 bob 1 alice 1]" {
+		t.Errorf("unexpected feed %v", got)
+	}
+
+	if res := follow("unfollow", bob.ID); res.Following || res.FollowerCount != 0 {
+		t.Errorf("unexpected unfollow result %+v", res)
+	}
+	if res := follow("unfollow", bob.ID); res.FollowerCount != 0 {
+		t.Errorf("repeated unfollow should be idempotent, got %+v", res)
+	}
+	if got := feed(); fmt.Sprint(got) != "[alice 1]" {
+		t.Errorf("unexpected feed after unfollow %v", got)
+	}
+	code = s.do("GET", "/api/feed", "", nil, nil)
+	expectStatus(t, "anonymous feed", code, http.StatusUnauthorized)
+
+	// 已注销的用户不出现在粉丝列表中
+	var followers followPage
+	s.do("GET", fmt.Sprintf("/api/user/followers?id=%d", alice.ID), "", nil, &followers)
+	if len(followers.Users) != 1 || followers.Users[0].User.ID != carol.ID {
+		t.Fatalf("unexpected followers %+v", followers)
+	}
+	expectStatus(t, "alice follows carol", s.do("POST", "/api/user/follow", aliceToken, gin.H{"id": carol.ID}, nil), http.StatusOK)
+	expectStatus(t, "delete carol", s.do("POST", "/api/admin/user/delete", admin, gin.H{"id": carol.ID}, nil), http.StatusOK)
+	s.do("GET", fmt.Sprintf("/api/user/followers?id=%d", alice.ID), "", nil, &followers)
+	if len(followers.Users) != 0 {
+		t.Errorf("deleted user should not be listed, got %+v", followers)
+	}
+	// 注销用户的关注关系一并删除，对方的计数与列表一致
+	s.do("GET", fmt.Sprintf("/api/user/profile?id=%d", alice.ID), "", nil, &profile)
+	if profile.FollowerCount != 0 || profile.FollowingCount != 0 {
+		t.Errorf("counts should drop with the deleted user's follows, got %+v", profile)
+	}
+	var edges int64
+	s.db.Model(&model.Follow{}).Where("follower_id = ? OR followee_id = ?", carol.ID, carol.ID).Count(&edges)
+	if edges != 0 {
+		t.Errorf("follows of the deleted user should be removed, %d left", edges)
+	}
+}
+
+type attachmentResp struct {
+	ID           uint
+	PostID       *uint  `json:"post_id"`
+	ContentType  string `json:"content_type"`
+	Size         int64
+	Width        int
+	Height       int
+	URL          string
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+// upload 以 multipart/form-data 上传文件
+func (s *testServer) upload(token, filename string, data []byte, out interface{}) int {
+	s.t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	fw.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/api/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, req)
+	if out != nil {
+		json.Unmarshal(w.Body.Bytes(), out)
+	}
+	return w.Code
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadAttachments(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.createUser("alice", model.RoleAuthor)
+	_, bob := s.createUser("bob", model.RoleAuthor)
+	_, reader := s.createUser("reader", model.RoleReader)
+
+	// 类型按文件头识别，扩展名不可信
+	var a attachmentResp
+	code := s.upload(alice, "photo.html", testPNG(t, 400, 200), &a)
+	expectStatus(t, "upload png", code, http.StatusCreated)
+	if a.ContentType != "image/png" || a.Width != 400 || a.Height != 200 || a.PostID != nil ||
+		!strings.HasPrefix(a.URL, "/uploads/attachments/") || !strings.HasSuffix(a.URL, ".png") {
+		t.Fatalf("unexpected attachment %+v", a)
+	}
+	if a.ThumbnailURL == a.URL || !strings.HasSuffix(a.ThumbnailURL, "_thumb.png") {
+		t.Fatalf("expected a separate thumbnail, got %s", a.ThumbnailURL)
+	}
+
+	// 本地存储的文件由本服务提供
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, httptest.NewRequest("GET", a.ThumbnailURL, nil))
+	expectStatus(t, "get thumbnail", w.Code, http.StatusOK)
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("uploaded files should be served with nosniff")
+	}
+	thumb, err := png.DecodeConfig(w.Body)
+	if err != nil || thumb.Width != 100 || thumb.Height != 50 {
+		t.Errorf("unexpected thumbnail %+v: %v", thumb, err)
+	}
+
+	// 小图不单独生成缩略图
+	var small attachmentResp
+	expectStatus(t, "upload small", s.upload(alice, "small.png", testPNG(t, 40, 30), &small), http.StatusCreated)
+	if small.ThumbnailURL != small.URL {
+		t.Errorf("small image should use itself as thumbnail, got %+v", small)
+	}
+
+	var errResp struct{ Error struct{ Code string } }
+	code = s.upload(alice, "evil.png", []byte("<html><script>alert(1)</script></html>"), &errResp)
+	expectStatus(t, "upload html", code, http.StatusUnsupportedMediaType)
+	if errResp.Error.Code != "upload.type_unsupported" {
+		t.Errorf("unexpected error %+v", errResp)
+	}
+	big := append(testPNG(t, 10, 10), make([]byte, 256<<10)...)
+	expectStatus(t, "upload too large", s.upload(alice, "big.png", big, nil), http.StatusRequestEntityTooLarge)
+	corrupt := testPNG(t, 10, 10)[:40]
+	expectStatus(t, "upload corrupt", s.upload(alice, "bad.png", corrupt, nil), http.StatusBadRequest)
+	expectStatus(t, "reader upload", s.upload(reader, "a.png", testPNG(t, 10, 10), nil), http.StatusForbidden)
+
+	// 关联到文章；不能使用别人的附件
+	var bobs attachmentResp
+	expectStatus(t, "bob upload", s.upload(bob, "b.png", testPNG(t, 10, 10), &bobs), http.StatusCreated)
+	code = s.do("POST", "/api/post/add", alice, gin.H{"title": "t", "content": "c", "attachment_ids": []uint{a.ID, bobs.ID}}, nil)
+	expectStatus(t, "attach someone else's upload", code, http.StatusBadRequest)
+
+	var post struct {
+		ID          uint
+		Attachments []attachmentResp
+	}
+	code = s.do("POST", "/api/post/add", alice, gin.H{"title": "t", "content": "c", "attachment_ids": []uint{a.ID, small.ID}}, &post)
+	expectStatus(t, "create post with attachments", code, http.StatusCreated)
+	if len(post.Attachments) != 2 || *post.Attachments[0].PostID != post.ID {
+		t.Fatalf("unexpected attachments %+v", post.Attachments)
+	}
+	code = s.do("POST", "/api/post/add", alice, gin.H{"title": "t2", "content": "c", "attachment_ids": []uint{a.ID}}, nil)
+	expectStatus(t, "reuse attachment of another post", code, http.StatusBadRequest)
+
+	// 编辑时移除一张，移除的附件成为孤儿
+	code = s.do("POST", "/api/post/update", alice, gin.H{"id": post.ID, "attachment_ids": []uint{a.ID}}, &post)
+	expectStatus(t, "update attachments", code, http.StatusOK)
+	if len(post.Attachments) != 1 || post.Attachments[0].ID != a.ID {
+		t.Fatalf("unexpected attachments after update %+v", post.Attachments)
+	}
+	code = s.do("GET", "/api/post/get", "", gin.H{"id": post.ID}, &post)
+	expectStatus(t, "get post", code, http.StatusOK)
+	if len(post.Attachments) != 1 {
+		t.Errorf("post detail should include attachments, got %+v", post.Attachments)
+	}
+
+	// 未过保留期的孤儿不清理；过期后连同文件一起删除，仍被文章使用的附件保留
+	uploads := &service.Uploads{Store: repository.New(s.db), Storage: s.files, Config: testUploadConfig()}
+	if n, err := uploads.CollectOrphans(context.Background(), time.Now()); err != nil || n != 0 {
+		t.Fatalf("nothing should expire yet, removed %d: %v", n, err)
+	}
+	n, err := uploads.CollectOrphans(context.Background(), time.Now().Add(48*time.Hour))
+	if err != nil || n != 2 {
+		t.Fatalf("expected small and bob's upload to be collected, removed %d: %v", n, err)
+	}
+	var left []model.Attachment
+	s.db.Order("id").Find(&left)
+	if len(left) != 1 || left[0].ID != a.ID {
+		t.Errorf("unexpected remaining attachments %+v", left)
+	}
+	if _, err := s.files.Open(context.Background(), left[0].Key); err != nil {
+		t.Errorf("attached file should be kept: %v", err)
+	}
+	w = httptest.NewRecorder()
+	s.r.ServeHTTP(w, httptest.NewRequest("GET", small.URL, nil))
+	expectStatus(t, "collected file", w.Code, http.StatusNotFound)
+
+	// 删除文章后附件随之成为孤儿
+	expectStatus(t, "delete post", s.do("POST", "/api/post/delete", alice, gin.H{"id": post.ID}, nil), http.StatusOK)
+	if n, _ := uploads.CollectOrphans(context.Background(), time.Now().Add(48*time.Hour)); n != 1 {
+		t.Errorf("attachments of deleted posts should be collected, removed %d", n)
+	}
+	if _, err := s.files.Open(context.Background(), left[0].ThumbnailKey); err != storage.ErrNotExist {
+		t.Errorf("thumbnail should be deleted, got %v", err)
+	}
+}
+
+func TestSearchHidesCommentsOfUnpublishedPosts(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.createUser("alice", model.RoleAuthor)
+
+	var post struct{ ID uint }
+	expectStatus(t, "create post", s.do("POST", "/api/post/add", alice, gin.H{"title": "t", "content": "c"}, &post), http.StatusCreated)
+	expectStatus(t, "comment", s.do("POST", "/api/comment/add", alice, gin.H{"post_id": post.ID, "content": "zebra"}, nil), http.StatusCreated)
+
+	searchHits := func() []search.Hit {
+		t.Helper()
+		var result struct {
+			Hits []search.Hit `json:"hits"`
+		}
+		expectStatus(t, "search", s.do("GET", "/api/search?q=zebra", "", nil, &result), http.StatusOK)
+		return result.Hits
+	}
+	if hits := searchHits(); len(hits) != 1 || hits[0].Kind != search.KindComment {
+		t.Fatalf("expected the comment to be found, got %+v", hits)
+	}
+
+	// 改回草稿后评论不再可搜
+	expectStatus(t, "unpublish", s.do("POST", "/api/post/update", alice, gin.H{"id": post.ID, "status": "draft"}, nil), http.StatusOK)
+	// 评论列表同样不可见
+	expectStatus(t, "list comments of draft", s.do("POST", "/api/comment/list", "", gin.H{"post_id": post.ID}, nil), http.StatusNotFound)
+	if hits := searchHits(); len(hits) != 0 {
+		t.Errorf("comments of a draft should not be searchable, got %+v", hits)
+	}
+	// 从数据库重建时同样跳过
+	rebuilt := search.NewMemory()
+	if err := search.Rebuild(context.Background(), rebuilt, s.db); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := rebuilt.Search(context.Background(), "zebra", 10); len(hits) != 0 {
+		t.Errorf("rebuild should skip comments of drafts, got %+v", hits)
+	}
+
+	// 定时发布到期后恢复
+	at := time.Now().Add(time.Hour)
+	expectStatus(t, "schedule", s.do("POST", "/api/post/update", alice, gin.H{"id": post.ID, "status": "scheduled", "publish_at": at}, nil), http.StatusOK)
+	posts := &service.Posts{Store: repository.New(s.db), Searcher: s.index}
+	if n, err := posts.PublishDue(context.Background(), at); err != nil || n != 1 {
+		t.Fatalf("expected 1 post published, got %d %v", n, err)
+	}
+	if hits := searchHits(); len(hits) != 1 {
+		t.Errorf("comments should be searchable again after scheduled publishing, got %+v", hits)
+	}
+	if n, _ := posts.PublishDue(context.Background(), at); n != 0 {
+		t.Errorf("expected nothing left to publish, got %d", n)
+	}
+	expectStatus(t, "unpublish again", s.do("POST", "/api/post/update", alice, gin.H{"id": post.ID, "status": "draft"}, nil), http.StatusOK)
+
+	// 重新发布后恢复，删除文章后移除
+	expectStatus(t, "republish", s.do("POST", "/api/post/update", alice, gin.H{"id": post.ID, "status": "published"}, nil), http.StatusOK)
+	if hits := searchHits(); len(hits) != 1 {
+		t.Errorf("comments should be searchable again after republishing, got %+v", hits)
+	}
+	expectStatus(t, "delete post", s.do("POST", "/api/post/delete", alice, gin.H{"id": post.ID}, nil), http.StatusOK)
+	if hits := searchHits(); len(hits) != 0 {
+		t.Errorf("comments of a deleted post should not be searchable, got %+v", hits)
+	}
+	expectStatus(t, "list comments of deleted post", s.do("POST", "/api/comment/list", "", gin.H{"post_id": post.ID}, nil), http.StatusNotFound)
+}
+
+// racingStorage 在第一次删除文件时执行 onDelete，模拟清理过程中附件被关联到文章
+type racingStorage struct {
+	*storage.Local
+	onDelete func()
+}
+
+func (r *racingStorage) Delete(ctx context.Context, key string) error {
+	if r.onDelete != nil {
+		f := r.onDelete
+		r.onDelete = nil
+		f()
+	}
+	return r.Local.Delete(ctx, key)
+}
+
+func TestCollectOrphansSkipsReattached(t *testing.T) {
+	s := newTestServer(t)
+	alice, aliceToken := s.createUser("alice", model.RoleAuthor)
+
+	var first, second attachmentResp
+	expectStatus(t, "upload first", s.upload(aliceToken, "a.png", testPNG(t, 10, 10), &first), http.StatusCreated)
+	expectStatus(t, "upload second", s.upload(aliceToken, "b.png", testPNG(t, 10, 10), &second), http.StatusCreated)
+	var post struct{ ID uint }
+	expectStatus(t, "create post", s.do("POST", "/api/post/add", aliceToken, gin.H{"title": "t", "content": "c"}, &post), http.StatusCreated)
+
+	// 两个附件都已被读取为孤儿，清理第一个时第二个被关联到文章
+	store := repository.New(s.db)
+	files := &racingStorage{Local: s.files, onDelete: func() {
+		if n, err := store.Attachments.Attach(context.Background(), post.ID, alice.ID, []uint{second.ID}); err != nil || n != 1 {
+			t.Errorf("attach during gc: %d %v", n, err)
+		}
+	}}
+	uploads := &service.Uploads{Store: store, Storage: files, Config: testUploadConfig()}
+	n, err := uploads.CollectOrphans(context.Background(), time.Now().Add(48*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("expected only the first attachment to be collected, removed %d: %v", n, err)
+	}
+
+	var kept model.Attachment
+	if err := s.db.First(&kept, second.ID).Error; err != nil || kept.PostID == nil || *kept.PostID != post.ID {
+		t.Fatalf("re-attached attachment should be kept, got %+v: %v", kept, err)
+	}
+	if _, err := s.files.Open(context.Background(), kept.Key); err != nil {
+		t.Errorf("file of re-attached attachment should be kept: %v", err)
+	}
+	if err := s.db.First(&model.Attachment{}, first.ID).Error; err == nil {
+		t.Error("first attachment should be collected")
+	}
+}
+
+// TestAuthorJSONHidesSecrets 公开接口返回的文章和评论只包含作者的公开资料
+func TestAuthorJSONHidesSecrets(t *testing.T) {
+	s := newTestServer(t)
+	alice, aliceToken := s.createUser("alice", model.RoleAuthor)
+	_, bobToken := s.createUser("bob", model.RoleReader)
+
+	var post postResp
+	expectStatus(t, "create post", s.do("POST", "/api/post/add", aliceToken, gin.H{"title": "Hello", "content": "c"}, &post), http.StatusCreated)
+	expectStatus(t, "comment", s.do("POST", "/api/comment/add", bobToken, gin.H{"post_id": post.ID, "content": "nice"}, nil), http.StatusCreated)
+	expectStatus(t, "follow", s.do("POST", "/api/user/follow", bobToken, gin.H{"id": alice.ID}, nil), http.StatusOK)
+	expectStatus(t, "bookmark", s.do("POST", "/api/post/bookmark", bobToken, gin.H{"id": post.ID}, nil), http.StatusOK)
+
+	for _, tt := range []struct {
+		method, path, token string
+		body                interface{}
+	}{
+		{"GET", "/api/post/get", "", gin.H{"id": post.ID}},
+		{"GET", "/api/post/list", "", nil},
+		{"POST", "/api/comment/list", "", gin.H{"post_id": post.ID}},
+		{"GET", "/api/feed", bobToken, nil},
+		{"GET", "/api/bookmark/list", bobToken, nil},
+	} {
+		var raw json.RawMessage
+		expectStatus(t, tt.path, s.do(tt.method, tt.path, tt.token, tt.body, &raw), http.StatusOK)
+		body := string(raw)
+		if !strings.Contains(body, `"User":{"ID":`) {
+			t.Errorf("%s: expected author in %s", tt.path, body)
+		}
+		for _, secret := range []string{"$2a$", "Password", "@example.com", "FailedLogins", "LockedUntil", "MFAEnabledAt", "EthAddress"} {
+			if strings.Contains(body, secret) {
+				t.Errorf("%s: response leaks %q: %s", tt.path, secret, body)
+			}
+		}
+	}
+}
+
+// TestRevisionDiffLimits 正文超过长度上限时拒绝保存，行数过多的版本拒绝比较
+func TestRevisionDiffLimits(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.createUser("alice", model.RoleAuthor)
+
+	code := s.do("POST", "/api/post/add", alice, gin.H{"title": "t", "content": strings.Repeat("a", 200001)}, nil)
+	expectStatus(t, "oversized content", code, http.StatusBadRequest)
+
+	var post postResp
+	code = s.do("POST", "/api/post/add", alice, gin.H{"title": "t", "content": "a\nb"}, &post)
+	expectStatus(t, "create post", code, http.StatusCreated)
+	code = s.do("POST", "/api/post/update", alice, gin.H{"id": post.ID, "content": "a\nB"}, nil)
+	expectStatus(t, "update post", code, http.StatusOK)
+	var diff struct {
+		Content []struct{ Op string }
+	}
+	code = s.do("POST", "/api/post/revision/diff", alice, gin.H{"id": post.ID, "from": 1, "to": 2}, &diff)
+	expectStatus(t, "diff", code, http.StatusOK)
+	if len(diff.Content) != 3 {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+
+	code = s.do("POST", "/api/post/update", alice, gin.H{"id": post.ID, "content": strings.Repeat("x\n", 6000)}, nil)
+	expectStatus(t, "update long post", code, http.StatusOK)
+	code = s.do("POST", "/api/post/revision/diff", alice, gin.H{"id": post.ID, "from": 2, "to": 3}, nil)
+	expectStatus(t, "diff too many lines", code, http.StatusRequestEntityTooLarge)
+}
+
+// TestRegisterConflicts 已删除用户的用户名和邮箱仍被占用，过长的密码返回 400
+func TestRegisterConflicts(t *testing.T) {
+	s := newTestServer(t)
+	_, admin := s.createUser("admin", model.RoleAdmin)
+	carol, _ := s.createUser("carol", model.RoleAuthor)
+	expectStatus(t, "delete carol", s.do("POST", "/api/admin/user/delete", admin, gin.H{"id": carol.ID}, nil), http.StatusOK)
+
+	code := s.do("POST", "/api/register", "", gin.H{"username": "carol", "email": "new@example.com", "password": "secret1"}, nil)
+	expectStatus(t, "register deleted username", code, http.StatusConflict)
+	code = s.do("POST", "/api/register", "", gin.H{"username": "carol2", "email": carol.Email, "password": "secret1"}, nil)
+	expectStatus(t, "register deleted email", code, http.StatusConflict)
+
+	code = s.do("POST", "/api/register", "", gin.H{"username": "dave", "email": "dave@example.com", "password": strings.Repeat("a", 73)}, nil)
+	expectStatus(t, "password over 72 characters", code, http.StatusBadRequest)
+	// 字符数未超限，但 UTF-8 编码超过 72 字节
+	code = s.do("POST", "/api/register", "", gin.H{"username": "dave", "email": "dave@example.com", "password": strings.Repeat("密", 30)}, nil)
+	expectStatus(t, "password over 72 bytes", code, http.StatusBadRequest)
+}
+
+// TestPurgeExpiredTokens 只删除已过期的令牌与 nonce
+func TestPurgeExpiredTokens(t *testing.T) {
+	s := newTestServer(t)
+	user, _ := s.createUser("alice", model.RoleAuthor)
+	now := time.Now()
+
+	for i, at := range []time.Time{now.Add(-time.Minute), now.Add(time.Hour)} {
+		key := fmt.Sprintf("%d", i)
+		for _, row := range []interface{}{
+			&model.RefreshToken{UserID: user.ID, TokenHash: "r" + key, FamilyID: "f", ExpiresAt: at},
+			&model.RevokedToken{JTI: "j" + key, ExpiresAt: at},
+			&model.ActionToken{UserID: user.ID, Purpose: model.PurposeEmailVerification, JTI: "a" + key, ExpiresAt: at},
+			&model.PasswordResetToken{UserID: user.ID, TokenHash: "p" + key, ExpiresAt: at},
+			&model.SIWENonce{Nonce: "n" + key, ExpiresAt: at},
+		} {
+			if err := s.db.Create(row).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	auth := &service.Auth{Store: repository.New(s.db)}
+	n, err := auth.PurgeExpired(context.Background(), now)
+	if err != nil || n != 5 {
+		t.Fatalf("expected 5 expired rows purged, got %d %v", n, err)
+	}
+	for _, m := range []interface{}{&model.RefreshToken{}, &model.RevokedToken{}, &model.ActionToken{}, &model.PasswordResetToken{}, &model.SIWENonce{}} {
+		var left int64
+		if err := s.db.Unscoped().Model(m).Count(&left).Error; err != nil || left != 1 {
+			t.Errorf("%T: expected 1 unexpired row left, got %d %v", m, left, err)
+		}
+	}
+}
+
+// TestListOrdersByPublishedAt 公开列表按发布时间排序和筛选，定时文章以发布时间而非创建时间出现
+func TestListOrdersByPublishedAt(t *testing.T) {
+	s := newTestServer(t)
+	_, alice := s.createUser("alice", model.RoleAuthor)
+
+	at := time.Now().Add(time.Hour)
+	var scheduled, published postResp
+	expectStatus(t, "create scheduled", s.do("POST", "/api/post/add", alice, gin.H{"title": "scheduled", "content": "c", "status": "scheduled", "publish_at": at}, &scheduled), http.StatusCreated)
+	expectStatus(t, "create published", s.do("POST", "/api/post/add", alice, gin.H{"title": "published", "content": "c"}, &published), http.StatusCreated)
+	posts := &service.Posts{Store: repository.New(s.db)}
+	if n, err := posts.PublishDue(context.Background(), at); err != nil || n != 1 {
+		t.Fatalf("expected 1 post published, got %d %v", n, err)
+	}
+
+	type page struct {
+		Posts      []postResp `json:"posts"`
+		NextCursor string     `json:"next_cursor"`
+	}
+	var first page
+	expectStatus(t, "list", s.do("GET", "/api/post/list?size=1", "", nil, &first), http.StatusOK)
+	if len(first.Posts) != 1 || first.Posts[0].ID != scheduled.ID || first.NextCursor == "" {
+		t.Fatalf("expected the scheduled post first, got %+v", first)
+	}
+	var second page
+	expectStatus(t, "list next", s.do("GET", "/api/post/list?size=1&cursor="+url.QueryEscape(first.NextCursor), "", nil, &second), http.StatusOK)
+	if len(second.Posts) != 1 || second.Posts[0].ID != published.ID {
+		t.Fatalf("expected the other post on the next page, got %+v", second)
+	}
+
+	var filtered page
+	from := url.QueryEscape(at.Add(-time.Minute).Format(time.RFC3339))
+	expectStatus(t, "list from", s.do("GET", "/api/post/list?from="+from, "", nil, &filtered), http.StatusOK)
+	if len(filtered.Posts) != 1 || filtered.Posts[0].ID != scheduled.ID {
+		t.Errorf("expected only the scheduled post published after from, got %+v", filtered)
+	}
+}
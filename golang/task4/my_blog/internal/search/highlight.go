@@ -0,0 +1,132 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	fragmentRadius = 30 // 命中词前后各保留的字符数
+	maxFragments   = 3
+)
+
+type span struct{ start, end int } // 字节区间 [start, end)
+
+// Highlight 在 text 中标出 query 的命中词，返回至多 maxFragments 个片段，
+// 命中部分用 <em></em> 包裹，其余内容做 HTML 转义
+func Highlight(text, query string) []string {
+	terms := uniqueTerms(Tokenize(query))
+	if len(terms) == 0 || text == "" {
+		return nil
+	}
+
+	lower := lowerSameWidth(text)
+	var spans []span
+	for _, t := range terms {
+		for from := 0; ; {
+			i := strings.Index(lower[from:], t)
+			if i < 0 {
+				break
+			}
+			spans = append(spans, span{from + i, from + i + len(t)})
+			from += i + len(t)
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	spans = mergeSpans(spans)
+
+	var fragments []string
+	for i := 0; i < len(spans) && len(fragments) < maxFragments; {
+		start := backRunes(text, spans[i].start, fragmentRadius)
+		end := forwardRunes(text, spans[i].end, fragmentRadius)
+		// 片段窗口内的其他命中一起标出
+		j := i
+		for j < len(spans) && spans[j].end <= end {
+			j++
+		}
+		fragments = append(fragments, renderFragment(text, start, end, spans[i:j]))
+		i = j
+	}
+	return fragments
+}
+
+func renderFragment(text string, start, end int, hits []span) string {
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, h := range hits {
+		b.WriteString(html.EscapeString(text[pos:h.start]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(text[h.start:h.end]))
+		b.WriteString("</em>")
+		pos = h.end
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func mergeSpans(spans []span) []span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	out := spans[:1]
+	for _, s := range spans[1:] {
+		last := &out[len(out)-1]
+		if s.start <= last.end {
+			if s.end > last.end {
+				last.end = s.end
+			}
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// backRunes 从字节位置 pos 向前回退 n 个字符
+func backRunes(s string, pos, n int) int {
+	for ; n > 0 && pos > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:pos])
+		pos -= size
+	}
+	return pos
+}
+
+// forwardRunes 从字节位置 pos 向后前进 n 个字符
+func forwardRunes(s string, pos, n int) int {
+	for ; n > 0 && pos < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[pos:])
+		pos += size
+	}
+	return pos
+}
+
+// lowerSameWidth 小写化但保持每个字符的字节长度不变，保证区间可直接用于原文
+func lowerSameWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		if l := unicode.ToLower(r); utf8.RuneLen(l) == utf8.RuneLen(r) {
+			return l
+		}
+		return r
+	}, s)
+}
+
+func uniqueTerms(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	out := tokens[:0:0]
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
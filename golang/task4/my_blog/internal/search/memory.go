@@ -0,0 +1,157 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// titleBoost 标题中的词频权重
+const titleBoost = 2
+
+type docKey string
+
+func keyOf(kind string, id uint) docKey {
+	return docKey(kind + ":" + strconv.FormatUint(uint64(id), 10))
+}
+
+type indexedDoc struct {
+	Document
+	terms  map[string]float64 // 词 -> 加权词频
+	length float64
+}
+
+// Memory 进程内倒排索引，按 TF-IDF 排序，适用于测试和 SQLite 部署
+type Memory struct {
+	mu       sync.RWMutex
+	docs     map[docKey]*indexedDoc
+	postings map[string]map[docKey]struct{}
+}
+
+// NewMemory 创建空的内存索引
+func NewMemory() *Memory {
+	return &Memory{
+		docs:     make(map[docKey]*indexedDoc),
+		postings: make(map[string]map[docKey]struct{}),
+	}
+}
+
+func (m *Memory) Index(_ context.Context, doc Document) error {
+	d := &indexedDoc{Document: doc, terms: make(map[string]float64)}
+	for _, t := range Tokenize(doc.Title) {
+		d.terms[t] += titleBoost
+		d.length += titleBoost
+	}
+	for _, t := range Tokenize(doc.Content) {
+		d.terms[t]++
+		d.length++
+	}
+
+	key := keyOf(doc.Kind, doc.ID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(key)
+	m.docs[key] = d
+	for t := range d.terms {
+		if m.postings[t] == nil {
+			m.postings[t] = make(map[docKey]struct{})
+		}
+		m.postings[t][key] = struct{}{}
+	}
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, kind string, id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(keyOf(kind, id))
+	if kind == KindPost {
+		for key, d := range m.docs {
+			if d.Kind == KindComment && d.PostID == id {
+				m.remove(key)
+			}
+		}
+	}
+	return nil
+}
+
+// remove 调用方需持有写锁
+func (m *Memory) remove(key docKey) {
+	d, ok := m.docs[key]
+	if !ok {
+		return
+	}
+	for t := range d.terms {
+		delete(m.postings[t], key)
+		if len(m.postings[t]) == 0 {
+			delete(m.postings, t)
+		}
+	}
+	delete(m.docs, key)
+}
+
+func (m *Memory) Search(_ context.Context, query string, limit int) ([]Hit, error) {
+	terms := uniqueTerms(Tokenize(query))
+	if len(terms) == 0 {
+		return []Hit{}, nil
+	}
+
+	m.mu.RLock()
+	n := float64(len(m.docs))
+	scores := make(map[docKey]float64)
+	for _, t := range terms {
+		posting := m.postings[t]
+		if len(posting) == 0 {
+			continue
+		}
+		idf := math.Log(1 + n/float64(len(posting)))
+		for key := range posting {
+			d := m.docs[key]
+			scores[key] += d.terms[t] / d.length * idf
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	docs := make([]Document, 0, len(scores))
+	for key, score := range scores {
+		d := m.docs[key]
+		hits = append(hits, Hit{Kind: d.Kind, ID: d.ID, PostID: d.PostID, Title: d.Title, Score: score})
+		docs = append(docs, d.Document)
+	}
+	m.mu.RUnlock()
+
+	order := make([]int, len(hits))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := hits[order[i]], hits[order[j]]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Kind != b.Kind {
+			return a.Kind > b.Kind // 同分时文章排在评论前
+		}
+		return a.ID > b.ID
+	})
+	if limit > 0 && len(order) > limit {
+		order = order[:limit]
+	}
+
+	out := make([]Hit, len(order))
+	for i, idx := range order {
+		out[i] = hits[idx]
+		out[i].Highlights = highlightDoc(docs[idx], query)
+	}
+	return out, nil
+}
+
+// highlightDoc 优先高亮正文，正文无命中时高亮标题
+func highlightDoc(d Document, query string) []string {
+	if h := Highlight(d.Content, query); len(h) > 0 {
+		return h
+	}
+	return Highlight(d.Title, query)
+}
@@ -0,0 +1,123 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{name: "英文单词小写化", text: "Hello, Gin World!", expected: []string{"hello", "gin", "world"}},
+		{name: "汉字二元组", text: "区块链", expected: []string{"区块", "块链"}},
+		{name: "单个汉字", text: "链", expected: []string{"链"}},
+		{name: "中英混排", text: "Go语言入门", expected: []string{"go", "语言", "言入", "入门"}},
+		{name: "空串", text: "  ", expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Tokenize(%q) = %v, expected %v", tt.text, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMemorySearch(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	docs := []Document{
+		{Kind: KindPost, ID: 1, PostID: 1, Title: "Gin 入门", Content: "使用 gin 编写 HTTP 服务"},
+		{Kind: KindPost, ID: 2, PostID: 2, Title: "GORM 笔记", Content: "gorm 的预加载与事务，顺带提一下 gin"},
+		{Kind: KindComment, ID: 10, PostID: 1, Content: "gin 的中间件很好用"},
+		{Kind: KindPost, ID: 3, PostID: 3, Title: "以太坊", Content: "go-ethereum 客户端"},
+	}
+	for _, d := range docs {
+		if err := m.Index(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hits, err := m.Search(ctx, "gin", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 3 {
+		t.Fatalf("expected 3 hits, got %d: %+v", len(hits), hits)
+	}
+	// 标题命中的文章相关度最高
+	if hits[0].Kind != KindPost || hits[0].ID != 1 {
+		t.Errorf("expected post 1 ranked first, got %+v", hits[0])
+	}
+	if len(hits[0].Highlights) == 0 || hits[0].Highlights[0] != "使用 <em>gin</em> 编写 HTTP 服务" {
+		t.Errorf("unexpected highlights %q", hits[0].Highlights)
+	}
+
+	// 更新后旧内容不再命中
+	if err := m.Index(ctx, Document{Kind: KindPost, ID: 2, PostID: 2, Title: "GORM 笔记", Content: "预加载与事务"}); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ = m.Search(ctx, "gin", 10); len(hits) != 2 {
+		t.Errorf("expected 2 hits after update, got %d", len(hits))
+	}
+
+	// 删除后不再命中
+	if err := m.Delete(ctx, KindComment, 10); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ = m.Search(ctx, "gin", 10); len(hits) != 1 {
+		t.Errorf("expected 1 hit after delete, got %d", len(hits))
+	}
+
+	if hits, _ = m.Search(ctx, "以太坊", 10); len(hits) != 1 || hits[0].ID != 3 {
+		t.Errorf("expected CJK query to match post 3, got %+v", hits)
+	}
+	if hits, _ = m.Search(ctx, "!!!", 10); len(hits) != 0 {
+		t.Errorf("expected no hits for empty query, got %+v", hits)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		query    string
+		expected []string
+	}{
+		{name: "大小写不敏感并转义", text: "<b>Gin</b> and gin", query: "GIN",
+			expected: []string{"&lt;b&gt;<em>Gin</em>&lt;/b&gt; and <em>gin</em>"}},
+		{name: "汉字命中", text: "学习区块链技术", query: "区块链",
+			expected: []string{"学习<em>区块链</em>技术"}},
+		{name: "无命中", text: "hello", query: "world", expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, tt.query); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Highlight(%q, %q) = %q, expected %q", tt.text, tt.query, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMemoryDeletePostRemovesComments(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	for _, d := range []Document{
+		{Kind: KindPost, ID: 1, PostID: 1, Title: "草稿", Content: "gin"},
+		{Kind: KindComment, ID: 10, PostID: 1, Content: "gin 评论"},
+		{Kind: KindComment, ID: 11, PostID: 2, Content: "gin 其他文章的评论"},
+	} {
+		m.Index(ctx, d)
+	}
+	if err := m.Delete(ctx, KindPost, 1); err != nil {
+		t.Fatal(err)
+	}
+	hits, _ := m.Search(ctx, "gin", 10)
+	if len(hits) != 1 || hits[0].ID != 11 {
+		t.Errorf("expected only the other post's comment, got %+v", hits)
+	}
+}
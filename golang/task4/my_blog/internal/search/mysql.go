@@ -0,0 +1,94 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// MySQL 基于 MySQL FULLTEXT（ngram 分词）的检索，索引由数据库自动维护
+type MySQL struct {
+	db *gorm.DB
+}
+
+// fulltextIndexes 需要的全文索引，由迁移 0010_fulltext 创建
+var fulltextIndexes = []struct{ table, name string }{
+	{"posts", "ft_posts_title_content"},
+	{"comments", "ft_comments_content"},
+}
+
+// NewMySQL 创建 MySQL 检索后端，全文索引不存在时返回错误（需先执行迁移）
+func NewMySQL(db *gorm.DB) (*MySQL, error) {
+	for _, idx := range fulltextIndexes {
+		var n int64
+		if err := db.Raw(
+			"SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?",
+			idx.table, idx.name,
+		).Scan(&n).Error; err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("search: FULLTEXT index %s on %s is missing, run migrations first", idx.name, idx.table)
+		}
+	}
+	return &MySQL{db: db}, nil
+}
+
+// Index 全文索引随表数据自动更新，无需额外操作
+func (m *MySQL) Index(context.Context, Document) error { return nil }
+
+// Delete 全文索引随表数据自动更新，无需额外操作；评论按所属文章的状态在查询时过滤
+func (m *MySQL) Delete(context.Context, string, uint) error { return nil }
+
+type mysqlRow struct {
+	ID      uint
+	PostID  uint
+	Title   string
+	Content string
+	Score   float64
+}
+
+func (m *MySQL) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	if len(Tokenize(query)) == 0 {
+		return []Hit{}, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	db := m.db.WithContext(ctx)
+	var posts, comments []mysqlRow
+	if err := db.Raw(`SELECT id, id AS post_id, title, content,
+			MATCH(title, content) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+		FROM posts
+		WHERE deleted_at IS NULL AND status = 'published' AND MATCH(title, content) AGAINST (? IN NATURAL LANGUAGE MODE)
+		ORDER BY score DESC LIMIT ?`, query, query, limit).Scan(&posts).Error; err != nil {
+		return nil, err
+	}
+	// 只返回已发布、未删除文章下的评论
+	if err := db.Raw(`SELECT c.id, c.post_id, '' AS title, c.content,
+			MATCH(c.content) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+		FROM comments c
+		JOIN posts p ON p.id = c.post_id AND p.deleted_at IS NULL AND p.status = 'published'
+		WHERE c.deleted_at IS NULL AND c.hidden_at IS NULL AND MATCH(c.content) AGAINST (? IN NATURAL LANGUAGE MODE)
+		ORDER BY score DESC LIMIT ?`, query, query, limit).Scan(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(posts)+len(comments))
+	for _, r := range posts {
+		hits = append(hits, Hit{Kind: KindPost, ID: r.ID, PostID: r.PostID, Title: r.Title, Score: r.Score,
+			Highlights: highlightDoc(Document{Title: r.Title, Content: r.Content}, query)})
+	}
+	for _, r := range comments {
+		hits = append(hits, Hit{Kind: KindComment, ID: r.ID, PostID: r.PostID, Score: r.Score,
+			Highlights: Highlight(r.Content, query)})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
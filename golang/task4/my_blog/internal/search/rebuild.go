@@ -0,0 +1,47 @@
+package search
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"my_blog/internal/model"
+)
+
+// PostDocument 文章对应的索引文档
+func PostDocument(p *model.Post) Document {
+	return Document{Kind: KindPost, ID: p.ID, PostID: p.ID, Title: p.Title, Content: p.Content}
+}
+
+// CommentDocument 评论对应的索引文档
+func CommentDocument(c *model.Comment) Document {
+	return Document{Kind: KindComment, ID: c.ID, PostID: c.PostID, Content: c.Content}
+}
+
+// Rebuild 从数据库全量重建索引，供内存索引在启动时使用
+func Rebuild(ctx context.Context, s Searcher, db *gorm.DB) error {
+	var posts []model.Post
+	if err := db.WithContext(ctx).Where("status = ?", model.PostStatusPublished).FindInBatches(&posts, 500, func(tx *gorm.DB, _ int) error {
+		for i := range posts {
+			if err := s.Index(ctx, PostDocument(&posts[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error; err != nil {
+		return err
+	}
+
+	// 只索引已发布、未删除文章下的评论
+	var comments []model.Comment
+	return db.WithContext(ctx).
+		Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL AND posts.status = ?", model.PostStatusPublished).
+		Where("comments.hidden_at IS NULL").FindInBatches(&comments, 500, func(tx *gorm.DB, _ int) error {
+		for i := range comments {
+			if err := s.Index(ctx, CommentDocument(&comments[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
@@ -0,0 +1,90 @@
+// Package search 文章与评论的全文检索
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// 文档类型
+const (
+	KindPost    = "post"
+	KindComment = "comment"
+)
+
+// Document 被索引的一篇文章或一条评论
+type Document struct {
+	Kind    string
+	ID      uint
+	PostID  uint // 评论所属文章；文章自身时等于 ID
+	Title   string
+	Content string
+}
+
+// Hit 一条搜索结果
+type Hit struct {
+	Kind       string   `json:"kind"`
+	ID         uint     `json:"id"`
+	PostID     uint     `json:"post_id"`
+	Title      string   `json:"title,omitempty"`
+	Score      float64  `json:"score"`
+	Highlights []string `json:"highlights"`
+}
+
+// Searcher 可插拔的检索后端
+type Searcher interface {
+	// Index 新增或更新一篇文档
+	Index(ctx context.Context, doc Document) error
+	// Delete 从索引中移除文档；移除文章时其评论一并移除
+	Delete(ctx context.Context, kind string, id uint) error
+	// Search 按相关度降序返回最多 limit 条结果
+	Search(ctx context.Context, query string, limit int) ([]Hit, error)
+}
+
+// isHan 是否为汉字，汉字按二元组切分
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+// Tokenize 切分文本：拉丁字母和数字按单词小写化，连续汉字按二元组切分（单字时保留单字）
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		switch {
+		case len(han) == 1:
+			tokens = append(tokens, string(han))
+		case len(han) > 1:
+			for i := 0; i+1 < len(han); i++ {
+				tokens = append(tokens, string(han[i:i+2]))
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isHan(r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
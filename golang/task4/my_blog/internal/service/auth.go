@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"my_blog/internal/conf"
+	"my_blog/internal/mail"
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+// Auth 注册、登录、令牌、邮箱验证、密码、两步验证与钱包登录
+type Auth struct {
+	Store    *repository.Store
+	Mailer   mail.Mailer
+	Queue    mail.Mailer                // 重置密码邮件经此队列在后台发送，响应耗时不暴露邮箱是否已注册；为 nil 时使用 Mailer
+	BaseURL  string                     // 邮件中链接指向的站点地址
+	Security func() conf.SecurityConfig // 当前的账号安全配置，为 nil 时使用默认值
+	SIWE     func() conf.SIWEConfig     // 当前的钱包登录配置，为 nil 时使用默认值
+}
+
+// LoginResult 登录结果：已启用两步验证时只有 MFAChallenge，否则只有 TokenPair
+type LoginResult struct {
+	*TokenPair
+	*MFAChallenge
+}
+
+// RegisterInput 注册信息
+type RegisterInput struct {
+	Username string
+	Email    string
+	Password string
+}
+
+// Register 创建账号并发送验证邮件，发送失败不影响注册，用户可登录后重新发送
+func (s *Auth) Register(ctx context.Context, in RegisterInput) (*model.User, error) {
+	email := strings.ToLower(strings.TrimSpace(in.Email))
+	if strings.HasSuffix(email, "@"+model.WalletEmailDomain) {
+		return nil, ErrEmailUnavailable
+	}
+
+	users := s.Store.Users
+	if taken, err := users.UsernameTaken(ctx, in.Username); err != nil || taken {
+		return nil, errOr(err, ErrUsernameTaken)
+	}
+	if taken, err := users.EmailTaken(ctx, email); err != nil || taken {
+		return nil, errOr(err, ErrEmailTaken)
+	}
+
+	hashed, err := hashPassword(in.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{
+		Username: in.Username,
+		Password: hashed,
+		Email:    email,
+		Role:     model.RoleAuthor,
+	}
+	if err := users.Create(ctx, user); err != nil {
+		return nil, internal(err)
+	}
+
+	if err := s.sendVerification(ctx, user); err != nil {
+		log.Printf("send verification mail to user %d: %v", user.ID, err)
+	}
+	return user, nil
+}
+
+// errOr 查询出错时返回内部错误，否则返回业务错误
+func errOr(err error, business error) error {
+	if err != nil {
+		return internal(err)
+	}
+	return business
+}
+
+// Login 用户名密码登录
+func (s *Auth) Login(ctx context.Context, username, password string, client Client) (*LoginResult, error) {
+	user, err := s.Store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, notFound(err, ErrBadCredentials)
+	}
+
+	// 锁定期间不校验密码，也不累加失败次数
+	if now := time.Now(); user.Locked(now) {
+		return nil, s.locked(ctx, user, client, now)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		if err := s.loginFailed(ctx, user, client, model.LoginFailBadPassword); err != nil {
+			log.Printf("record failed login for user %d: %v", user.ID, err)
+		}
+		return nil, ErrBadCredentials
+	}
+	return s.completeLogin(ctx, user, client)
+}
+
+// completeLogin 第一步认证通过后：已启用两步验证时返回 mfa_token，凭验证码到 LoginMFA 换取令牌；否则直接签发令牌
+func (s *Auth) completeLogin(ctx context.Context, user *model.User, client Client) (*LoginResult, error) {
+	if user.MFAEnabled() {
+		challenge, err := s.startMFALogin(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAChallenge: challenge}, nil
+	}
+	if err := s.loginSucceeded(ctx, user, client); err != nil {
+		return nil, internal(err)
+	}
+	pair, err := issueTokens(ctx, s.Store, user, "")
+	if err != nil {
+		return nil, internal(err)
+	}
+	return &LoginResult{TokenPair: pair}, nil
+}
+
+// Authenticate 校验 access token 并检查 jti 是否已被吊销（退出登录或令牌族被吊销）
+func (s *Auth) Authenticate(ctx context.Context, token string) (*util.Claims, error) {
+	claims, err := util.ParseToken(token)
+	if err != nil {
+		return nil, ErrTokenInvalid.Wrap(err)
+	}
+	revoked, err := s.Store.Tokens.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, internal(err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// CheckVerified 用户是否可以发文和评论：邮箱已验证，或绑定了以太坊地址（已通过签名证明身份）
+func (s *Auth) CheckVerified(ctx context.Context, userID uint) error {
+	user, err := s.Store.Users.Find(ctx, userID)
+	if err != nil {
+		return notFound(err, ErrAccountMissing)
+	}
+	if !user.EmailVerified() && user.EthAddress == nil {
+		return ErrEmailUnverified
+	}
+	return nil
+}
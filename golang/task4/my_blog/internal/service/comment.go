@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"my_blog/internal/model"
+	"my_blog/internal/realtime"
+	"my_blog/internal/repository"
+	"my_blog/internal/search"
+	"my_blog/internal/util"
+)
+
+const (
+	defaultCommentPageSize = 20
+	maxCommentPageSize     = 100
+)
+
+// Comments 评论
+type Comments struct {
+	Store         *repository.Store
+	Searcher      search.Searcher
+	Events        Events
+	Notifications *Notifications // 为 nil 时不生成通知
+}
+
+// CommentPage 一页顶层评论，每条带出整楼回复
+type CommentPage struct {
+	Comments   []*model.Comment `json:"comments"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+// Load 按 ID 加载评论，供所有权检查使用
+func (s *Comments) Load(ctx context.Context, id uint) (model.Owned, error) {
+	comment, err := s.Store.Comments.Find(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrCommentNotFound)
+	}
+	return comment, nil
+}
+
+// Create 在已发布的文章下发表评论并累加文章的评论数；parentID 非空时为回复，父评论必须属于同一篇文章
+func (s *Comments) Create(ctx context.Context, userID, postID uint, parentID *uint, content string) (*model.Comment, error) {
+	post, err := s.Store.Posts.FindPublished(ctx, postID)
+	if err != nil {
+		return nil, notFound(err, ErrPostNotFound)
+	}
+
+	comment := &model.Comment{
+		Content: content,
+		PostID:  postID,
+		UserID:  userID,
+	}
+
+	// 楼层根节点沿用父评论的
+	var parent *model.Comment
+	if parentID != nil {
+		parent, err = s.Store.Comments.Find(ctx, *parentID)
+		if err != nil {
+			return nil, notFound(err, ErrParentNotFound)
+		}
+		if parent.PostID != postID {
+			return nil, ErrParentPostMismatch
+		}
+		comment.ParentID = &parent.ID
+		comment.RootID = parent.RootID
+		if comment.RootID == nil {
+			comment.RootID = &parent.ID
+		}
+	}
+
+	err = s.Store.Tx(ctx, func(tx *repository.Store) error {
+		if err := tx.Comments.Create(ctx, comment); err != nil {
+			return err
+		}
+		return tx.Posts.AddComments(ctx, postID, 1)
+	})
+	if err != nil {
+		return nil, internal(err)
+	}
+	indexDocument(s.Searcher, search.CommentDocument(comment))
+	publish(s.Events, realtime.PostTopic(postID), realtime.CommentCreated, newCommentEvent(comment))
+	if s.Notifications != nil {
+		s.Notifications.CommentCreated(ctx, post, parent, comment)
+	}
+	return comment, nil
+}
+
+// List 已发布文章的评论树，按顶层评论游标分页，每页带出整楼回复
+func (s *Comments) List(ctx context.Context, postID uint, cursor string, limit int) (*CommentPage, error) {
+	limit = pageLimit(limit, defaultCommentPageSize, maxCommentPageSize)
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Store.Posts.FindPublished(ctx, postID); err != nil {
+		return nil, notFound(err, ErrPostNotFound)
+	}
+
+	roots, err := s.Store.Comments.Roots(ctx, postID, after, limit+1)
+	if err != nil {
+		return nil, internal(err)
+	}
+	page := &CommentPage{}
+	if len(roots) > limit {
+		roots = roots[:limit]
+		last := roots[len(roots)-1]
+		page.NextCursor = util.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	rootIDs := make([]uint, len(roots))
+	for i, r := range roots {
+		rootIDs[i] = r.ID
+	}
+	replies, err := s.Store.Comments.Replies(ctx, rootIDs)
+	if err != nil {
+		return nil, internal(err)
+	}
+	page.Comments = buildCommentTree(roots, replies)
+	return page, nil
+}
+
+// buildCommentTree 将回复挂到各自的父评论下，被隐藏评论的内容不对外展示
+func buildCommentTree(roots, replies []*model.Comment) []*model.Comment {
+	byID := make(map[uint]*model.Comment, len(roots)+len(replies))
+	for _, cm := range roots {
+		byID[cm.ID] = cm
+	}
+	for _, cm := range replies {
+		byID[cm.ID] = cm
+	}
+	for _, cm := range byID {
+		if cm.Hidden() {
+			cm.Content = ""
+		}
+	}
+	// replies 已按时间排序，同一父评论下的回复按时间先后排列
+	for _, cm := range replies {
+		if parent, ok := byID[*cm.ParentID]; ok {
+			parent.Replies = append(parent.Replies, cm)
+		}
+	}
+	return roots
+}
+
+// Update 修改评论内容
+func (s *Comments) Update(ctx context.Context, comment *model.Comment, content string) (*model.Comment, error) {
+	if err := s.Store.Comments.SetContent(ctx, comment, content); err != nil {
+		return nil, internal(err)
+	}
+	comment.Content = content
+	s.indexComment(ctx, comment)
+	publish(s.Events, realtime.PostTopic(comment.PostID), realtime.CommentUpdated, newCommentEvent(comment))
+	return comment, nil
+}
+
+// Delete 删除评论及其全部回复，文章的评论数同步扣减
+func (s *Comments) Delete(ctx context.Context, comment *model.Comment) error {
+	var deleted []uint
+	err := s.Store.Tx(ctx, func(tx *repository.Store) error {
+		var err error
+		if deleted, err = tx.Comments.DeleteTree(ctx, comment.ID); err != nil {
+			return err
+		}
+		return tx.Posts.AddComments(ctx, comment.PostID, -int64(len(deleted)))
+	})
+	if err != nil {
+		return internal(err)
+	}
+	for _, id := range deleted {
+		removeDocument(s.Searcher, search.KindComment, id)
+	}
+	publish(s.Events, realtime.PostTopic(comment.PostID), realtime.CommentDeleted,
+		CommentsDeletedEvent{PostID: comment.PostID, IDs: deleted})
+	return nil
+}
+
+// SetHidden 版主隐藏/取消隐藏评论，隐藏后内容不再对外展示但保留楼层结构
+func (s *Comments) SetHidden(ctx context.Context, id, moderatorID uint, hidden bool) (*model.Comment, error) {
+	comment, err := s.Store.Comments.Find(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrCommentNotFound)
+	}
+
+	comment.HiddenAt, comment.HiddenBy = nil, nil
+	if hidden {
+		now := time.Now()
+		comment.HiddenAt, comment.HiddenBy = &now, &moderatorID
+	}
+	if err := s.Store.Comments.SaveHidden(ctx, comment); err != nil {
+		return nil, internal(err)
+	}
+	typ := realtime.CommentUnhidden
+	if comment.Hidden() {
+		typ = realtime.CommentHidden
+		removeDocument(s.Searcher, search.KindComment, comment.ID)
+	} else {
+		s.indexComment(ctx, comment)
+	}
+	publish(s.Events, realtime.PostTopic(comment.PostID), typ, newCommentEvent(comment))
+	return comment, nil
+}
+
+// indexComment 评论未隐藏且所属文章公开可见时才进入检索索引
+func (s *Comments) indexComment(ctx context.Context, comment *model.Comment) {
+	if s.Searcher == nil || comment.Hidden() {
+		return
+	}
+	post, err := s.Store.Posts.Find(ctx, comment.PostID)
+	if err != nil || !post.Published() {
+		return
+	}
+	indexDocument(s.Searcher, search.CommentDocument(comment))
+}
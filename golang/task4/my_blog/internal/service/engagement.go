@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+const (
+	defaultBookmarkPageSize = 20
+	maxBookmarkPageSize     = 50
+)
+
+// Engagement 点赞与收藏。重复点赞、收藏由唯一索引去重，接口是幂等的
+type Engagement struct {
+	Store *repository.Store
+}
+
+// LikeResult 点赞或取消点赞后的状态
+type LikeResult struct {
+	Liked     bool  `json:"liked"`
+	LikeCount int64 `json:"like_count"`
+}
+
+// BookmarkResult 收藏或取消收藏后的状态
+type BookmarkResult struct {
+	Bookmarked bool `json:"bookmarked"`
+}
+
+// Reaction 当前用户对文章的点赞、收藏状态
+type Reaction struct {
+	Liked      bool `json:"liked"`
+	Bookmarked bool `json:"bookmarked"`
+}
+
+// BookmarkPage 一页收藏
+type BookmarkPage struct {
+	Bookmarks  []model.Bookmark `json:"bookmarks"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+// Like 点赞已发布的文章，点赞记录与文章的点赞数在同一事务中写入
+func (s *Engagement) Like(ctx context.Context, userID, postID uint) (*LikeResult, error) {
+	return s.setLike(ctx, userID, postID, true)
+}
+
+// Unlike 取消点赞，没有赞过时直接返回当前状态
+func (s *Engagement) Unlike(ctx context.Context, userID, postID uint) (*LikeResult, error) {
+	return s.setLike(ctx, userID, postID, false)
+}
+
+func (s *Engagement) setLike(ctx context.Context, userID, postID uint, liked bool) (*LikeResult, error) {
+	var post *model.Post
+	err := s.Store.Tx(ctx, func(tx *repository.Store) error {
+		var err error
+		if post, err = findEngageable(ctx, tx, postID, liked); err != nil {
+			return err
+		}
+		var changed bool
+		if liked {
+			changed, err = tx.Likes.Add(ctx, userID, postID)
+		} else {
+			changed, err = tx.Likes.Remove(ctx, userID, postID)
+		}
+		if err != nil || !changed {
+			return err
+		}
+		delta := int64(1)
+		if !liked {
+			delta = -1
+		}
+		if err := tx.Posts.AddLikes(ctx, postID, delta); err != nil {
+			return err
+		}
+		post, err = tx.Posts.Find(ctx, postID)
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err, ErrPostNotFound)
+	}
+	return &LikeResult{Liked: liked, LikeCount: post.LikeCount}, nil
+}
+
+// Bookmark 收藏已发布的文章
+func (s *Engagement) Bookmark(ctx context.Context, userID, postID uint) (*BookmarkResult, error) {
+	if _, err := findEngageable(ctx, s.Store, postID, true); err != nil {
+		return nil, notFound(err, ErrPostNotFound)
+	}
+	if _, err := s.Store.Bookmarks.Add(ctx, userID, postID); err != nil {
+		return nil, internal(err)
+	}
+	return &BookmarkResult{Bookmarked: true}, nil
+}
+
+// Unbookmark 取消收藏，文章已不再公开时也可以取消
+func (s *Engagement) Unbookmark(ctx context.Context, userID, postID uint) (*BookmarkResult, error) {
+	if _, err := s.Store.Bookmarks.Remove(ctx, userID, postID); err != nil {
+		return nil, internal(err)
+	}
+	return &BookmarkResult{Bookmarked: false}, nil
+}
+
+// Reaction 当前用户是否赞过、收藏了文章
+func (s *Engagement) Reaction(ctx context.Context, userID, postID uint) (*Reaction, error) {
+	if _, err := s.Store.Posts.FindPublished(ctx, postID); err != nil {
+		return nil, notFound(err, ErrPostNotFound)
+	}
+	liked, err := s.Store.Likes.Exists(ctx, userID, postID)
+	if err != nil {
+		return nil, internal(err)
+	}
+	bookmarked, err := s.Store.Bookmarks.Exists(ctx, userID, postID)
+	if err != nil {
+		return nil, internal(err)
+	}
+	return &Reaction{Liked: liked, Bookmarked: bookmarked}, nil
+}
+
+// Bookmarks 用户的收藏，按收藏时间倒序游标分页
+func (s *Engagement) Bookmarks(ctx context.Context, userID uint, cursor string, size int) (*BookmarkPage, error) {
+	limit := pageLimit(size, defaultBookmarkPageSize, maxBookmarkPageSize)
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.Store.Bookmarks.List(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, internal(err)
+	}
+	page := &BookmarkPage{Bookmarks: list}
+	if len(list) > limit {
+		page.Bookmarks = list[:limit]
+		last := page.Bookmarks[limit-1]
+		page.NextCursor = util.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// findEngageable 新增点赞、收藏只允许已发布的文章；取消时文章存在即可
+func findEngageable(ctx context.Context, store *repository.Store, postID uint, adding bool) (*model.Post, error) {
+	if adding {
+		return store.Posts.FindPublished(ctx, postID)
+	}
+	return store.Posts.Find(ctx, postID)
+}
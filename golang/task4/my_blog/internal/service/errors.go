@@ -0,0 +1,116 @@
+package service
+
+import "my_blog/internal/apperr"
+
+// codes 本包定义的全部错误码，供测试检查多语言消息是否齐全
+var codes []string
+
+func newError(kind apperr.Kind, code string) *apperr.Error {
+	codes = append(codes, code)
+	return apperr.New(kind, code)
+}
+
+// ErrorCodes 本包定义的全部错误码
+func ErrorCodes() []string {
+	return append([]string(nil), codes...)
+}
+
+// 通用
+var (
+	ErrCursorInvalid = newError(apperr.Invalid, "request.cursor_invalid")
+	ErrDateInvalid   = newError(apperr.Invalid, "request.date_invalid")
+)
+
+// 认证与会话
+var (
+	ErrUnauthenticated   = newError(apperr.Unauthorized, "auth.unauthenticated")
+	ErrAuthHeaderMissing = newError(apperr.Unauthorized, "auth.header_missing")
+	ErrAuthHeaderFormat  = newError(apperr.Unauthorized, "auth.header_malformed")
+	ErrTokenInvalid      = newError(apperr.Unauthorized, "auth.token_invalid")
+	ErrTokenRevoked      = newError(apperr.Unauthorized, "auth.token_revoked")
+	ErrAccountMissing    = newError(apperr.Unauthorized, "auth.account_missing")
+	ErrForbidden         = newError(apperr.Forbidden, "auth.forbidden")
+	ErrEmailUnverified   = newError(apperr.Forbidden, "auth.email_unverified")
+	ErrBadCredentials    = newError(apperr.Unauthorized, "auth.bad_credentials")
+	ErrAccountLocked     = newError(apperr.Locked, "auth.locked")
+	ErrRefreshInvalid    = newError(apperr.Unauthorized, "auth.refresh_invalid")
+	ErrRefreshReused     = newError(apperr.Unauthorized, "auth.refresh_reused")
+	ErrRefreshExpired    = newError(apperr.Unauthorized, "auth.refresh_expired")
+)
+
+// 账号与邮箱
+var (
+	ErrUserNotFound          = newError(apperr.NotFound, "user.not_found")
+	ErrUsernameTaken         = newError(apperr.Conflict, "user.username_taken")
+	ErrEmailTaken            = newError(apperr.Conflict, "user.email_taken")
+	ErrEmailUnavailable      = newError(apperr.Invalid, "user.email_unavailable")
+	ErrRoleInvalid           = newError(apperr.Invalid, "user.role_invalid")
+	ErrChangeOwnRole         = newError(apperr.Invalid, "user.own_role")
+	ErrDeleteSelf            = newError(apperr.Invalid, "user.delete_self")
+	ErrFollowSelf            = newError(apperr.Invalid, "user.follow_self")
+	ErrVerifyLinkInvalid     = newError(apperr.Invalid, "email.link_invalid")
+	ErrVerifyLinkUsed        = newError(apperr.Invalid, "email.link_used")
+	ErrEmailAlreadyVerified  = newError(apperr.Conflict, "email.already_verified")
+	ErrWalletAccountNoEmail  = newError(apperr.Invalid, "email.wallet_account")
+	ErrPasswordWrong         = newError(apperr.Invalid, "password.wrong")
+	ErrPasswordUnchanged     = newError(apperr.Invalid, "password.unchanged")
+	ErrPasswordTooLong       = newError(apperr.Invalid, "password.too_long")
+	ErrResetLinkInvalid      = newError(apperr.Invalid, "password.reset_link_invalid")
+	ErrMFACodeRequired       = newError(apperr.Invalid, "mfa.code_required")
+	ErrMFACodeInvalid        = newError(apperr.Invalid, "mfa.code_invalid")
+	ErrMFALoginCodeInvalid   = newError(apperr.Unauthorized, "mfa.login_code_invalid")
+	ErrMFAChallengeExpired   = newError(apperr.Unauthorized, "mfa.challenge_expired")
+	ErrMFAChallengeStale     = newError(apperr.Unauthorized, "mfa.challenge_stale")
+	ErrMFAAlreadyEnabled     = newError(apperr.Conflict, "mfa.already_enabled")
+	ErrMFANotEnabled         = newError(apperr.Conflict, "mfa.not_enabled")
+	ErrMFASetupRequired      = newError(apperr.Invalid, "mfa.setup_required")
+	ErrSIWEMalformed         = newError(apperr.Invalid, "siwe.malformed")
+	ErrSIWEDomain            = newError(apperr.Unauthorized, "siwe.domain_mismatch")
+	ErrSIWEExpired           = newError(apperr.Unauthorized, "siwe.expired")
+	ErrSIWESignature         = newError(apperr.Unauthorized, "siwe.signature_invalid")
+	ErrSIWENonce             = newError(apperr.Unauthorized, "siwe.nonce_invalid")
+	ErrWalletLinkedElsewhere = newError(apperr.Conflict, "siwe.address_taken")
+)
+
+// 文章
+var (
+	ErrPostNotFound       = newError(apperr.NotFound, "post.not_found")
+	ErrPostForbidden      = newError(apperr.Forbidden, "post.forbidden")
+	ErrPostStatusInvalid  = newError(apperr.Invalid, "post.status_invalid")
+	ErrPublishAtRequired  = newError(apperr.Invalid, "post.publish_at_required")
+	ErrPostFormatInvalid  = newError(apperr.Invalid, "post.format_invalid")
+	ErrMarkdownRender     = newError(apperr.Invalid, "post.render_failed")
+	ErrRevisionNotFound   = newError(apperr.NotFound, "post.revision_not_found")
+	ErrRevisionTooLarge   = newError(apperr.TooLarge, "post.revision_too_large")
+	ErrCommentNotFound    = newError(apperr.NotFound, "comment.not_found")
+	ErrCommentForbidden   = newError(apperr.Forbidden, "comment.forbidden")
+	ErrParentNotFound     = newError(apperr.NotFound, "comment.parent_not_found")
+	ErrParentPostMismatch = newError(apperr.Invalid, "comment.parent_mismatch")
+	ErrTooManyTags        = newError(apperr.Invalid, "tag.too_many")
+	ErrTagNameInvalid     = newError(apperr.Invalid, "tag.name_invalid")
+	ErrCategoryNotFound   = newError(apperr.Invalid, "category.not_found")
+	ErrCategoryExists     = newError(apperr.Conflict, "category.exists")
+)
+
+// 实时推送
+var (
+	ErrTopicInvalid   = newError(apperr.Invalid, "realtime.topic_invalid")
+	ErrTopicForbidden = newError(apperr.Forbidden, "realtime.topic_forbidden")
+	ErrTooManyTopics  = newError(apperr.Invalid, "realtime.too_many_topics")
+	ErrSlowConsumer   = newError(apperr.TooManyRequests, "realtime.slow_consumer")
+)
+
+// 通知
+var (
+	ErrNotificationTypeInvalid = newError(apperr.Invalid, "notification.type_invalid")
+)
+
+// 上传与附件
+var (
+	ErrUploadFileMissing = newError(apperr.Invalid, "upload.file_missing")
+	ErrUploadTooLarge    = newError(apperr.TooLarge, "upload.too_large")
+	ErrUploadType        = newError(apperr.Unsupported, "upload.type_unsupported")
+	ErrImageInvalid      = newError(apperr.Invalid, "upload.image_invalid")
+	ErrImageTooLarge     = newError(apperr.TooLarge, "upload.image_too_large")
+	ErrAttachmentInvalid = newError(apperr.Invalid, "attachment.invalid")
+)
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"testing"
+
+	"my_blog/internal/i18n"
+)
+
+func TestErrorCodesHaveMessages(t *testing.T) {
+	seen := map[string]bool{}
+	for _, code := range ErrorCodes() {
+		if seen[code] {
+			t.Errorf("duplicate error code %q", code)
+		}
+		seen[code] = true
+		if !i18n.Has(code) {
+			t.Errorf("no message for error code %q", code)
+		}
+	}
+}
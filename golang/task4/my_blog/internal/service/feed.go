@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+const (
+	defaultFeedPageSize = 10
+	maxFeedPageSize     = 50
+)
+
+// FeedSource 个人信息流的生成方式：返回 userID 关注的作者已发布的文章，按 (发布时间, ID) 倒序，
+// after 非空时从该位置之后开始。
+//
+// 当前只有读扩散实现 FanOutOnRead。粉丝很多的作者可以改为写扩散（发布时写入各粉丝的收件箱），
+// 只需另行实现本接口，也可以把两种实现的结果按相同顺序归并
+type FeedSource interface {
+	Posts(ctx context.Context, userID uint, after *repository.Cursor, limit int) ([]model.Post, error)
+}
+
+// FanOutOnRead 读扩散：读取时按关注关系实时查询关注作者的文章，写入没有额外开销
+type FanOutOnRead struct {
+	Store *repository.Store
+}
+
+func (f *FanOutOnRead) Posts(ctx context.Context, userID uint, after *repository.Cursor, limit int) ([]model.Post, error) {
+	return f.Store.Posts.ListFollowed(ctx, userID, after, limit)
+}
+
+// Feed 个人首页信息流
+type Feed struct {
+	Source FeedSource
+}
+
+// FeedPage 一页信息流
+type FeedPage struct {
+	Posts      []model.Post `json:"posts"`
+	NextCursor string       `json:"next_cursor"`
+}
+
+// Page 用户关注的作者的文章，按发布时间倒序游标分页
+func (s *Feed) Page(ctx context.Context, userID uint, cursor string, size int) (*FeedPage, error) {
+	limit := pageLimit(size, defaultFeedPageSize, maxFeedPageSize)
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.Source.Posts(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, internal(err)
+	}
+	page := &FeedPage{Posts: posts}
+	if len(posts) > limit {
+		page.Posts = posts[:limit]
+		last := page.Posts[limit-1]
+		// 已发布的文章都有发布时间
+		page.NextCursor = util.EncodeCursor(*last.PublishedAt, last.ID)
+	}
+	return page, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+const (
+	defaultFollowPageSize = 20
+	maxFollowPageSize     = 100
+)
+
+// Follows 用户关注关系。关注与取消关注是幂等的，双方的计数与关注记录在同一事务中更新
+type Follows struct {
+	Store *repository.Store
+}
+
+// Profile 用户的公开资料
+type Profile struct {
+	ID             uint   `json:"id"`
+	Username       string `json:"username"`
+	Role           string `json:"role"`
+	FollowerCount  int64  `json:"follower_count"`
+	FollowingCount int64  `json:"following_count"`
+}
+
+// FollowResult 关注或取消关注后的状态
+type FollowResult struct {
+	Following     bool  `json:"following"`
+	FollowerCount int64 `json:"follower_count"` // 被关注者最新的粉丝数
+}
+
+// FollowEntry 粉丝或关注列表中的一项
+type FollowEntry struct {
+	User       Profile   `json:"user"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// FollowPage 一页粉丝或关注
+type FollowPage struct {
+	Users      []FollowEntry `json:"users"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+func newProfile(u *model.User) Profile {
+	return Profile{
+		ID:             u.ID,
+		Username:       u.Username,
+		Role:           u.Role,
+		FollowerCount:  u.FollowerCount,
+		FollowingCount: u.FollowingCount,
+	}
+}
+
+// Profile 用户的公开资料，包含粉丝数与关注数
+func (s *Follows) Profile(ctx context.Context, userID uint) (*Profile, error) {
+	user, err := s.Store.Users.Find(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	p := newProfile(user)
+	return &p, nil
+}
+
+// Follow 关注用户，不能关注自己
+func (s *Follows) Follow(ctx context.Context, followerID, followeeID uint) (*FollowResult, error) {
+	if followerID == followeeID {
+		return nil, ErrFollowSelf
+	}
+	return s.set(ctx, followerID, followeeID, true)
+}
+
+// Unfollow 取消关注，没有关注时直接返回当前状态
+func (s *Follows) Unfollow(ctx context.Context, followerID, followeeID uint) (*FollowResult, error) {
+	return s.set(ctx, followerID, followeeID, false)
+}
+
+func (s *Follows) set(ctx context.Context, followerID, followeeID uint, follow bool) (*FollowResult, error) {
+	var followee *model.User
+	err := s.Store.Tx(ctx, func(tx *repository.Store) error {
+		var err error
+		if followee, err = tx.Users.Find(ctx, followeeID); err != nil {
+			return err
+		}
+		var changed bool
+		if follow {
+			changed, err = tx.Follows.Add(ctx, followerID, followeeID)
+		} else {
+			changed, err = tx.Follows.Remove(ctx, followerID, followeeID)
+		}
+		if err != nil || !changed {
+			return err
+		}
+		delta := int64(1)
+		if !follow {
+			delta = -1
+		}
+		if err := tx.Users.AddFollowCounts(ctx, followerID, followeeID, delta); err != nil {
+			return err
+		}
+		followee, err = tx.Users.Find(ctx, followeeID)
+		return err
+	})
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	return &FollowResult{Following: follow, FollowerCount: followee.FollowerCount}, nil
+}
+
+// Followers 用户的粉丝，按关注时间倒序游标分页
+func (s *Follows) Followers(ctx context.Context, userID uint, cursor string, size int) (*FollowPage, error) {
+	return s.page(ctx, userID, cursor, size, s.Store.Follows.Followers, func(f *model.Follow) *model.User { return f.Follower })
+}
+
+// Following 用户关注的人，按关注时间倒序游标分页
+func (s *Follows) Following(ctx context.Context, userID uint, cursor string, size int) (*FollowPage, error) {
+	return s.page(ctx, userID, cursor, size, s.Store.Follows.Following, func(f *model.Follow) *model.User { return f.Followee })
+}
+
+func (s *Follows) page(ctx context.Context, userID uint, cursor string, size int,
+	list func(context.Context, uint, *repository.Cursor, int) ([]model.Follow, error),
+	user func(*model.Follow) *model.User) (*FollowPage, error) {
+	limit := pageLimit(size, defaultFollowPageSize, maxFollowPageSize)
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Store.Users.Find(ctx, userID); err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+
+	follows, err := list(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, internal(err)
+	}
+	page := &FollowPage{Users: make([]FollowEntry, 0, len(follows))}
+	if len(follows) > limit {
+		follows = follows[:limit]
+		last := follows[limit-1]
+		page.NextCursor = util.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	for i := range follows {
+		page.Users = append(page.Users, FollowEntry{User: newProfile(user(&follows[i])), FollowedAt: follows[i].CreatedAt})
+	}
+	return page, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+const (
+	// mfaPendingTTL 密码校验通过后完成两步验证的时限
+	mfaPendingTTL = 5 * time.Minute
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+// MFACode 两步验证凭据，验证码与恢复码二选一
+type MFACode struct {
+	Code         string
+	RecoveryCode string
+}
+
+func (c MFACode) empty() bool { return c.Code == "" && c.RecoveryCode == "" }
+
+// MFAChallenge 登录第一步通过后返回的两步验证挑战
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+// MFAStatus 两步验证状态
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// TOTPSetup 待确认的 TOTP 密钥
+type TOTPSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// verifyMFA 校验 TOTP 验证码或恢复码，成功时记录已用时间步或将恢复码标记为已使用。
+// 凭据错误时返回 ErrMFACodeInvalid
+func verifyMFA(ctx context.Context, tx *repository.Store, user *model.User, in MFACode) error {
+	now := time.Now()
+	switch {
+	case in.Code != "":
+		step, ok := util.ValidateTOTP(user.TOTPSecret, in.Code, now)
+		if !ok {
+			return ErrMFACodeInvalid
+		}
+		// 条件更新保证同一时间步的验证码只能使用一次
+		ok, err := tx.Users.AdvanceTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrMFACodeInvalid
+		}
+		user.TOTPLastStep = step
+		return nil
+	case in.RecoveryCode != "":
+		ok, err := tx.Tokens.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(in.RecoveryCode), now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrMFACodeInvalid
+		}
+		return nil
+	}
+	return ErrMFACodeInvalid
+}
+
+// hashRecoveryCode 忽略大小写、空格与连字符后取哈希
+func hashRecoveryCode(code string) string {
+	code = strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	return util.HashToken(code)
+}
+
+// replaceRecoveryCodes 作废旧的恢复码并生成一组新的，返回明文（只展示一次）
+func replaceRecoveryCodes(ctx context.Context, tx *repository.Store, userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := util.RandomToken(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(raw)
+	}
+	if err := tx.Tokens.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// clearMFA 关闭两步验证：清除密钥并删除恢复码
+func clearMFA(ctx context.Context, st *repository.Store, userID uint) error {
+	return st.Tx(ctx, func(tx *repository.Store) error {
+		if err := tx.Users.ClearMFA(ctx, userID); err != nil {
+			return err
+		}
+		return tx.Tokens.DeleteRecoveryCodes(ctx, userID)
+	})
+}
+
+// startMFALogin 签发一次性的 mfa_pending 令牌，该令牌不能当作 access token 使用
+func (s *Auth) startMFALogin(ctx context.Context, user *model.User) (*MFAChallenge, error) {
+	at, err := s.issueActionToken(ctx, user.ID, model.PurposeMFALogin, mfaPendingTTL)
+	if err != nil {
+		return nil, internal(err)
+	}
+	return &MFAChallenge{
+		MFARequired: true,
+		MFAToken:    at.Token,
+		ExpiresAt:   at.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// LoginMFA 两步登录的第二步：用 mfa_token 与验证码（或恢复码）换取令牌对。
+// 验证码错误计入连续登录失败次数，mfa_token 在成功前可重试
+func (s *Auth) LoginMFA(ctx context.Context, mfaToken string, in MFACode, client Client) (*TokenPair, error) {
+	if in.empty() {
+		return nil, ErrMFACodeRequired
+	}
+	pending, err := util.ParseActionToken(mfaToken, model.PurposeMFALogin)
+	if err != nil {
+		return nil, ErrMFAChallengeExpired.Wrap(err)
+	}
+	user, err := s.Store.Users.Find(ctx, pending.UserID)
+	if err != nil {
+		return nil, notFound(err, ErrAccountMissing)
+	}
+	if now := time.Now(); user.Locked(now) {
+		return nil, s.locked(ctx, user, client, now)
+	}
+	if !user.MFAEnabled() {
+		return nil, ErrMFAChallengeStale
+	}
+
+	// 验证失败时事务回滚，mfa_token 不会被标记为已使用
+	err = s.consumeActionToken(ctx, mfaToken, model.PurposeMFALogin, func(tx *repository.Store, _ uint) error {
+		return verifyMFA(ctx, tx, user, in)
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrMFACodeInvalid):
+		_ = s.loginFailed(ctx, user, client, model.LoginFailBadMFACode)
+		return nil, ErrMFALoginCodeInvalid
+	case errors.Is(err, errActionTokenInvalid), errors.Is(err, errActionTokenUsed):
+		return nil, ErrMFAChallengeExpired
+	default:
+		return nil, internal(err)
+	}
+
+	if err := s.loginSucceeded(ctx, user, client); err != nil {
+		return nil, internal(err)
+	}
+	pair, err := issueTokens(ctx, s.Store, user, "")
+	return pair, internal(err)
+}
+
+// MFAStatus 用户的两步验证状态
+func (s *Auth) MFAStatus(ctx context.Context, userID uint) (*MFAStatus, error) {
+	user, err := s.Store.Users.Find(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	remaining, err := s.Store.Tokens.CountRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, internal(err)
+	}
+	return &MFAStatus{
+		Enabled:                user.MFAEnabled(),
+		EnabledAt:              user.MFAEnabledAt,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// SetupTOTP 开始启用两步验证：生成新的密钥与 otpauth:// 链接，需调用 ConfirmTOTP 后才生效
+func (s *Auth) SetupTOTP(ctx context.Context, userID uint) (*TOTPSetup, error) {
+	user, err := s.Store.Users.Find(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return nil, internal(err)
+	}
+	if err := s.Store.Users.SetTOTPSecret(ctx, user.ID, secret); err != nil {
+		return nil, internal(err)
+	}
+	return &TOTPSetup{
+		Secret:     secret,
+		OTPAuthURI: util.TOTPURI(s.security().MFAIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP 用验证器 App 生成的验证码确认启用两步验证，返回一次性恢复码（只展示一次）
+func (s *Auth) ConfirmTOTP(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.Store.Users.Find(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFASetupRequired
+	}
+
+	var codes []string
+	err = s.Store.Tx(ctx, func(tx *repository.Store) error {
+		if err := verifyMFA(ctx, tx, user, MFACode{Code: code}); err != nil {
+			return err
+		}
+		if err := tx.Users.EnableMFA(ctx, user.ID, time.Now()); err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(ctx, tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, internal(err)
+	}
+	return codes, nil
+}
+
+// DisableMFA 关闭两步验证，需同时提供密码与验证码（或恢复码）
+func (s *Auth) DisableMFA(ctx context.Context, userID uint, password string, in MFACode) error {
+	if in.empty() {
+		return ErrMFACodeRequired
+	}
+	user, err := s.Store.Users.Find(ctx, userID)
+	if err != nil {
+		return notFound(err, ErrUserNotFound)
+	}
+	if !user.MFAEnabled() {
+		return ErrMFANotEnabled
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return ErrPasswordWrong
+	}
+	if err := verifyMFA(ctx, s.Store, user, in); err != nil {
+		return internal(err)
+	}
+	return internal(clearMFA(ctx, s.Store, user.ID))
+}
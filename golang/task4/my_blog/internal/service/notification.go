@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"my_blog/internal/mail"
+	"my_blog/internal/model"
+	"my_blog/internal/realtime"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+	maxMentions                 = 10  // 单条评论最多通知的 @ 用户数
+	notificationExcerptRunes    = 100 // 通知中评论摘要的长度
+)
+
+// mentionPattern @用户名：@ 前不能是字母、数字等（排除邮箱地址），用户名由字母、数字、下划线、点和连字符组成
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@-])@([\p{L}\p{N}_][\p{L}\p{N}_.-]*)`)
+
+// Notifications 站内通知：评论发表后为文章作者、被回复者和被 @ 的用户生成通知，
+// 按各自的设置写入站内通知（并实时推送到 user:<id>）或发送邮件
+type Notifications struct {
+	Store   *repository.Store
+	Mailer  mail.Mailer // 通知邮件的发送队列（*mail.Queue），为 nil 时不发送邮件
+	BaseURL string      // 邮件中的站点地址
+	Events  Events
+}
+
+// NotificationPage 一页通知
+type NotificationPage struct {
+	Notifications []model.Notification `json:"notifications"`
+	NextCursor    string               `json:"next_cursor"`
+}
+
+// UnreadCount 未读通知数
+type UnreadCount struct {
+	Total  int64            `json:"total"`
+	ByType map[string]int64 `json:"by_type"`
+}
+
+// ReadResult 标记已读的结果
+type ReadResult struct {
+	Updated int64        `json:"updated"`
+	Unread  *UnreadCount `json:"unread"`
+}
+
+// NotificationEvent 新通知的实时推送
+type NotificationEvent struct {
+	Notification *model.Notification `json:"notification"`
+	Unread       *UnreadCount        `json:"unread"`
+}
+
+// PreferencePatch 修改某类通知的设置，nil 表示不修改
+type PreferencePatch struct {
+	Type  string
+	InApp *bool
+	Email *bool
+}
+
+// parseMentions 提取评论中 @ 的用户名，去重后至多 maxMentions 个
+func parseMentions(content string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimRight(m[1], ".-") // 句末的标点不属于用户名
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+	return names
+}
+
+// excerpt 截取评论开头作为通知摘要
+func excerpt(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	if utf8.RuneCountInString(content) <= notificationExcerptRunes {
+		return content
+	}
+	return string([]rune(content)[:notificationExcerptRunes]) + "…"
+}
+
+// CommentCreated 为新评论生成通知。每个接收者至多收到一条，优先级为回复、@、文章评论；
+// 评论者本人不会收到通知。失败只记录日志，不影响评论本身
+func (s *Notifications) CommentCreated(ctx context.Context, post *model.Post, parent, comment *model.Comment) {
+	if err := s.commentCreated(ctx, post, parent, comment); err != nil {
+		log.Printf("notification: comment %d: %v", comment.ID, err)
+	}
+}
+
+func (s *Notifications) commentCreated(ctx context.Context, post *model.Post, parent, comment *model.Comment) error {
+	types := make(map[uint]string)
+	var ids []uint
+	add := func(userID uint, typ string) {
+		if userID == comment.UserID || types[userID] != "" {
+			return
+		}
+		types[userID] = typ
+		ids = append(ids, userID)
+	}
+
+	if parent != nil {
+		add(parent.UserID, model.NotifyReply)
+	}
+	if names := parseMentions(comment.Content); len(names) > 0 {
+		mentioned, err := s.Store.Users.FindByUsernames(ctx, names)
+		if err != nil {
+			return err
+		}
+		for _, u := range mentioned {
+			add(u.ID, model.NotifyMention)
+		}
+	}
+	add(post.UserID, model.NotifyComment)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	actor, err := s.Store.Users.Find(ctx, comment.UserID)
+	if err != nil {
+		return err
+	}
+	recipients, err := s.Store.Users.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	prefs, err := s.preferenceMap(ctx, ids...)
+	if err != nil {
+		return err
+	}
+
+	for i := range recipients {
+		user := &recipients[i]
+		typ := types[user.ID]
+		pref := prefs.get(user.ID, typ)
+		n := &model.Notification{
+			UserID:    user.ID,
+			ActorID:   actor.ID,
+			Actor:     actor.Username,
+			Type:      typ,
+			PostID:    post.ID,
+			CommentID: comment.ID,
+			Excerpt:   excerpt(comment.Content),
+		}
+		if pref.InApp {
+			if err := s.Store.Notifications.Create(ctx, n); err != nil {
+				return err
+			}
+			s.publish(ctx, user.ID, realtime.NotificationCreated, func(unread *UnreadCount) any {
+				return NotificationEvent{Notification: n, Unread: unread}
+			})
+		}
+		// 通知已在请求内写入，邮件只放入队列由后台发送，不阻塞评论请求
+		if pref.Email && user.EmailVerified() && s.Mailer != nil {
+			if err := s.Mailer.Send(ctx, s.message(user, post, n)); err != nil {
+				log.Printf("notification: mail to user %d: %v", user.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// message 通知邮件
+func (s *Notifications) message(user *model.User, post *model.Post, n *model.Notification) mail.Message {
+	var subject, action string
+	switch n.Type {
+	case model.NotifyReply:
+		subject = fmt.Sprintf("%s 回复了你的评论", n.Actor)
+		action = fmt.Sprintf("%s 在《%s》中回复了你的评论", n.Actor, post.Title)
+	case model.NotifyMention:
+		subject = fmt.Sprintf("%s 在评论中提到了你", n.Actor)
+		action = fmt.Sprintf("%s 在《%s》的评论中提到了你", n.Actor, post.Title)
+	default:
+		subject = fmt.Sprintf("《%s》有新评论", post.Title)
+		action = fmt.Sprintf("%s 评论了你的文章《%s》", n.Actor, post.Title)
+	}
+	return mail.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body: fmt.Sprintf("%s，你好：\n\n%s：\n\n%s\n\n登录 %s 查看全部通知。不想再收到此类邮件，可以在通知设置中关闭邮件提醒。",
+			user.Username, action, n.Excerpt, strings.TrimRight(s.BaseURL, "/")),
+	}
+}
+
+// publish 向用户推送通知事件，附带最新的未读数
+func (s *Notifications) publish(ctx context.Context, userID uint, typ string, data func(*UnreadCount) any) {
+	if s.Events == nil {
+		return
+	}
+	unread, err := s.Unread(ctx, userID)
+	if err != nil {
+		log.Printf("notification: unread count for user %d: %v", userID, err)
+		return
+	}
+	s.Events.Publish(realtime.UserTopic(userID), typ, data(unread))
+}
+
+// List 用户的通知，按时间倒序游标分页
+func (s *Notifications) List(ctx context.Context, userID uint, cursor string, size int, unreadOnly bool) (*NotificationPage, error) {
+	limit := pageLimit(size, defaultNotificationPageSize, maxNotificationPageSize)
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.Store.Notifications.List(ctx, userID, unreadOnly, after, limit+1)
+	if err != nil {
+		return nil, internal(err)
+	}
+	page := &NotificationPage{Notifications: list}
+	if len(list) > limit {
+		page.Notifications = list[:limit]
+		last := page.Notifications[limit-1]
+		page.NextCursor = util.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// Unread 未读通知数
+func (s *Notifications) Unread(ctx context.Context, userID uint) (*UnreadCount, error) {
+	counts, err := s.Store.Notifications.UnreadCounts(ctx, userID)
+	if err != nil {
+		return nil, internal(err)
+	}
+	unread := &UnreadCount{ByType: make(map[string]int64, len(model.NotificationTypes))}
+	for _, typ := range model.NotificationTypes {
+		unread.ByType[typ] = counts[typ]
+		unread.Total += counts[typ]
+	}
+	return unread, nil
+}
+
+// MarkRead 将指定通知标记为已读，不属于该用户的通知忽略
+func (s *Notifications) MarkRead(ctx context.Context, userID uint, ids []uint) (*ReadResult, error) {
+	updated, err := s.Store.Notifications.MarkRead(ctx, userID, ids, time.Now())
+	if err != nil {
+		return nil, internal(err)
+	}
+	return s.readResult(ctx, userID, updated)
+}
+
+// MarkAllRead 将全部通知标记为已读
+func (s *Notifications) MarkAllRead(ctx context.Context, userID uint) (*ReadResult, error) {
+	updated, err := s.Store.Notifications.MarkAllRead(ctx, userID, time.Now())
+	if err != nil {
+		return nil, internal(err)
+	}
+	return s.readResult(ctx, userID, updated)
+}
+
+func (s *Notifications) readResult(ctx context.Context, userID uint, updated int64) (*ReadResult, error) {
+	unread, err := s.Unread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if updated > 0 {
+		publish(s.Events, realtime.UserTopic(userID), realtime.NotificationRead, unread)
+	}
+	return &ReadResult{Updated: updated, Unread: unread}, nil
+}
+
+// preferences 多个用户的通知设置，按 (用户, 类型) 索引
+type preferences map[uint]map[string]model.NotificationPreference
+
+func (p preferences) get(userID uint, typ string) model.NotificationPreference {
+	if pref, ok := p[userID][typ]; ok {
+		return pref
+	}
+	return model.DefaultNotificationPreference(userID, typ)
+}
+
+func (p preferences) set(pref model.NotificationPreference) {
+	if p[pref.UserID] == nil {
+		p[pref.UserID] = make(map[string]model.NotificationPreference)
+	}
+	p[pref.UserID][pref.Type] = pref
+}
+
+func (s *Notifications) preferenceMap(ctx context.Context, userIDs ...uint) (preferences, error) {
+	saved, err := s.Store.Notifications.Preferences(ctx, userIDs...)
+	if err != nil {
+		return nil, err
+	}
+	prefs := make(preferences, len(userIDs))
+	for _, pref := range saved {
+		prefs.set(pref)
+	}
+	return prefs, nil
+}
+
+// Preferences 用户全部类型的通知设置，未设置的类型使用默认值
+func (s *Notifications) Preferences(ctx context.Context, userID uint) ([]model.NotificationPreference, error) {
+	prefs, err := s.preferenceMap(ctx, userID)
+	if err != nil {
+		return nil, internal(err)
+	}
+	list := make([]model.NotificationPreference, len(model.NotificationTypes))
+	for i, typ := range model.NotificationTypes {
+		list[i] = prefs.get(userID, typ)
+	}
+	return list, nil
+}
+
+// UpdatePreferences 修改通知设置，返回修改后的全部设置
+func (s *Notifications) UpdatePreferences(ctx context.Context, userID uint, patches []PreferencePatch) ([]model.NotificationPreference, error) {
+	for _, p := range patches {
+		if !model.ValidNotificationType(p.Type) {
+			return nil, ErrNotificationTypeInvalid
+		}
+	}
+	prefs, err := s.preferenceMap(ctx, userID)
+	if err != nil {
+		return nil, internal(err)
+	}
+
+	// 同一类型出现多次时依次合并，每种类型只写入一行
+	touched := make(map[string]bool)
+	for _, p := range patches {
+		pref := prefs.get(userID, p.Type)
+		if p.InApp != nil {
+			pref.InApp = *p.InApp
+		}
+		if p.Email != nil {
+			pref.Email = *p.Email
+		}
+		pref.ID, pref.UpdatedAt = 0, time.Now()
+		prefs.set(pref)
+		touched[p.Type] = true
+	}
+	var changed []model.NotificationPreference
+	for _, typ := range model.NotificationTypes {
+		if touched[typ] {
+			changed = append(changed, prefs.get(userID, typ))
+		}
+	}
+	if len(changed) > 0 {
+		if err := s.Store.Notifications.SavePreferences(ctx, changed); err != nil {
+			return nil, internal(err)
+		}
+	}
+	return s.Preferences(ctx, userID)
+}
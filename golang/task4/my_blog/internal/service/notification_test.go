@@ -0,0 +1,40 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"@alice 你好", "alice"},
+		{"谢谢 @bob 和 @张三。", "bob,张三"},
+		{"cc @alice, @alice @carol.", "alice,carol"},
+		{"(@dave) @e.f-g_h", "dave,e.f-g_h"},
+		{"mail me at alice@example.com", ""},
+		{"@@alice @ bob", ""},
+	}
+	for _, c := range cases {
+		if got := strings.Join(parseMentions(c.in), ","); got != c.want {
+			t.Errorf("parseMentions(%q) = %q, expected %q", c.in, got, c.want)
+		}
+	}
+
+	many := "@u1 @u2 @u3 @u4 @u5 @u6 @u7 @u8 @u9 @u10 @u11 @u12"
+	if got := parseMentions(many); len(got) != maxMentions {
+		t.Errorf("expected at most %d mentions, got %v", maxMentions, got)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if got := excerpt("  多行\n\n评论  "); got != "多行 评论" {
+		t.Errorf("unexpected excerpt %q", got)
+	}
+	long := strings.Repeat("字", notificationExcerptRunes+5)
+	if got := excerpt(long); got != strings.Repeat("字", notificationExcerptRunes)+"…" {
+		t.Errorf("unexpected truncated excerpt %q", got)
+	}
+}
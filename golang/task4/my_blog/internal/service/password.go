@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"my_blog/internal/mail"
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+// ChangePassword 修改密码，需提供原密码；成功后除当前会话外全部下线
+func (s *Auth) ChangePassword(ctx context.Context, sess Session, oldPassword, newPassword string) error {
+	user, err := s.Store.Users.Find(ctx, sess.UserID)
+	if err != nil {
+		return notFound(err, ErrUserNotFound)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)) != nil {
+		return ErrPasswordWrong
+	}
+	if oldPassword == newPassword {
+		return ErrPasswordUnchanged
+	}
+
+	hashed, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.Users.SetPassword(ctx, user.ID, hashed, false); err != nil {
+		return internal(err)
+	}
+
+	if err := s.revokeSessions(ctx, user.ID, s.currentFamily(ctx, sess.JTI)); err != nil {
+		log.Printf("revoke sessions of user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// hashPassword 计算密码的 bcrypt 哈希。bcrypt 只接受 72 字节以内的密码，
+// 绑定校验按字符计数，多字节字符仍可能超限，这里再按字节检查一次
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", ErrPasswordTooLong
+	}
+	if err != nil {
+		return "", internal(err)
+	}
+	return string(hashed), nil
+}
+
+// ForgotPassword 向已注册的邮箱发送重置密码邮件。邮箱未注册时同样返回成功，避免探测账号
+func (s *Auth) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.Store.Users.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil
+	case err != nil:
+		return internal(err)
+	}
+	if err := s.sendPasswordReset(ctx, user); err != nil {
+		log.Printf("send password reset mail to user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// sendPasswordReset 生成重置令牌（库中只保存哈希）并把邮件放入发送队列
+func (s *Auth) sendPasswordReset(ctx context.Context, user *model.User) error {
+	token, err := util.RandomToken(32)
+	if err != nil {
+		return err
+	}
+	rt := model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(s.security().PasswordResetTTL),
+	}
+	if err := s.Store.Tokens.CreatePasswordReset(ctx, &rt); err != nil {
+		return err
+	}
+
+	// 链接指向前端的重置页面，由页面将令牌与新密码提交到 /api/password/reset
+	link := strings.TrimRight(s.BaseURL, "/") + "/reset-password?token=" + url.QueryEscape(token)
+	mailer := s.Mailer
+	if s.Queue != nil {
+		mailer = s.Queue
+	}
+	return mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "重置密码",
+		Body: fmt.Sprintf("%s，你好：\n\n请在 %s 前打开以下链接重置密码：\n\n%s\n\n如果这不是你本人的操作，请忽略本邮件，你的密码不会改变。",
+			user.Username, rt.ExpiresAt.Format("2006-01-02 15:04"), link),
+	})
+}
+
+// ResetPassword 使用邮件中的令牌重置密码。令牌只能使用一次，成功后解除锁定并下线所有会话
+func (s *Auth) ResetPassword(ctx context.Context, token, newPassword string) error {
+	hashed, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	var userID uint
+	err = s.Store.Tx(ctx, func(tx *repository.Store) error {
+		rt, err := tx.Tokens.FindPasswordReset(ctx, util.HashToken(token))
+		if err != nil {
+			return notFound(err, ErrResetLinkInvalid)
+		}
+
+		// 条件更新保证令牌只能使用一次，同时使该用户其余未使用的重置令牌失效
+		now := time.Now()
+		ok, err := tx.Tokens.UsePasswordReset(ctx, rt.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrResetLinkInvalid
+		}
+		if err := tx.Tokens.ExpirePasswordResets(ctx, rt.UserID, now); err != nil {
+			return err
+		}
+		userID = rt.UserID
+		return tx.Users.SetPassword(ctx, rt.UserID, hashed, true)
+	})
+	if err != nil {
+		return internal(err)
+	}
+
+	if err := s.revokeSessions(ctx, userID, ""); err != nil {
+		log.Printf("revoke sessions of user %d: %v", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"my_blog/internal/markdown"
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/search"
+	"my_blog/internal/util"
+)
+
+const (
+	defaultPostPageSize = 10
+	maxPostPageSize     = 50
+)
+
+// Get 支持的内容格式
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Posts 文章与历史版本
+type Posts struct {
+	Store          *repository.Store
+	Searcher       search.Searcher
+	Events         Events
+	Views          *ViewCounter  // 为 nil 时不统计阅读数
+	TrendingWindow time.Duration // 热门榜统计的发布时间范围，0 为不限
+}
+
+// PostInput 新建文章的内容
+type PostInput struct {
+	Title      string
+	Content    string
+	Tags       []string
+	Categories []string
+	Status     string // 为空时直接发布
+	PublishAt  *time.Time
+	// AttachmentIDs 文章使用的附件，必须是作者本人上传且未被其他文章使用的
+	AttachmentIDs []uint
+}
+
+// PostPatch 更新文章的字段，nil 表示不修改；Tags、Categories 非 nil 时整体替换
+type PostPatch struct {
+	Title      *string
+	Content    *string
+	Tags       *[]string
+	Categories *[]string
+	Status     *string
+	PublishAt  *time.Time
+	// AttachmentIDs 非 nil 时整体替换，移除的附件在保留期后被清理；新增的附件必须由文章作者上传
+	AttachmentIDs *[]uint
+}
+
+// PostListQuery 公开文章列表的参数
+type PostListQuery struct {
+	Cursor   string
+	Size     int
+	Sort     string // newest（默认）、most_commented、most_liked 或 trending
+	AuthorID uint
+	Tag      string
+	Category string
+	From     string // 发布时间的范围，RFC3339 或 2006-01-02
+	To       string
+}
+
+// PostPage 一页文章
+type PostPage struct {
+	Posts      []model.Post `json:"posts"`
+	NextCursor string       `json:"next_cursor"`
+	Total      int64        `json:"total"`
+}
+
+// Load 按 ID 加载文章（任意状态），供所有权检查使用
+func (s *Posts) Load(ctx context.Context, id uint) (model.Owned, error) {
+	post, err := s.Store.Posts.Find(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrPostNotFound)
+	}
+	return post, nil
+}
+
+// Create 创建文章并保存第一个版本
+func (s *Posts) Create(ctx context.Context, userID uint, in PostInput) (*model.Post, error) {
+	if in.Status == "" {
+		in.Status = model.PostStatusPublished
+	}
+	post := &model.Post{
+		Title:   in.Title,
+		Content: in.Content,
+		UserID:  userID,
+	}
+	if err := renderPost(post); err != nil {
+		return nil, ErrMarkdownRender.Wrap(err)
+	}
+	if err := applyStatus(post, in.Status, in.PublishAt); err != nil {
+		return nil, err
+	}
+
+	err := s.Store.Tx(ctx, func(tx *repository.Store) error {
+		var err error
+		if post.Tags, err = resolveTags(ctx, tx, in.Tags); err != nil {
+			return err
+		}
+		if post.Categories, err = resolveCategories(ctx, tx, in.Categories); err != nil {
+			return err
+		}
+		if err := tx.Posts.Create(ctx, post); err != nil {
+			return err
+		}
+		if err := linkAttachments(ctx, tx, post.ID, post.UserID, in.AttachmentIDs); err != nil {
+			return err
+		}
+		return tx.Posts.CreateRevision(ctx, post, post.UserID)
+	})
+	if err != nil {
+		return nil, internal(err)
+	}
+
+	syncPostIndex(s.Searcher, post)
+	if err := s.Store.Posts.Reload(ctx, post); err != nil {
+		return nil, internal(err)
+	}
+	PublishPost(s.Events, post.ID, post, false)
+	return post, nil
+}
+
+// List 已发布文章列表，支持游标分页、排序与筛选
+func (s *Posts) List(ctx context.Context, in PostListQuery) (*PostPage, error) {
+	q := repository.PostQuery{
+		AuthorID: in.AuthorID,
+		Tag:      strings.ToLower(strings.TrimSpace(in.Tag)),
+		Category: strings.ToLower(strings.TrimSpace(in.Category)),
+		Sort:     in.Sort,
+		Limit:    pageLimit(in.Size, defaultPostPageSize, maxPostPageSize),
+	}
+	if q.Sort == "" {
+		q.Sort = repository.SortNewest
+	}
+	if q.Sort == repository.SortTrending && s.TrendingWindow > 0 {
+		since := time.Now().Add(-s.TrendingWindow)
+		q.Since = &since
+	}
+	if in.From != "" {
+		from, err := parseDateParam(in.From)
+		if err != nil {
+			return nil, ErrDateInvalid.With("field", "from").Wrap(err)
+		}
+		q.From = &from
+	}
+	if in.To != "" {
+		to, err := parseDateParam(in.To)
+		if err != nil {
+			return nil, ErrDateInvalid.With("field", "to").Wrap(err)
+		}
+		q.To = &to
+	}
+	if in.Cursor != "" {
+		if q.Sort != repository.SortNewest {
+			key, id, err := util.DecodeKeyCursor(in.Cursor)
+			if err != nil {
+				return nil, ErrCursorInvalid.Wrap(err)
+			}
+			q.AfterKey = &repository.KeyCursor{Key: key, ID: id}
+		} else {
+			after, err := decodeCursor(in.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			q.After = after
+		}
+	}
+
+	size := q.Limit
+	q.Limit++
+	posts, total, err := s.Store.Posts.ListPublished(ctx, q)
+	if err != nil {
+		return nil, internal(err)
+	}
+
+	page := &PostPage{Posts: posts, Total: total}
+	if len(posts) > size {
+		page.Posts = posts[:size]
+		last := page.Posts[len(page.Posts)-1]
+		if q.Sort != repository.SortNewest {
+			page.NextCursor = util.EncodeKeyCursor(repository.SortKey(q.Sort, &last), last.ID)
+		} else {
+			// 已发布的文章都有发布时间
+			page.NextCursor = util.EncodeCursor(*last.PublishedAt, last.ID)
+		}
+	}
+	return page, nil
+}
+
+// parseDateParam 解析 RFC3339 或 2006-01-02 格式的时间参数
+func parseDateParam(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+// Get 已发布文章的详情并记一次阅读，format 为 html 时 Content 为渲染后的 HTML，默认为 Markdown 源文
+func (s *Posts) Get(ctx context.Context, id uint, format string) (*model.Post, error) {
+	if format == "" {
+		format = FormatMarkdown
+	}
+	if format != FormatMarkdown && format != FormatHTML {
+		return nil, ErrPostFormatInvalid
+	}
+
+	post, err := s.Store.Posts.FindPublished(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrPostNotFound)
+	}
+	if format == FormatHTML {
+		// 早于 Markdown 渲染上线的文章没有预渲染结果，现场渲染
+		if post.ContentHTML == "" && post.Content != "" {
+			if err := renderPost(post); err != nil {
+				return nil, internal(err)
+			}
+		}
+		post.Content = post.ContentHTML
+	}
+	if s.Views != nil {
+		s.Views.Add(post.ID)
+		// 返回值包含尚未写入数据库的阅读数
+		post.ViewCount += s.Views.Pending(post.ID)
+	}
+	return post, nil
+}
+
+// Mine 用户自己的文章，包含草稿、定时和归档文章
+func (s *Posts) Mine(ctx context.Context, userID uint, status string) ([]model.Post, error) {
+	if status != "" && !model.ValidPostStatus(status) {
+		return nil, ErrPostStatusInvalid
+	}
+	posts, err := s.Store.Posts.ListByUser(ctx, userID, status)
+	return posts, internal(err)
+}
+
+// Update 更新文章，内容变更时保存新版本
+func (s *Posts) Update(ctx context.Context, post *model.Post, editorID uint, in PostPatch) (*model.Post, error) {
+	wasPublished := post.Published()
+	contentChanged := false
+	if in.Title != nil && *in.Title != post.Title {
+		post.Title = *in.Title
+		contentChanged = true
+	}
+	if in.Content != nil && *in.Content != post.Content {
+		post.Content = *in.Content
+		contentChanged = true
+	}
+	if contentChanged {
+		if err := renderPost(post); err != nil {
+			return nil, ErrMarkdownRender.Wrap(err)
+		}
+	}
+	if in.Status != nil {
+		if err := applyStatus(post, *in.Status, in.PublishAt); err != nil {
+			return nil, err
+		}
+	}
+
+	err := s.Store.Tx(ctx, func(tx *repository.Store) error {
+		if err := tx.Posts.Save(ctx, post); err != nil {
+			return err
+		}
+		if contentChanged {
+			if err := tx.Posts.CreateRevision(ctx, post, editorID); err != nil {
+				return err
+			}
+		}
+		if in.Tags != nil {
+			tags, err := resolveTags(ctx, tx, *in.Tags)
+			if err != nil {
+				return err
+			}
+			if err := tx.Posts.ReplaceTags(ctx, post, tags); err != nil {
+				return err
+			}
+		}
+		if in.Categories != nil {
+			categories, err := resolveCategories(ctx, tx, *in.Categories)
+			if err != nil {
+				return err
+			}
+			if err := tx.Posts.ReplaceCategories(ctx, post, categories); err != nil {
+				return err
+			}
+		}
+		if in.AttachmentIDs != nil {
+			if err := linkAttachments(ctx, tx, post.ID, post.UserID, *in.AttachmentIDs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, internal(err)
+	}
+
+	syncPostIndex(s.Searcher, post)
+	if post.Published() && !wasPublished {
+		// 取消发布时评论随文章移出了索引，重新发布后恢复
+		s.indexComments(ctx, post.ID)
+	}
+	if err := s.Store.Posts.Reload(ctx, post); err != nil {
+		return nil, internal(err)
+	}
+	PublishPost(s.Events, post.ID, post, wasPublished)
+	return post, nil
+}
+
+// PublishDue 发布所有 publish_at 不晚于 now 的定时文章，返回发布数量。
+// 与编辑时发布相同：文章及其评论进入检索索引，并推送实时事件
+func (s *Posts) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.Store.Posts.DueScheduled(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for i := range due {
+		post := &due[i]
+		ok, err := s.Store.Posts.PublishScheduled(ctx, post)
+		if err != nil {
+			return published, err
+		}
+		if !ok {
+			continue
+		}
+		published++
+		post.Status, post.PublishedAt, post.PublishAt = model.PostStatusPublished, post.PublishAt, nil
+		syncPostIndex(s.Searcher, post)
+		// 曾经发布过的文章可能已有评论，取消发布时它们随文章移出了索引
+		s.indexComments(ctx, post.ID)
+		PublishPost(s.Events, post.ID, post, false)
+	}
+	return published, nil
+}
+
+// Delete 删除文章并移出检索索引，文章的附件成为待清理的孤儿
+func (s *Posts) Delete(ctx context.Context, post *model.Post) error {
+	err := s.Store.Tx(ctx, func(tx *repository.Store) error {
+		if err := tx.Attachments.Detach(ctx, post.ID, nil); err != nil {
+			return err
+		}
+		return tx.Posts.Delete(ctx, post)
+	})
+	if err != nil {
+		return internal(err)
+	}
+	removeDocument(s.Searcher, search.KindPost, post.ID)
+	PublishPost(s.Events, post.ID, nil, post.Published())
+	return nil
+}
+
+// renderPost 由 Markdown 源文生成净化后的 HTML、摘要和阅读时长
+func renderPost(post *model.Post) error {
+	rendered, err := markdown.Render(post.Content)
+	if err != nil {
+		return err
+	}
+	text := markdown.PlainText(rendered)
+	post.ContentHTML = rendered
+	post.Excerpt = markdown.Excerpt(text)
+	post.ReadingTime = markdown.ReadingMinutes(text)
+	return nil
+}
+
+// applyStatus 校验并设置文章状态：定时发布必须给出未来的 publish_at，首次发布记录发布时间
+func applyStatus(post *model.Post, status string, publishAt *time.Time) error {
+	if !model.ValidPostStatus(status) {
+		return ErrPostStatusInvalid
+	}
+
+	post.Status = status
+	post.PublishAt = nil
+	switch status {
+	case model.PostStatusScheduled:
+		if publishAt == nil || !publishAt.After(time.Now()) {
+			return ErrPublishAtRequired
+		}
+		post.PublishAt = publishAt
+	case model.PostStatusPublished:
+		if post.PublishedAt == nil {
+			now := time.Now()
+			post.PublishedAt = &now
+		}
+	}
+	return nil
+}
+
+// indexComments 将文章下未隐藏的评论加入检索索引，失败只记录日志
+func (s *Posts) indexComments(ctx context.Context, postID uint) {
+	if s.Searcher == nil {
+		return
+	}
+	comments, err := s.Store.Comments.Visible(ctx, postID)
+	if err != nil {
+		log.Printf("search: load comments of post %d: %v", postID, err)
+		return
+	}
+	for i := range comments {
+		indexDocument(s.Searcher, search.CommentDocument(&comments[i]))
+	}
+}
+
+// syncPostIndex 已发布的文章进入检索索引，其余状态（连同文章的评论）从索引中移除
+func syncPostIndex(s search.Searcher, post *model.Post) {
+	if post.Published() {
+		indexDocument(s, search.PostDocument(post))
+	} else {
+		removeDocument(s, search.KindPost, post.ID)
+	}
+}
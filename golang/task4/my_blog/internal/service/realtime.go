@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"my_blog/internal/model"
+	"my_blog/internal/realtime"
+	"my_blog/internal/repository"
+)
+
+// Events 实时事件的发布者（realtime.Hub），为 nil 时不推送
+type Events interface {
+	Publish(topic, typ string, data any)
+}
+
+// PostEvent 推送的文章摘要
+type PostEvent struct {
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Excerpt     string     `json:"excerpt"`
+	UserID      uint       `json:"user_id"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// CommentEvent 推送的评论，不含作者账号信息；被隐藏的评论不带内容
+type CommentEvent struct {
+	ID        uint      `json:"id"`
+	PostID    uint      `json:"post_id"`
+	UserID    uint      `json:"user_id"`
+	ParentID  *uint     `json:"parent_id"`
+	RootID    *uint     `json:"root_id"`
+	Content   string    `json:"content"`
+	Hidden    bool      `json:"hidden"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CommentsDeletedEvent 被删除的评论（含全部回复）
+type CommentsDeletedEvent struct {
+	PostID uint   `json:"post_id"`
+	IDs    []uint `json:"ids"`
+}
+
+func newPostEvent(p *model.Post) PostEvent {
+	return PostEvent{
+		ID:          p.ID,
+		Title:       p.Title,
+		Excerpt:     p.Excerpt,
+		UserID:      p.UserID,
+		Status:      p.Status,
+		PublishedAt: p.PublishedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+func newCommentEvent(c *model.Comment) CommentEvent {
+	ev := CommentEvent{
+		ID:        c.ID,
+		PostID:    c.PostID,
+		UserID:    c.UserID,
+		ParentID:  c.ParentID,
+		RootID:    c.RootID,
+		Content:   c.Content,
+		Hidden:    c.Hidden(),
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+	if ev.Hidden {
+		ev.Content = ""
+	}
+	return ev
+}
+
+// publish 发布实时事件，未配置发布者时忽略
+func publish(e Events, topic, typ string, data any) {
+	if e != nil {
+		e.Publish(topic, typ, data)
+	}
+}
+
+// PublishPost 文章变更后通知全站列表与文章页的订阅者，只有公开过的文章才会推送；
+// wasPublished 为变更前是否公开，post 为 nil 表示已删除
+func PublishPost(e Events, id uint, post *model.Post, wasPublished bool) {
+	var typ string
+	var data any = PostEvent{ID: id}
+	switch {
+	case post == nil:
+		if !wasPublished {
+			return
+		}
+		typ = realtime.PostDeleted
+	case post.Published():
+		typ = realtime.PostUpdated
+		if !wasPublished {
+			typ = realtime.PostPublished
+		}
+		data = newPostEvent(post)
+	case wasPublished:
+		typ = realtime.PostUnpublished
+	default:
+		return
+	}
+	publish(e, realtime.TopicPosts, typ, data)
+	publish(e, realtime.PostTopic(id), typ, data)
+}
+
+// Realtime 实时推送的订阅校验
+type Realtime struct {
+	Store     *repository.Store
+	MaxTopics int // 单个连接最多订阅的主题数，0 为不限
+}
+
+// Authorize 校验用户能否新订阅这些主题：post:<id> 必须是已发布的文章，user:<id> 只能订阅自己的。
+// userID 为 0 表示匿名，subscribed 为连接已订阅的主题数
+func (s *Realtime) Authorize(ctx context.Context, userID uint, topics []string, subscribed int) error {
+	if s.MaxTopics > 0 && subscribed+len(topics) > s.MaxTopics {
+		return ErrTooManyTopics.With("max", strconv.Itoa(s.MaxTopics))
+	}
+	for _, name := range topics {
+		kind, id, ok := realtime.ParseTopic(name)
+		if !ok {
+			return ErrTopicInvalid.With("topic", name)
+		}
+		switch kind {
+		case realtime.KindPost:
+			if _, err := s.Store.Posts.FindPublished(ctx, id); err != nil {
+				return notFound(err, ErrPostNotFound)
+			}
+		case realtime.KindUser:
+			if userID == 0 || id != userID {
+				return ErrTopicForbidden.With("topic", name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+// maxDiffLines 参与比较的版本最多的行数，差异计算的耗时随行数与差异行数的乘积增长
+const maxDiffLines = 5000
+
+// RevisionDiff 两个版本之间的逐行差异
+type RevisionDiff struct {
+	From    int             `json:"from"`
+	To      int             `json:"to"`
+	Title   []util.DiffLine `json:"title"`
+	Content []util.DiffLine `json:"content"`
+}
+
+// findRevision 查找文章的某个版本
+func (s *Posts) findRevision(ctx context.Context, postID uint, version int) (*model.PostRevision, error) {
+	rev, err := s.Store.Posts.FindRevision(ctx, postID, version)
+	if err != nil {
+		return nil, notFound(err, ErrRevisionNotFound)
+	}
+	return rev, nil
+}
+
+// Revisions 文章的历史版本列表，不含正文
+func (s *Posts) Revisions(ctx context.Context, post *model.Post) ([]model.PostRevision, error) {
+	revisions, err := s.Store.Posts.Revisions(ctx, post.ID)
+	return revisions, internal(err)
+}
+
+// Diff 比较文章的两个版本
+func (s *Posts) Diff(ctx context.Context, post *model.Post, from, to int) (*RevisionDiff, error) {
+	a, err := s.findRevision(ctx, post.ID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.findRevision(ctx, post.ID, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, content := range []string{a.Title, a.Content, b.Title, b.Content} {
+		if strings.Count(content, "\n")+1 > maxDiffLines {
+			return nil, ErrRevisionTooLarge.With("max", strconv.Itoa(maxDiffLines))
+		}
+	}
+	return &RevisionDiff{
+		From:    a.Version,
+		To:      b.Version,
+		Title:   util.DiffLines(a.Title, b.Title),
+		Content: util.DiffLines(a.Content, b.Content),
+	}, nil
+}
+
+// Restore 将文章恢复到某个历史版本，恢复本身也会生成新版本
+func (s *Posts) Restore(ctx context.Context, post *model.Post, version int, editorID uint) (*model.Post, error) {
+	rev, err := s.findRevision(ctx, post.ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	post.Title, post.Content = rev.Title, rev.Content
+	if err := renderPost(post); err != nil {
+		return nil, internal(err)
+	}
+	err = s.Store.Tx(ctx, func(tx *repository.Store) error {
+		if err := tx.Posts.SaveContent(ctx, post); err != nil {
+			return err
+		}
+		return tx.Posts.CreateRevision(ctx, post, editorID)
+	})
+	if err != nil {
+		return nil, internal(err)
+	}
+
+	syncPostIndex(s.Searcher, post)
+	if err := s.Store.Posts.Reload(ctx, post); err != nil {
+		return nil, internal(err)
+	}
+	PublishPost(s.Events, post.ID, post, post.Published())
+	return post, nil
+}
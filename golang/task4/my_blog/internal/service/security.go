@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"my_blog/internal/conf"
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+const (
+	defaultLoginEventPageSize = 20
+	maxLoginEventPageSize     = 100
+)
+
+// security 当前的账号安全配置
+func (s *Auth) security() conf.SecurityConfig {
+	if s.Security == nil {
+		return conf.Defaults().Security
+	}
+	return s.Security()
+}
+
+// recordLogin 写入登录审计记录，失败只影响审计，不影响登录结果
+func (s *Auth) recordLogin(ctx context.Context, userID uint, client Client, success bool, reason string) {
+	ua := client.UserAgent
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+	_ = s.Store.Audit.CreateLoginEvent(ctx, &model.LoginEvent{
+		UserID:    userID,
+		IP:        client.IP,
+		UserAgent: ua,
+		Success:   success,
+		Reason:    reason,
+	})
+}
+
+// loginFailed 累加连续失败次数（密码或两步验证码错误），达到阈值后按指数退避锁定账号
+func (s *Auth) loginFailed(ctx context.Context, user *model.User, client Client, reason string) error {
+	s.recordLogin(ctx, user.ID, client, false, reason)
+	return s.Store.Tx(ctx, func(tx *repository.Store) error {
+		failures, err := tx.Users.IncrFailedLogins(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		user.FailedLogins = failures
+		lockout := s.security().Lockout(failures)
+		if lockout == 0 {
+			return nil
+		}
+		until := time.Now().Add(lockout)
+		user.LockedUntil = &until
+		return tx.Users.LockUntil(ctx, user.ID, until)
+	})
+}
+
+// locked 账号锁定中：记录审计并返回带 Retry-After 与锁定截止时间的错误
+func (s *Auth) locked(ctx context.Context, user *model.User, client Client, now time.Time) error {
+	s.recordLogin(ctx, user.ID, client, false, model.LoginFailLocked)
+	return ErrAccountLocked.
+		WithRetryAfter(user.LockedUntil.Sub(now)).
+		WithMeta("locked_until", user.LockedUntil.Format(time.RFC3339))
+}
+
+// loginSucceeded 清零失败次数并记录登录成功
+func (s *Auth) loginSucceeded(ctx context.Context, user *model.User, client Client) error {
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := s.Store.Users.ClearLockout(ctx, user.ID); err != nil {
+			return err
+		}
+		user.FailedLogins, user.LockedUntil = 0, nil
+	}
+	s.recordLogin(ctx, user.ID, client, true, "")
+	return nil
+}
+
+// LoginEventPage 一页登录记录
+type LoginEventPage struct {
+	Events     []model.LoginEvent `json:"events"`
+	NextCursor string             `json:"next_cursor"`
+}
+
+// LoginEvents 用户的登录记录，按时间倒序，游标分页
+func (s *Auth) LoginEvents(ctx context.Context, userID uint, cursor string, size int) (*LoginEventPage, error) {
+	size = pageLimit(size, defaultLoginEventPageSize, maxLoginEventPageSize)
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.Store.Audit.LoginEvents(ctx, userID, after, size+1)
+	if err != nil {
+		return nil, internal(err)
+	}
+	page := &LoginEventPage{Events: events}
+	if len(events) > size {
+		page.Events = events[:size]
+		last := page.Events[len(page.Events)-1]
+		page.NextCursor = util.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
@@ -0,0 +1,90 @@
+// Package service 业务逻辑层：handler 只负责绑定参数与渲染结果，业务规则与数据访问在这里完成。
+// 返回的错误均为 *apperr.Error（或包装了底层错误的 apperr.ErrInternal），由 respond 统一映射为 HTTP 响应
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"my_blog/internal/apperr"
+	"my_blog/internal/repository"
+	"my_blog/internal/search"
+	"my_blog/internal/util"
+)
+
+// Client 发起请求的客户端，用于登录审计
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// Session 当前登录会话（来自已校验的 access token）
+type Session struct {
+	UserID uint
+	Role   string
+	JTI    string
+}
+
+// internal 将非领域错误包装为内部错误，领域错误原样返回
+func internal(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *apperr.Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return apperr.ErrInternal.Wrap(err)
+}
+
+// notFound 记录不存在时返回 notFoundErr，其余错误视为内部错误
+func notFound(err error, notFoundErr *apperr.Error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFoundErr
+	}
+	return internal(err)
+}
+
+// pageLimit 规范分页大小
+func pageLimit(size, def, max int) int {
+	if size <= 0 {
+		return def
+	}
+	if size > max {
+		return max
+	}
+	return size
+}
+
+// decodeCursor 解析 (时间, ID) 游标，空串表示第一页
+func decodeCursor(cursor string) (*repository.Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	at, id, err := util.DecodeCursor(cursor)
+	if err != nil {
+		return nil, ErrCursorInvalid.Wrap(err)
+	}
+	return &repository.Cursor{At: at, ID: id}, nil
+}
+
+// indexDocument 更新检索索引，失败只记录日志，不影响写操作本身
+func indexDocument(s search.Searcher, doc search.Document) {
+	if s == nil {
+		return
+	}
+	if err := s.Index(context.Background(), doc); err != nil {
+		log.Printf("search: index %s %d: %v", doc.Kind, doc.ID, err)
+	}
+}
+
+// removeDocument 从检索索引中移除文档，失败只记录日志
+func removeDocument(s search.Searcher, kind string, id uint) {
+	if s == nil {
+		return
+	}
+	if err := s.Delete(context.Background(), kind, id); err != nil {
+		log.Printf("search: delete %s %d: %v", kind, id, err)
+	}
+}
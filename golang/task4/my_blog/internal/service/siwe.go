@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"my_blog/internal/conf"
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/siwe"
+	"my_blog/internal/util"
+)
+
+// SIWENonce 签发给前端、写入 EIP-4361 消息的 nonce
+type SIWENonce struct {
+	Nonce     string `json:"nonce"`
+	Domain    string `json:"domain"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// siweConfig 当前的钱包登录配置
+func (s *Auth) siweConfig() conf.SIWEConfig {
+	if s.SIWE == nil {
+		return conf.Defaults().SIWE
+	}
+	return s.SIWE()
+}
+
+// NewSIWENonce 签发钱包登录用的一次性 nonce
+func (s *Auth) NewSIWENonce(ctx context.Context) (*SIWENonce, error) {
+	nonce, err := util.RandomToken(16)
+	if err != nil {
+		return nil, internal(err)
+	}
+	cfg := s.siweConfig()
+	n := model.SIWENonce{Nonce: nonce, ExpiresAt: time.Now().Add(cfg.NonceTTL)}
+	if err := s.Store.Tokens.CreateNonce(ctx, &n); err != nil {
+		return nil, internal(err)
+	}
+	return &SIWENonce{Nonce: n.Nonce, Domain: cfg.Domain, ExpiresAt: n.ExpiresAt.Format(time.RFC3339)}, nil
+}
+
+// verifySIWE 校验签名消息并消耗其中的 nonce
+func (s *Auth) verifySIWE(ctx context.Context, message, signature string) (*siwe.Message, error) {
+	msg, err := siwe.Verify(message, signature, s.siweConfig().Domain, time.Now())
+	switch {
+	case err == nil:
+	case errors.Is(err, siwe.ErrMalformed), errors.Is(err, siwe.ErrBadVersion), errors.Is(err, siwe.ErrNonceTooShort):
+		return nil, ErrSIWEMalformed.With("reason", err.Error()).Wrap(err)
+	case errors.Is(err, siwe.ErrDomain):
+		return nil, ErrSIWEDomain.Wrap(err)
+	case errors.Is(err, siwe.ErrExpired), errors.Is(err, siwe.ErrNotYetValid):
+		return nil, ErrSIWEExpired.Wrap(err)
+	default:
+		return nil, ErrSIWESignature.Wrap(err)
+	}
+
+	ok, err := s.Store.Tokens.UseNonce(ctx, msg.Nonce, time.Now())
+	if err != nil {
+		return nil, internal(err)
+	}
+	if !ok {
+		return nil, ErrSIWENonce
+	}
+	return msg, nil
+}
+
+// SIWELogin 以太坊钱包登录：校验 EIP-4361 签名，首次登录时按地址创建账号。
+// 已启用两步验证的账号同样需要完成 LoginMFA
+func (s *Auth) SIWELogin(ctx context.Context, message, signature string, client Client) (*LoginResult, error) {
+	msg, err := s.verifySIWE(ctx, message, signature)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.walletUser(ctx, msg.Address.Hex())
+	if err != nil {
+		return nil, internal(err)
+	}
+	if now := time.Now(); user.Locked(now) {
+		return nil, s.locked(ctx, user, client, now)
+	}
+	return s.completeLogin(ctx, user, client)
+}
+
+// walletUser 按地址查找用户，不存在时创建。钱包账号没有密码，邮箱为占位地址
+func (s *Auth) walletUser(ctx context.Context, address string) (*model.User, error) {
+	users := s.Store.Users
+	user, err := users.FindByEthAddress(ctx, address)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return user, err
+	}
+
+	username := address
+	taken, err := users.UsernameTaken(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		suffix, err := util.RandomToken(3)
+		if err != nil {
+			return nil, err
+		}
+		username = address + "-" + suffix
+	}
+
+	user = &model.User{
+		Username:   username,
+		Email:      strings.ToLower(address) + "@" + model.WalletEmailDomain,
+		Role:       model.RoleAuthor,
+		EthAddress: &address,
+	}
+	if err := users.Create(ctx, user); err != nil {
+		// 并发的首次登录可能已创建该地址的账号
+		if existing, ferr := users.FindByEthAddress(ctx, address); ferr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// LinkWallet 为账号绑定签名消息中的以太坊地址，之后可用钱包登录该账号。
+// 返回绑定的地址，以及该地址此前是否已绑定在本账号上
+func (s *Auth) LinkWallet(ctx context.Context, userID uint, message, signature string) (string, bool, error) {
+	msg, err := s.verifySIWE(ctx, message, signature)
+	if err != nil {
+		return "", false, err
+	}
+	address := msg.Address.Hex()
+
+	owner, err := s.Store.Users.FindByEthAddress(ctx, address)
+	switch {
+	case err == nil && owner.ID == userID:
+		return address, true, nil
+	case err == nil:
+		return "", false, ErrWalletLinkedElsewhere
+	case !errors.Is(err, repository.ErrNotFound):
+		return "", false, internal(err)
+	}
+
+	if err := s.Store.Users.SetEthAddress(ctx, userID, address); err != nil {
+		return "", false, internal(err)
+	}
+	return address, false, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+)
+
+const (
+	maxTagsPerPost  = 10
+	maxTagNameLen   = 32
+	defaultTagLimit = 10
+	maxTagLimit     = 100
+	defaultTagCloud = 50
+)
+
+// Tags 标签与分类
+type Tags struct {
+	Store *repository.Store
+}
+
+// normalizeTagNames 去空白、转小写、去重
+func normalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if n == "" || len([]rune(n)) > maxTagNameLen {
+			return nil, ErrTagNameInvalid
+		}
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	if len(out) > maxTagsPerPost {
+		return nil, ErrTooManyTags
+	}
+	return out, nil
+}
+
+// resolveTags 按名称查找标签，不存在的自动创建
+func resolveTags(ctx context.Context, tx *repository.Store, names []string) ([]model.Tag, error) {
+	names, err := normalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Tags.EnsureTags(ctx, names)
+}
+
+// resolveCategories 按名称查找分类，分类必须已存在
+func resolveCategories(ctx context.Context, tx *repository.Store, names []string) ([]model.Category, error) {
+	names, err := normalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+	out, err := tx.Tags.FindCategories(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) != len(names) {
+		return nil, ErrCategoryNotFound
+	}
+	return out, nil
+}
+
+// Autocomplete 以 prefix 开头的标签，按使用次数降序
+func (s *Tags) Autocomplete(ctx context.Context, prefix string, limit int) ([]repository.NameCount, error) {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	// 转义 LIKE 通配符，用 ! 作转义符以兼容各数据库
+	prefix = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(prefix)
+
+	tags, err := s.Store.Tags.TagCounts(ctx, prefix, pageLimit(limit, defaultTagLimit, maxTagLimit))
+	return tags, internal(err)
+}
+
+// Cloud 使用最多的标签及文章数
+func (s *Tags) Cloud(ctx context.Context, limit int) ([]repository.NameCount, error) {
+	tags, err := s.Store.Tags.TagCounts(ctx, "", pageLimit(limit, defaultTagCloud, maxTagLimit))
+	return tags, internal(err)
+}
+
+// Categories 分类列表及已发布文章数
+func (s *Tags) Categories(ctx context.Context) ([]repository.NameCount, error) {
+	categories, err := s.Store.Tags.CategoryCounts(ctx)
+	return categories, internal(err)
+}
+
+// CreateCategory 新建分类
+func (s *Tags) CreateCategory(ctx context.Context, name string) (*model.Category, error) {
+	names, err := normalizeTagNames([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	category := &model.Category{Name: names[0]}
+	created, err := s.Store.Tags.CreateCategory(ctx, category)
+	if err != nil {
+		return nil, internal(err)
+	}
+	if !created {
+		return nil, ErrCategoryExists
+	}
+	return category, nil
+}
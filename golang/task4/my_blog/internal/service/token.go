@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+// TokenPair 登录/刷新返回给客户端的令牌对
+type TokenPair struct {
+	AccessToken      string `json:"token"`
+	ExpiresAt        string `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt string `json:"refresh_expires_at"`
+}
+
+// issueTokens 签发 access token 与 refresh token，familyID 为空时开启新的令牌族
+func issueTokens(ctx context.Context, st *repository.Store, user *model.User, familyID string) (*TokenPair, error) {
+	access, err := util.GenerateToken(user.ID, user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := util.ParseToken(access)
+	if err != nil {
+		return nil, err
+	}
+
+	if familyID == "" {
+		if familyID, err = util.RandomToken(16); err != nil {
+			return nil, err
+		}
+	}
+	refresh, err := util.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	rt := model.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: util.HashToken(refresh),
+		FamilyID:  familyID,
+		AccessJTI: claims.ID,
+		ExpiresAt: time.Now().Add(util.RefreshTokenTTL),
+	}
+	if err := st.Tokens.CreateRefresh(ctx, &rt); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		ExpiresAt:        claims.ExpiresAt.Time.Format(time.RFC3339),
+		RefreshToken:     refresh,
+		RefreshExpiresAt: rt.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// revokeFamily 吊销整个令牌族
+func (s *Auth) revokeFamily(ctx context.Context, familyID string) error {
+	return s.Store.Tokens.RevokeFamily(ctx, familyID, util.AccessTokenTTL)
+}
+
+// revokeSessions 吊销用户的所有令牌族，keepFamily 非空时保留该令牌族（当前会话）
+func (s *Auth) revokeSessions(ctx context.Context, userID uint, keepFamily string) error {
+	families, err := s.Store.Tokens.ActiveFamilies(ctx, userID, keepFamily)
+	if err != nil {
+		return err
+	}
+	for _, f := range families {
+		if err := s.revokeFamily(ctx, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Refresh 用 refresh token 换取新的令牌对，旧 refresh token 立即失效。
+// 已轮换过的 token 再次出现视为泄露，吊销整个令牌族
+func (s *Auth) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	rt, err := s.Store.Tokens.FindRefreshByHash(ctx, util.HashToken(refreshToken))
+	if err != nil {
+		return nil, notFound(err, ErrRefreshInvalid)
+	}
+	if rt.RevokedAt != nil {
+		_ = s.revokeFamily(ctx, rt.FamilyID)
+		return nil, ErrRefreshReused
+	}
+	if time.Now().After(rt.ExpiresAt) {
+		return nil, ErrRefreshExpired
+	}
+
+	user, err := s.Store.Users.Find(ctx, rt.UserID)
+	if err != nil {
+		return nil, notFound(err, ErrAccountMissing)
+	}
+
+	var pair *TokenPair
+	err = s.Store.Tx(ctx, func(tx *repository.Store) error {
+		// 条件更新保证并发刷新时只有一个请求能成功轮换
+		ok, err := tx.Tokens.RevokeRefresh(ctx, rt.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrRefreshReused
+		}
+		pair, err = issueTokens(ctx, tx, user, rt.FamilyID)
+		return err
+	})
+	if errors.Is(err, ErrRefreshReused) {
+		_ = s.revokeFamily(ctx, rt.FamilyID)
+	}
+	if err != nil {
+		return nil, internal(err)
+	}
+	return pair, nil
+}
+
+// Logout 吊销当前 access token 及其所属的令牌族
+func (s *Auth) Logout(ctx context.Context, sess Session, expiresAt time.Time) error {
+	if sess.JTI == "" {
+		return ErrUnauthenticated
+	}
+	rt, err := s.Store.Tokens.FindRefreshByAccessJTI(ctx, sess.JTI)
+	switch {
+	case err == nil:
+		err = s.revokeFamily(ctx, rt.FamilyID)
+	case errors.Is(err, repository.ErrNotFound):
+		err = nil
+	}
+	if err == nil {
+		err = s.Store.Tokens.RevokeJTI(ctx, sess.JTI, expiresAt)
+	}
+	return internal(err)
+}
+
+// currentFamily 当前会话所属的令牌族，找不到时返回空串
+func (s *Auth) currentFamily(ctx context.Context, jti string) string {
+	if jti == "" {
+		return ""
+	}
+	rt, err := s.Store.Tokens.FindRefreshByAccessJTI(ctx, jti)
+	if err != nil {
+		return ""
+	}
+	return rt.FamilyID
+}
+
+// PurgeExpired 删除 now 之前已过期的令牌与 nonce，返回删除的行数
+func (s *Auth) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	return s.Store.Tokens.PurgeExpired(ctx, now)
+}
+
+// RunPurge 每隔 interval 清理一次过期的令牌与 nonce，直到 ctx 取消
+func (s *Auth) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.PurgeExpired(ctx, time.Now())
+			if err != nil {
+				log.Printf("auth: purge expired tokens: %v", err)
+			}
+			if n > 0 {
+				log.Printf("auth: purged %d expired tokens", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"my_blog/internal/conf"
+	"my_blog/internal/media"
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/storage"
+)
+
+// orphanBatch 每轮清理最多处理的附件数
+const orphanBatch = 100
+
+// Uploads 图片上传与未关联附件的清理
+type Uploads struct {
+	Store   *repository.Store
+	Storage storage.Storage
+	Config  conf.UploadConfig
+}
+
+// Upload 校验并保存一张图片：类型按文件头识别，可解码的格式会检查尺寸并生成缩略图。
+// 新附件不属于任何文章，需在创建或编辑文章时通过 attachment_ids 关联，否则超过保留期后被清理
+func (s *Uploads) Upload(ctx context.Context, userID uint, filename string, r io.Reader) (*model.Attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(s.Config.MaxSize)+1))
+	if err != nil {
+		return nil, internal(err)
+	}
+	if len(data) > s.Config.MaxSize {
+		return nil, s.TooLarge(nil)
+	}
+
+	contentType := media.Sniff(data)
+	if !slices.Contains(s.Config.AllowedTypes, contentType) {
+		return nil, ErrUploadType.With("type", contentType)
+	}
+
+	base := newObjectKey(time.Now())
+	a := &model.Attachment{
+		UserID:      userID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Key:         base + media.Extension(contentType),
+	}
+
+	var thumb bytes.Buffer
+	thumbType := ""
+	if media.Decodable(contentType) {
+		img, err := media.Decode(data, s.Config.MaxPixels)
+		if errors.Is(err, media.ErrTooManyPixels) {
+			return nil, ErrImageTooLarge.With("max", strconv.Itoa(s.Config.MaxPixels))
+		}
+		if err != nil {
+			return nil, ErrImageInvalid.Wrap(err)
+		}
+		a.Width, a.Height = img.Bounds().Dx(), img.Bounds().Dy()
+		// 小图直接以原图作为缩略图
+		if a.Width > s.Config.ThumbnailSize || a.Height > s.Config.ThumbnailSize {
+			if thumbType, err = media.EncodeThumbnail(&thumb, media.Thumbnail(img, s.Config.ThumbnailSize), contentType); err != nil {
+				return nil, internal(err)
+			}
+			a.ThumbnailKey = base + "_thumb" + media.Extension(thumbType)
+		}
+	}
+
+	if err := s.Storage.Put(ctx, a.Key, bytes.NewReader(data), a.Size, contentType); err != nil {
+		return nil, internal(err)
+	}
+	a.URL = s.Storage.URL(a.Key)
+	a.ThumbnailURL = a.URL
+	if a.ThumbnailKey != "" {
+		if err := s.Storage.Put(ctx, a.ThumbnailKey, bytes.NewReader(thumb.Bytes()), int64(thumb.Len()), thumbType); err != nil {
+			s.deleteObjects(ctx, a.Key)
+			return nil, internal(err)
+		}
+		a.ThumbnailURL = s.Storage.URL(a.ThumbnailKey)
+	}
+
+	if err := s.Store.Attachments.Create(ctx, a); err != nil {
+		s.deleteObjects(ctx, a.Key, a.ThumbnailKey)
+		return nil, internal(err)
+	}
+	return a, nil
+}
+
+// TooLarge 文件超过大小限制的错误，err 为底层错误（可为 nil）
+func (s *Uploads) TooLarge(err error) error {
+	e := ErrUploadTooLarge.With("max", formatBytes(s.Config.MaxSize))
+	if err != nil {
+		return e.Wrap(err)
+	}
+	return e
+}
+
+// CollectOrphans 删除在 now - OrphanTTL 之前就未关联文章的附件及其文件，返回删除的数量。
+// 每条记录先按条件删除（认领），读取之后又被关联到文章的附件认领失败，文件保留；
+// 认领成功后文件删除失败只记录日志，宁可遗留文件也不能删掉仍在使用的文件
+func (s *Uploads) CollectOrphans(ctx context.Context, now time.Time) (int, error) {
+	before := now.Add(-s.Config.OrphanTTL)
+	total := 0
+	for {
+		orphans, err := s.Store.Attachments.Orphans(ctx, before, orphanBatch)
+		if err != nil {
+			return total, err
+		}
+		for _, a := range orphans {
+			claimed, err := s.Store.Attachments.Claim(ctx, a.ID, before)
+			if err != nil {
+				return total, err
+			}
+			if !claimed {
+				continue
+			}
+			s.deleteObjects(ctx, a.Key, a.ThumbnailKey)
+			total++
+		}
+		if len(orphans) < orphanBatch {
+			return total, nil
+		}
+	}
+}
+
+// RunGC 每隔 interval 清理一次未关联的附件，直到 ctx 取消
+func (s *Uploads) RunGC(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.CollectOrphans(ctx, time.Now())
+			if err != nil {
+				log.Printf("uploads: gc: %v", err)
+			}
+			if n > 0 {
+				log.Printf("uploads: gc removed %d orphaned attachments", n)
+			}
+		}
+	}
+}
+
+// deleteObjects 删除存储中的对象，空 key 跳过，失败只记录日志
+func (s *Uploads) deleteObjects(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.Storage.Delete(ctx, key); err != nil {
+			log.Printf("uploads: delete %s: %v", key, err)
+		}
+	}
+}
+
+// newObjectKey 按年月分目录的随机对象键（不含扩展名），不使用用户提供的文件名
+func newObjectKey(now time.Time) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "attachments/" + now.UTC().Format("2006/01") + "/" + hex.EncodeToString(b)
+}
+
+// cleanFilename 去掉路径部分，只保留文件名用于展示
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
+
+// formatBytes 以 KiB / MiB 表示文件大小
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MiB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%d KiB", n>>10)
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// linkAttachments 让文章恰好关联 ids 中的附件：不在其中的解除关联，新增的必须由 ownerID 上传且未被其他文章使用
+func linkAttachments(ctx context.Context, tx *repository.Store, postID, ownerID uint, ids []uint) error {
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	if err := tx.Attachments.Detach(ctx, postID, ids); err != nil {
+		return err
+	}
+	n, err := tx.Attachments.Attach(ctx, postID, ownerID, ids)
+	if err != nil {
+		return err
+	}
+	if n != int64(len(ids)) {
+		return ErrAttachmentInvalid
+	}
+	return nil
+}
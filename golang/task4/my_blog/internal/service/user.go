@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+)
+
+// Users 管理员的用户管理
+type Users struct {
+	Store *repository.Store
+}
+
+// List 全部用户
+func (s *Users) List(ctx context.Context) ([]model.User, error) {
+	users, err := s.Store.Users.List(ctx)
+	return users, internal(err)
+}
+
+// SetRole 修改用户角色，管理员不能修改自己的角色
+func (s *Users) SetRole(ctx context.Context, adminID, userID uint, role string) (*model.User, error) {
+	if !model.ValidRole(role) {
+		return nil, ErrRoleInvalid
+	}
+	if userID == adminID {
+		return nil, ErrChangeOwnRole
+	}
+	user, err := s.Store.Users.Find(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	if err := s.Store.Users.SetRole(ctx, user.ID, role); err != nil {
+		return nil, internal(err)
+	}
+	user.Role = role
+	return user, nil
+}
+
+// Delete 删除用户，管理员不能删除自己；用户的关注关系一并删除，对方的关注数与粉丝数同步扣减
+func (s *Users) Delete(ctx context.Context, adminID, userID uint) error {
+	if userID == adminID {
+		return ErrDeleteSelf
+	}
+	err := s.Store.Tx(ctx, func(tx *repository.Store) error {
+		if err := tx.Users.Delete(ctx, userID); err != nil {
+			return err
+		}
+		edges, err := tx.Follows.RemoveUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, e := range edges {
+			if err := tx.Users.AddFollowCounts(ctx, e.FollowerID, e.FolloweeID, -1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return notFound(err, ErrUserNotFound)
+}
+
+// ResetMFA 重置用户的两步验证，用于用户丢失验证器且恢复码用尽的情况
+func (s *Users) ResetMFA(ctx context.Context, adminID, userID uint) (*model.User, error) {
+	user, err := s.Store.Users.Find(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	if err := clearMFA(ctx, s.Store, user.ID); err != nil {
+		return nil, internal(err)
+	}
+	log.Printf("admin %d reset mfa of user %d", adminID, user.ID)
+
+	user.MFAEnabledAt = nil
+	return user, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"my_blog/internal/mail"
+	"my_blog/internal/model"
+	"my_blog/internal/repository"
+	"my_blog/internal/util"
+)
+
+// emailVerificationTTL 邮箱验证链接有效期
+const emailVerificationTTL = 24 * time.Hour
+
+var (
+	errActionTokenInvalid = errors.New("invalid or expired token")
+	errActionTokenUsed    = errors.New("token already used")
+)
+
+// issueActionToken 签发一次性操作令牌并按 jti 记录
+func (s *Auth) issueActionToken(ctx context.Context, userID uint, purpose string, ttl time.Duration) (*util.ActionToken, error) {
+	at, err := util.GenerateActionToken(userID, purpose, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Store.Tokens.CreateAction(ctx, &model.ActionToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		JTI:       at.JTI,
+		ExpiresAt: at.ExpiresAt,
+	}); err != nil {
+		return nil, err
+	}
+	return at, nil
+}
+
+// sendVerification 签发邮箱验证令牌并发送验证邮件
+func (s *Auth) sendVerification(ctx context.Context, user *model.User) error {
+	at, err := s.issueActionToken(ctx, user.ID, model.PurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := strings.TrimRight(s.BaseURL, "/") + "/api/email/verify?token=" + url.QueryEscape(at.Token)
+	return s.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "验证你的邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请在 %s 前打开以下链接完成邮箱验证：\n\n%s\n\n如果这不是你本人的操作，请忽略本邮件。",
+			user.Username, at.ExpiresAt.Format("2006-01-02 15:04"), link),
+	})
+}
+
+// consumeActionToken 校验一次性令牌并标记为已使用，随后在同一事务中执行 fn。
+// fn 返回错误时事务回滚，令牌不会被标记为已使用
+func (s *Auth) consumeActionToken(ctx context.Context, token, purpose string, fn func(tx *repository.Store, userID uint) error) error {
+	at, err := util.ParseActionToken(token, purpose)
+	if err != nil {
+		return errActionTokenInvalid
+	}
+	return s.Store.Tx(ctx, func(tx *repository.Store) error {
+		ok, err := tx.Tokens.UseAction(ctx, at.JTI, purpose, at.UserID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errActionTokenUsed
+		}
+		return fn(tx, at.UserID)
+	})
+}
+
+// VerifyEmail 使用邮件中的令牌验证邮箱
+func (s *Auth) VerifyEmail(ctx context.Context, token string) error {
+	err := s.consumeActionToken(ctx, token, model.PurposeEmailVerification, func(tx *repository.Store, userID uint) error {
+		return tx.Users.MarkEmailVerified(ctx, userID, time.Now())
+	})
+	switch {
+	case errors.Is(err, errActionTokenInvalid):
+		return ErrVerifyLinkInvalid
+	case errors.Is(err, errActionTokenUsed):
+		return ErrVerifyLinkUsed
+	}
+	return internal(err)
+}
+
+// ResendVerification 重新发送验证邮件
+func (s *Auth) ResendVerification(ctx context.Context, userID uint) error {
+	user, err := s.Store.Users.Find(ctx, userID)
+	if err != nil {
+		return notFound(err, ErrUserNotFound)
+	}
+	if user.EmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+	if user.WalletOnly() {
+		return ErrWalletAccountNoEmail
+	}
+	return internal(s.sendVerification(ctx, user))
+}
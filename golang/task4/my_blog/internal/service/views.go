@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"my_blog/internal/repository"
+)
+
+// ViewCounter 文章阅读计数器：阅读数先在内存中累加，由 Run 定期批量写入数据库，
+// 避免每次打开文章都更新 posts 表。进程异常退出时最多丢失一个周期的计数
+type ViewCounter struct {
+	Store *repository.Store
+
+	mu      sync.Mutex
+	pending map[uint]int64
+}
+
+// NewViewCounter 创建阅读计数器
+func NewViewCounter(store *repository.Store) *ViewCounter {
+	return &ViewCounter{Store: store, pending: make(map[uint]int64)}
+}
+
+// Add 记录一次阅读
+func (v *ViewCounter) Add(postID uint) {
+	v.mu.Lock()
+	v.pending[postID]++
+	v.mu.Unlock()
+}
+
+// Pending 尚未写入数据库的阅读数
+func (v *ViewCounter) Pending(postID uint) int64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.pending[postID]
+}
+
+// Flush 将累积的阅读数写入数据库，失败时计数放回缓冲区等待下次写入
+func (v *ViewCounter) Flush(ctx context.Context) error {
+	v.mu.Lock()
+	views := v.pending
+	v.pending = make(map[uint]int64, len(views))
+	v.mu.Unlock()
+	if len(views) == 0 {
+		return nil
+	}
+
+	if err := v.Store.Posts.AddViews(ctx, views); err != nil {
+		v.mu.Lock()
+		for id, n := range views {
+			v.pending[id] += n
+		}
+		v.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run 每隔 interval 写入一次阅读数，直到 ctx 取消；停机时调用方应在请求处理完后再 Flush 一次
+func (v *ViewCounter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.Flush(ctx); err != nil {
+				log.Printf("views: flush: %v", err)
+			}
+		}
+	}
+}
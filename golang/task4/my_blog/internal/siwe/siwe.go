@@ -0,0 +1,238 @@
+// Package siwe 解析与校验 Sign-In with Ethereum（EIP-4361）消息，并从 EIP-191 签名中恢复签名地址
+package siwe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const header = " wants you to sign in with your Ethereum account:"
+
+var (
+	ErrMalformed     = errors.New("siwe: malformed message")
+	ErrDomain        = errors.New("siwe: domain mismatch")
+	ErrExpired       = errors.New("siwe: message expired")
+	ErrNotYetValid   = errors.New("siwe: message not yet valid")
+	ErrBadSignature  = errors.New("siwe: invalid signature")
+	ErrAddrMismatch  = errors.New("siwe: signer does not match address")
+	ErrBadVersion    = errors.New("siwe: unsupported version")
+	ErrNonceTooShort = errors.New("siwe: nonce must be at least 8 alphanumeric characters")
+)
+
+// Message EIP-4361 消息
+type Message struct {
+	Scheme         string // 可选，如 https
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// Parse 解析 EIP-4361 文本消息。地址必须是 EIP-55 校验和格式
+func Parse(text string) (*Message, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], header) {
+		return nil, fmt.Errorf("%w: missing header", ErrMalformed)
+	}
+
+	m := &Message{Domain: strings.TrimSuffix(lines[0], header)}
+	if scheme, domain, ok := strings.Cut(m.Domain, "://"); ok {
+		m.Scheme, m.Domain = scheme, domain
+	}
+	if m.Domain == "" {
+		return nil, fmt.Errorf("%w: missing domain", ErrMalformed)
+	}
+
+	addr := lines[1]
+	if !common.IsHexAddress(addr) || common.HexToAddress(addr).Hex() != addr {
+		return nil, fmt.Errorf("%w: address must be EIP-55 checksummed", ErrMalformed)
+	}
+	m.Address = common.HexToAddress(addr)
+
+	// 地址之后是空行、可选的 statement，再是空行与字段
+	i := 2
+	for i < len(lines) && lines[i] == "" {
+		i++
+	}
+	if i < len(lines) && !strings.HasPrefix(lines[i], "URI: ") {
+		m.Statement = lines[i]
+		i++
+		for i < len(lines) && lines[i] == "" {
+			i++
+		}
+	}
+
+	var err error
+	seen := map[string]bool{}
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+		if line == "Resources:" {
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], "- ") {
+				i++
+				m.Resources = append(m.Resources, strings.TrimPrefix(lines[i], "- "))
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok || seen[key] {
+			return nil, fmt.Errorf("%w: unexpected line %q", ErrMalformed, line)
+		}
+		seen[key] = true
+		switch key {
+		case "URI":
+			m.URI = value
+		case "Version":
+			m.Version = value
+		case "Chain ID":
+			if m.ChainID, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("%w: chain id %q", ErrMalformed, value)
+			}
+		case "Nonce":
+			m.Nonce = value
+		case "Issued At":
+			if m.IssuedAt, err = time.Parse(time.RFC3339, value); err != nil {
+				return nil, fmt.Errorf("%w: issued at %q", ErrMalformed, value)
+			}
+		case "Expiration Time":
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: expiration time %q", ErrMalformed, value)
+			}
+			m.ExpirationTime = &t
+		case "Not Before":
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: not before %q", ErrMalformed, value)
+			}
+			m.NotBefore = &t
+		case "Request ID":
+			m.RequestID = value
+		default:
+			return nil, fmt.Errorf("%w: unknown field %q", ErrMalformed, key)
+		}
+	}
+
+	for _, f := range []string{"URI", "Version", "Chain ID", "Nonce", "Issued At"} {
+		if !seen[f] {
+			return nil, fmt.Errorf("%w: missing %s", ErrMalformed, f)
+		}
+	}
+	if m.Version != "1" {
+		return nil, ErrBadVersion
+	}
+	if !validNonce(m.Nonce) {
+		return nil, ErrNonceTooShort
+	}
+	return m, nil
+}
+
+func validNonce(n string) bool {
+	if len(n) < 8 {
+		return false
+	}
+	for _, r := range n {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// String 按 EIP-4361 格式输出消息
+func (m *Message) String() string {
+	var b strings.Builder
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme + "://")
+	}
+	b.WriteString(m.Domain + header + "\n" + m.Address.Hex() + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "URI: %s\nVersion: %s\nChain ID: %d\nNonce: %s\nIssued At: %s",
+		m.URI, m.Version, m.ChainID, m.Nonce, m.IssuedAt.UTC().Format(time.RFC3339))
+	if m.ExpirationTime != nil {
+		b.WriteString("\nExpiration Time: " + m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if m.NotBefore != nil {
+		b.WriteString("\nNot Before: " + m.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if m.RequestID != "" {
+		b.WriteString("\nRequest ID: " + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, r := range m.Resources {
+			b.WriteString("\n- " + r)
+		}
+	}
+	return b.String()
+}
+
+// Validate 校验域名与有效期，domain 为本站期望的域名（host[:port]）
+func (m *Message) Validate(domain string, now time.Time) error {
+	if !strings.EqualFold(m.Domain, domain) {
+		return ErrDomain
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return ErrExpired
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return ErrNotYetValid
+	}
+	return nil
+}
+
+// RecoverAddress 从 personal_sign（EIP-191）签名中恢复签名者地址，签名为 65 字节 hex，v 可为 0/1 或 27/28
+func RecoverAddress(message, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, ErrBadSignature
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return common.Address{}, ErrBadSignature
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Verify 解析消息、校验域名与有效期，并确认签名者即消息中的地址
+func Verify(text, signature, domain string, now time.Time) (*Message, error) {
+	m, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Validate(domain, now); err != nil {
+		return nil, err
+	}
+	signer, err := RecoverAddress(text, signature)
+	if err != nil {
+		return nil, err
+	}
+	if signer != m.Address {
+		return nil, ErrAddrMismatch
+	}
+	return m, nil
+}
@@ -0,0 +1,96 @@
+package siwe
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EIP-4361 规范中的示例消息
+const specExample = `service.invalid wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ServiceOrg Terms of Service: https://service.invalid/tos
+
+URI: https://service.invalid/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+func TestParse(t *testing.T) {
+	m, err := Parse(specExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Domain != "service.invalid" || m.Address.Hex() != "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2" ||
+		m.Statement != "I accept the ServiceOrg Terms of Service: https://service.invalid/tos" ||
+		m.URI != "https://service.invalid/login" || m.ChainID != 1 || m.Nonce != "32891756" ||
+		!m.IssuedAt.Equal(time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC)) || len(m.Resources) != 2 {
+		t.Errorf("unexpected message %+v", m)
+	}
+	if m.String() != specExample {
+		t.Errorf("round trip mismatch:\n%s", m.String())
+	}
+
+	for name, text := range map[string]string{
+		"lowercase address": "a.invalid wants you to sign in with your Ethereum account:\n0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2\n\nURI: https://a.invalid\nVersion: 1\nChain ID: 1\nNonce: 12345678\nIssued At: 2021-09-30T16:25:24Z",
+		"missing nonce":     "a.invalid wants you to sign in with your Ethereum account:\n0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\nURI: https://a.invalid\nVersion: 1\nChain ID: 1\nIssued At: 2021-09-30T16:25:24Z",
+		"bad header":        "hello\n0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+	} {
+		if _, err := Parse(text); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: expected ErrMalformed, got %v", name, err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	exp := now.Add(time.Minute)
+	m := &Message{
+		Domain:         "blog.test",
+		Address:        crypto.PubkeyToAddress(key.PublicKey),
+		URI:            "https://blog.test",
+		Version:        "1",
+		ChainID:        1,
+		Nonce:          "abcdef123456",
+		IssuedAt:       now,
+		ExpirationTime: &exp,
+	}
+	text := m.String()
+	sig, err := crypto.Sign(accounts.TextHash([]byte(text)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27 // 钱包返回的 v 为 27/28
+
+	if _, err := Verify(text, hexutil.Encode(sig), "blog.test", now); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if _, err := Verify(text, hexutil.Encode(sig), "evil.test", now); !errors.Is(err, ErrDomain) {
+		t.Errorf("expected ErrDomain, got %v", err)
+	}
+	if _, err := Verify(text, hexutil.Encode(sig), "blog.test", exp); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+	if _, err := Verify(text, "0x1234", "blog.test", now); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature, got %v", err)
+	}
+
+	other, _ := crypto.GenerateKey()
+	forged, _ := crypto.Sign(accounts.TextHash([]byte(text)), other)
+	if _, err := Verify(text, hexutil.Encode(forged), "blog.test", now); !errors.Is(err, ErrAddrMismatch) {
+		t.Errorf("expected ErrAddrMismatch, got %v", err)
+	}
+}
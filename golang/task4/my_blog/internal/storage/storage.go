@@ -0,0 +1,61 @@
+// Package storage 上传文件的存储后端。Storage 是可替换的对象存储接口，
+// 本地部署使用 Local（写入本地目录并由本服务提供静态访问），生产环境可使用任意 S3 兼容服务
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"my_blog/internal/conf"
+)
+
+// ErrNotExist 对象不存在
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// Storage 对象存储接口，key 为 "/" 分隔的相对路径
+type Storage interface {
+	// Put 写入对象，同名对象被覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open 读取对象，不存在时返回 ErrNotExist
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，不存在时视为成功
+	Delete(ctx context.Context, key string) error
+	// URL 对象的访问地址
+	URL(key string) string
+}
+
+// New 按配置创建存储后端
+func New(cfg conf.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return &Local{Dir: cfg.Dir, Prefix: cfg.URLPrefix}, nil
+	case "s3":
+		return &S3{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+			PublicURL: cfg.S3.PublicURL,
+			Client:    http.DefaultClient,
+		}, nil
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", cfg.Driver)
+	}
+}
+
+var keyPattern = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9._-]*(/[a-zA-Z0-9_-][a-zA-Z0-9._-]*)*$`)
+
+// checkKey 拒绝空路径、绝对路径和 .. 等可能逃出存储目录的 key
+func checkKey(key string) error {
+	if !keyPattern.MatchString(key) || strings.Contains(key, "..") {
+		return fmt.Errorf("storage: invalid key %q", key)
+	}
+	return nil
+}
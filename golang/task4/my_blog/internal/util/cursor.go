@@ -0,0 +1,53 @@
+package util
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor 游标格式错误
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor 将 (created_at, id) 编码为不透明的分页游标
+func EncodeCursor(t time.Time, id uint) string {
+	return EncodeKeyCursor(t.UnixNano(), id)
+}
+
+// DecodeCursor 解析 EncodeCursor 生成的游标
+func DecodeCursor(cursor string) (time.Time, uint, error) {
+	nanos, id, err := DecodeKeyCursor(cursor)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return time.Unix(0, nanos), id, nil
+}
+
+// EncodeKeyCursor 将 (排序键, id) 编码为游标，适用于按计数等整数排序的场景
+func EncodeKeyCursor(key int64, id uint) string {
+	raw := strconv.FormatInt(key, 10) + ":" + strconv.FormatUint(uint64(id), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeKeyCursor 解析 EncodeKeyCursor 生成的游标
+func DecodeKeyCursor(cursor string) (int64, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+	k, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return 0, 0, ErrInvalidCursor
+	}
+	key, err := strconv.ParseInt(k, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+	return key, uint(n), nil
+}
@@ -0,0 +1,146 @@
+package util
+
+import "strings"
+
+// 差异操作类型
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine 行级差异中的一行
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffLines 用 Myers 差分算法计算 a 到 b 的行级差异。N、M 为行数、D 为差异行数时，
+// 时间为 O((N+M)·D)，空间为 O(N+M)；调用方应限制输入的行数
+func DiffLines(a, b string) []DiffLine {
+	x, y := splitLines(a), splitLines(b)
+	out := make([]DiffLine, 0, len(x)+len(y))
+	return diff(out, x, y)
+}
+
+// diff 去掉公共前后缀后，在中间蛇形处把问题一分为二递归求解
+func diff(out []DiffLine, x, y []string) []DiffLine {
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	for _, line := range x[:prefix] {
+		out = append(out, DiffLine{Op: DiffEqual, Text: line})
+	}
+	x, y = x[prefix:], y[prefix:]
+
+	suffix := 0
+	for suffix < len(x) && suffix < len(y) && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+	common := x[len(x)-suffix:]
+	x, y = x[:len(x)-suffix], y[:len(y)-suffix]
+
+	if len(x) > 0 && len(y) > 0 {
+		if i, j, ok := middleSnake(x, y); ok {
+			out = diff(out, x[:i], y[:j])
+			out = diff(out, x[i:], y[j:])
+		} else {
+			out = appendOps(out, DiffDelete, x)
+			out = appendOps(out, DiffInsert, y)
+		}
+	} else {
+		out = appendOps(out, DiffDelete, x)
+		out = appendOps(out, DiffInsert, y)
+	}
+	return appendOps(out, DiffEqual, common)
+}
+
+func appendOps(out []DiffLine, op string, lines []string) []DiffLine {
+	for _, line := range lines {
+		out = append(out, DiffLine{Op: op, Text: line})
+	}
+	return out
+}
+
+// middleSnake 同时从两端搜索最短编辑路径，两个方向在某条对角线上相遇时返回分割点；
+// 没有任何公共行时返回 false
+func middleSnake(x, y []string) (int, int, bool) {
+	n, m := len(x), len(y)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	// vf[k] / vb[k] 为正向 / 反向在对角线 k 上走到的最远 x，-1 表示尚未到达
+	vf, vb := make([]int, 2*maxD+2), make([]int, 2*maxD+2)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+	delta := n - m
+	front := delta%2 != 0 // 差值为奇数时由正向检查相遇，否则由反向检查
+	// 越出边界的对角线不再搜索
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var px int
+			if k == -d || (k != d && vf[i-1] < vf[i+1]) {
+				px = vf[i+1]
+			} else {
+				px = vf[i-1] + 1
+			}
+			py := px - k
+			for px < n && py < m && x[px] == y[py] {
+				px++
+				py++
+			}
+			vf[i] = px
+			switch {
+			case px > n:
+				fEnd += 2
+			case py > m:
+				fStart += 2
+			case front:
+				if j := offset + delta - k; j >= 0 && j < len(vb) && vb[j] != -1 && px >= n-vb[j] {
+					return px, py, true
+				}
+			}
+		}
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			i := offset + k
+			var px int
+			if k == -d || (k != d && vb[i-1] < vb[i+1]) {
+				px = vb[i+1]
+			} else {
+				px = vb[i-1] + 1
+			}
+			py := px - k
+			for px < n && py < m && x[n-px-1] == y[m-py-1] {
+				px++
+				py++
+			}
+			vb[i] = px
+			switch {
+			case px > n:
+				bEnd += 2
+			case py > m:
+				bStart += 2
+			case !front:
+				if j := offset + delta - k; j >= 0 && j < len(vf) && vf[j] != -1 {
+					fx := vf[j]
+					if fx >= n-px {
+						return fx, offset + fx - j, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,105 @@
+package util
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected []DiffLine
+	}{
+		{
+			name:     "完全相同",
+			a:        "a\nb",
+			b:        "a\nb",
+			expected: []DiffLine{{DiffEqual, "a"}, {DiffEqual, "b"}},
+		},
+		{
+			name:     "修改中间一行",
+			a:        "a\nb\nc",
+			b:        "a\nB\nc",
+			expected: []DiffLine{{DiffEqual, "a"}, {DiffDelete, "b"}, {DiffInsert, "B"}, {DiffEqual, "c"}},
+		},
+		{
+			name:     "末尾追加",
+			a:        "a",
+			b:        "a\nb",
+			expected: []DiffLine{{DiffEqual, "a"}, {DiffInsert, "b"}},
+		},
+		{
+			name:     "从空到有",
+			a:        "",
+			b:        "a",
+			expected: []DiffLine{{DiffInsert, "a"}},
+		},
+		{
+			name:     "CRLF 与 LF 视为相同",
+			a:        "a\r\nb",
+			b:        "a\nb",
+			expected: []DiffLine{{DiffEqual, "a"}, {DiffEqual, "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffLines(tt.a, tt.b); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("DiffLines(%q, %q) = %v, expected %v", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
+
+// lcsLen 动态规划求最长公共子序列长度，作为最短差异的参照
+func lcsLen(x, y []string) int {
+	prev, cur := make([]int, len(y)+1), make([]int, len(y)+1)
+	for i := range x {
+		for j := range y {
+			if x[i] == y[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(y)]
+}
+
+// TestDiffLinesRandom 随机输入下差异能还原两侧文本，且公共行数最多
+func TestDiffLinesRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	gen := func() string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return strings.Join(lines, "\n")
+	}
+	for i := 0; i < 500; i++ {
+		a, b := gen(), gen()
+		var left, right []string
+		equal := 0
+		for _, l := range DiffLines(a, b) {
+			switch l.Op {
+			case DiffEqual:
+				left, right = append(left, l.Text), append(right, l.Text)
+				equal++
+			case DiffDelete:
+				left = append(left, l.Text)
+			case DiffInsert:
+				right = append(right, l.Text)
+			}
+		}
+		if strings.Join(left, "\n") != a || strings.Join(right, "\n") != b {
+			t.Fatalf("DiffLines(%q, %q) does not reproduce the inputs", a, b)
+		}
+		if want := lcsLen(splitLines(a), splitLines(b)); equal != want {
+			t.Fatalf("DiffLines(%q, %q) keeps %d equal lines, expected %d", a, b, equal, want)
+		}
+	}
+}
@@ -1,47 +1,147 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte("your-secret-key")
+const (
+	// AccessTokenTTL access token 有效期，短期有效，过期后用 refresh token 换取
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL refresh token 有效期
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
 
 // Claims 自定义 JWT 载荷
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成 JWT
-func GenerateToken(userID uint, username string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // 24 小时有效
+// GenerateToken 生成 JWT（access token），带唯一 jti 便于吊销
+func GenerateToken(userID uint, username, role string) (string, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := &Claims{
 		UserID:   userID,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	key := currentKeySet().current
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.sign)
 }
 
-// ParseToken 解析并验证 JWT
+// ParseToken 解析并验证 JWT：按 kid 选择验证密钥，alg 必须与该密钥的算法一致。
+// 带 aud 的一次性操作令牌不能当作 access token 使用
 func ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	ks := currentKeySet()
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, ks.keyFunc, jwt.WithValidMethods(ks.methods()))
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && len(claims.Audience) == 0 {
 		return claims, nil
 	}
 	return nil, errors.New("invalid token")
 }
+
+// keyFunc 按 kid 选择验证密钥
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q for kid %q", token.Method.Alg(), kid)
+	}
+	return key.verify, nil
+}
+
+// ActionToken 一次性操作令牌（如邮箱验证），aud 为用途，sub 为用户 ID。
+// 令牌本身只保证未被篡改且未过期，"只能使用一次"由调用方按 jti 在数据库中记录
+type ActionToken struct {
+	Token     string
+	JTI       string
+	UserID    uint
+	ExpiresAt time.Time
+}
+
+// GenerateActionToken 为某个用途签发一次性操作令牌
+func GenerateActionToken(userID uint, purpose string, ttl time.Duration) (*ActionToken, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expires := now.Add(ttl)
+	claims := jwt.RegisteredClaims{
+		ID:        jti,
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		Audience:  jwt.ClaimStrings{purpose},
+		ExpiresAt: jwt.NewNumericDate(expires),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+
+	key := currentKeySet().current
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	signed, err := token.SignedString(key.sign)
+	if err != nil {
+		return nil, err
+	}
+	return &ActionToken{Token: signed, JTI: jti, UserID: userID, ExpiresAt: expires}, nil
+}
+
+// ParseActionToken 验证一次性操作令牌的签名、有效期与用途
+func ParseActionToken(tokenString, purpose string) (*ActionToken, error) {
+	ks := currentKeySet()
+	var claims jwt.RegisteredClaims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, ks.keyFunc,
+		jwt.WithValidMethods(ks.methods()), jwt.WithAudience(purpose), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if !token.Valid || err != nil || claims.ID == "" {
+		return nil, errors.New("invalid token")
+	}
+	return &ActionToken{Token: tokenString, JTI: claims.ID, UserID: uint(userID), ExpiresAt: claims.ExpiresAt.Time}, nil
+}
+
+// RandomToken 生成 n 字节的随机串（hex 编码），用于 refresh token、jti 等
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken 对不透明 token 做 SHA-256，数据库中只保存哈希值
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
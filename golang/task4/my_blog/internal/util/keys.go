@@ -0,0 +1,211 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"my_blog/internal/conf"
+)
+
+// signingKey 一把 JWT 密钥：签名方法固定，验证时严格要求 alg 一致
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	sign   interface{} // 签名用密钥，仅验证的旧密钥为 nil
+	verify interface{} // 验证用密钥
+}
+
+// KeySet 当前签名密钥 + 所有可用于验证的密钥（按 kid 索引）
+type KeySet struct {
+	current *signingKey
+	keys    map[string]*signingKey
+}
+
+var (
+	keysMu sync.RWMutex
+	keySet = defaultKeySet()
+)
+
+// defaultKeySet 未配置 [jwt] 时使用的 HS256 密钥，每个进程随机生成，重启后已签发的令牌全部失效
+func defaultKeySet() *KeySet {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic("jwt: generate default key: " + err.Error())
+	}
+	k := &signingKey{id: "default", method: jwt.SigningMethodHS256, sign: secret, verify: secret}
+	return &KeySet{current: k, keys: map[string]*signingKey{k.id: k}}
+}
+
+// LoadKeySet 根据配置构建密钥集
+func LoadKeySet(cfg conf.JWTConfig) (*KeySet, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("jwt: no keys configured")
+	}
+
+	ks := &KeySet{keys: make(map[string]*signingKey, len(cfg.Keys))}
+	for _, kc := range cfg.Keys {
+		if kc.ID == "" {
+			return nil, errors.New("jwt: key id is required")
+		}
+		if _, dup := ks.keys[kc.ID]; dup {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", kc.ID)
+		}
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %q: %w", kc.ID, err)
+		}
+		ks.keys[kc.ID] = k
+	}
+
+	current, ok := ks.keys[cfg.SigningKey]
+	if !ok {
+		return nil, fmt.Errorf("jwt: signing key %q not found", cfg.SigningKey)
+	}
+	if current.sign == nil {
+		return nil, fmt.Errorf("jwt: signing key %q has no private key", cfg.SigningKey)
+	}
+	ks.current = current
+	return ks, nil
+}
+
+func loadKey(kc conf.JWTKeyConfig) (*signingKey, error) {
+	k := &signingKey{id: kc.ID}
+	switch kc.Algorithm {
+	case "HS256":
+		if kc.Secret == "" {
+			return nil, errors.New("secret is required for HS256")
+		}
+		k.method = jwt.SigningMethodHS256
+		k.sign = []byte(kc.Secret)
+		k.verify = k.sign
+
+	case "RS256":
+		k.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			k.sign, k.verify = priv, &priv.PublicKey
+		}
+		if kc.PublicKeyFile != "" {
+			pem, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			k.verify = pub
+		}
+
+	case "EdDSA":
+		k.method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			k.sign, k.verify = priv, priv.(ed25519.PrivateKey).Public()
+		}
+		if kc.PublicKeyFile != "" {
+			pem, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseEdPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			k.verify = pub
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", kc.Algorithm)
+	}
+
+	if k.verify == nil {
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+	return k, nil
+}
+
+// SetKeySet 替换全局密钥集
+func SetKeySet(ks *KeySet) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	keySet = ks
+}
+
+func currentKeySet() *KeySet {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return keySet
+}
+
+// JWK JSON Web Key，只包含公开部分
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS 导出所有非对称验证公钥，HS256 共享密钥永不公开
+func JWKS() map[string][]JWK {
+	ks := currentKeySet()
+	out := make([]JWK, 0, len(ks.keys))
+	for _, k := range ks.keys {
+		switch pub := k.verify.(type) {
+		case *rsa.PublicKey:
+			out = append(out, JWK{
+				Kty: "RSA", Kid: k.id, Use: "sig", Alg: k.method.Alg(),
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			out = append(out, JWK{
+				Kty: "OKP", Kid: k.id, Use: "sig", Alg: k.method.Alg(),
+				Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Kid < out[j].Kid })
+	return map[string][]JWK{"keys": out}
+}
+
+// methods 密钥集中出现的所有算法，供解析器做白名单校验
+func (ks *KeySet) methods() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, k := range ks.keys {
+		if alg := k.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			out = append(out, alg)
+		}
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"my_blog/internal/conf"
+)
+
+// writePEM 将密钥写入临时 PEM 文件并返回路径
+func writePEM(t *testing.T, name, typ string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testKeys(t *testing.T) (rsaKey, edKey conf.JWTKeyConfig) {
+	t.Helper()
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ek, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(ek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey = conf.JWTKeyConfig{ID: "rsa-1", Algorithm: "RS256",
+		PrivateKeyFile: writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rk))}
+	edKey = conf.JWTKeyConfig{ID: "ed-1", Algorithm: "EdDSA",
+		PrivateKeyFile: writePEM(t, "ed.pem", "PRIVATE KEY", edDER)}
+	return rsaKey, edKey
+}
+
+func useKeys(t *testing.T, cfg conf.JWTConfig) {
+	t.Helper()
+	ks, err := LoadKeySet(cfg)
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	old := currentKeySet()
+	SetKeySet(ks)
+	t.Cleanup(func() { SetKeySet(old) })
+}
+
+// TestKeyRotation 轮换签名密钥后，旧密钥签发的 token 仍可验证
+func TestKeyRotation(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	hsKey := conf.JWTKeyConfig{ID: "hs-1", Algorithm: "HS256", Secret: "s3cret"}
+
+	for _, signing := range []conf.JWTKeyConfig{hsKey, rsaKey, edKey} {
+		t.Run(signing.Algorithm, func(t *testing.T) {
+			useKeys(t, conf.JWTConfig{SigningKey: signing.ID, Keys: []conf.JWTKeyConfig{hsKey, rsaKey, edKey}})
+			token, err := GenerateToken(1, "alice", "author")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 切换到另一把签名密钥，旧 token 依然有效
+			useKeys(t, conf.JWTConfig{SigningKey: hsKey.ID, Keys: []conf.JWTKeyConfig{hsKey, rsaKey, edKey}})
+			claims, err := ParseToken(token)
+			if err != nil {
+				t.Fatalf("ParseToken after rotation: %v", err)
+			}
+			if claims.UserID != 1 || claims.Username != "alice" || claims.ID == "" {
+				t.Errorf("unexpected claims %+v", claims)
+			}
+
+			// 旧密钥下线后不再接受
+			if signing.ID != hsKey.ID {
+				useKeys(t, conf.JWTConfig{SigningKey: hsKey.ID, Keys: []conf.JWTKeyConfig{hsKey}})
+				if _, err := ParseToken(token); err == nil {
+					t.Error("expected token signed by retired key to be rejected")
+				}
+			}
+		})
+	}
+}
+
+// TestAlgorithmPinning 用 RSA 公钥当 HMAC 密钥伪造的 token 必须被拒绝
+func TestAlgorithmPinning(t *testing.T) {
+	rsaKey, _ := testKeys(t)
+	hsKey := conf.JWTKeyConfig{ID: "hs-1", Algorithm: "HS256", Secret: "s3cret"}
+	useKeys(t, conf.JWTConfig{SigningKey: rsaKey.ID, Keys: []conf.JWTKeyConfig{rsaKey, hsKey}})
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    string
+		key    interface{}
+	}{
+		{name: "HS256 冒充 RSA kid", method: jwt.SigningMethodHS256, kid: rsaKey.ID, key: []byte("s3cret")},
+		{name: "未知 kid", method: jwt.SigningMethodHS256, kid: "nope", key: []byte("s3cret")},
+		{name: "缺少 kid", method: jwt.SigningMethodHS256, kid: "", key: []byte("s3cret")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(tt.method, &Claims{UserID: 1})
+			if tt.kid != "" {
+				token.Header["kid"] = tt.kid
+			}
+			s, err := token.SignedString(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ParseToken(s); err == nil {
+				t.Error("expected token to be rejected")
+			}
+		})
+	}
+
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, &Claims{UserID: 1}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := ParseToken(none); err == nil {
+		t.Error("expected alg=none token to be rejected")
+	}
+}
+
+// TestJWKS JWKS 只导出非对称公钥
+func TestJWKS(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	hsKey := conf.JWTKeyConfig{ID: "hs-1", Algorithm: "HS256", Secret: "s3cret"}
+	useKeys(t, conf.JWTConfig{SigningKey: hsKey.ID, Keys: []conf.JWTKeyConfig{hsKey, rsaKey, edKey}})
+
+	keys := JWKS()["keys"]
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 public keys, got %d", len(keys))
+	}
+	if keys[0].Kid != "ed-1" || keys[0].Kty != "OKP" || keys[0].X == "" {
+		t.Errorf("unexpected EdDSA JWK %+v", keys[0])
+	}
+	if keys[1].Kid != "rsa-1" || keys[1].Kty != "RSA" || keys[1].N == "" || keys[1].E != "AQAB" {
+		t.Errorf("unexpected RSA JWK %+v", keys[1])
+	}
+}
+
+// TestDefaultKeySet 未配置密钥时使用随机密钥，用公开的占位密钥伪造的 token 无法通过验证
+func TestDefaultKeySet(t *testing.T) {
+	old := currentKeySet()
+	SetKeySet(defaultKeySet())
+	t.Cleanup(func() { SetKeySet(old) })
+
+	forgedToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1, Role: "admin"})
+	forgedToken.Header["kid"] = "default"
+	forged, err := forgedToken.SignedString([]byte("your-secret-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(forged); err == nil {
+		t.Error("expected token signed with the placeholder secret to be rejected")
+	}
+	token, err := GenerateToken(1, "alice", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(token); err != nil {
+		t.Errorf("expected token signed with the default key to verify, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA-1 测试向量
+func TestHOTPVectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	} {
+		if got := hotp(key, uint64(TOTPStep(time.Unix(tc.unix, 0))), 8); got != tc.want {
+			t.Errorf("T=%d: got %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	code, err := TOTPCode(secret, now)
+	if err != nil || len(code) != TOTPDigits {
+		t.Fatalf("TOTPCode: %q %v", code, err)
+	}
+
+	if step, ok := ValidateTOTP(secret, code, now); !ok || step != TOTPStep(now) {
+		t.Errorf("current code rejected: %d %v", step, ok)
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(TOTPPeriod)); !ok {
+		t.Error("code from the previous step should be accepted")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(3*TOTPPeriod)); ok {
+		t.Error("stale code should be rejected")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Error("short code should be rejected")
+	}
+
+	uri := TOTPURI("my blog", "alice@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/my%20blog:alice@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("unexpected uri %s", uri)
+	}
+}